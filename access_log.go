@@ -0,0 +1,280 @@
+// Copyright (c) 2015 Uber Technologies, Inc.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package tchannel
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/uber/tchannel-go/trand"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// AccessLogOptions configures per-call access logging.
+type AccessLogOptions struct {
+	// Writer receives an entry for each call as a line of JSON. If nil,
+	// entries are logged using the channel's Logger at info level.
+	Writer io.Writer
+
+	// SkipInbound disables access logs for inbound calls.
+	SkipInbound bool
+
+	// SkipOutbound disables access logs for outbound calls.
+	SkipOutbound bool
+
+	// SampleRate is the fraction of calls that are logged, between 0 and 1.
+	// If it is not set, all calls are logged.
+	SampleRate float64
+
+	// Methods restricts access logs to the given methods. Each entry may be
+	// a method name, or a service and method name in the form "service::method".
+	// If empty, all methods are logged.
+	Methods []string
+
+	// ExcludeMethods lists methods that are never logged, in the same form as Methods.
+	ExcludeMethods []string
+}
+
+// AccessLogEntry is the access log entry for a single call.
+type AccessLogEntry struct {
+	Time      time.Time `json:"time"`
+	Direction string    `json:"direction"`
+	Caller    string    `json:"caller"`
+	Service   string    `json:"service"`
+	Method    string    `json:"method"`
+	Format    string    `json:"format"`
+
+	// Peer is the host:port of the remote peer.
+	Peer string `json:"peer"`
+
+	Duration time.Duration `json:"duration"`
+
+	// RequestBytes and ResponseBytes are the sizes of the frame payloads
+	// that were sent or received for the call.
+	RequestBytes  int64 `json:"requestBytes"`
+	ResponseBytes int64 `json:"responseBytes"`
+
+	// Outcome is "success", "app-error", or the metrics key of the
+	// system error code for calls that failed with a system error.
+	Outcome string `json:"outcome"`
+
+	// TraceID is the hex-encoded trace ID, if the call was traced.
+	TraceID string `json:"traceID,omitempty"`
+}
+
+const (
+	accessLogSuccess  = "success"
+	accessLogAppError = "app-error"
+)
+
+// accessLogger writes access log entries, and is shared by all connections
+// of a channel, or all calls of a subchannel.
+type accessLogger struct {
+	opts    AccessLogOptions
+	logger  Logger
+	methods map[string]struct{}
+	exclude map[string]struct{}
+	rng     *rand.Rand
+
+	writerMut sync.Mutex
+}
+
+func newAccessLogger(opts *AccessLogOptions, logger Logger) *accessLogger {
+	if opts == nil {
+		return nil
+	}
+
+	al := &accessLogger{
+		opts:    *opts,
+		logger:  logger,
+		exclude: toStringSet(opts.ExcludeMethods),
+		rng:     trand.NewSeeded(),
+	}
+	if len(opts.Methods) > 0 {
+		al.methods = toStringSet(opts.Methods)
+	}
+	return al
+}
+
+func matchesMethod(set map[string]struct{}, service, method string) bool {
+	if _, ok := set[method]; ok {
+		return true
+	}
+	_, ok := set[service+"::"+method]
+	return ok
+}
+
+// enabled returns whether a call should be logged. It is nil-safe.
+func (al *accessLogger) enabled(dir connectionDirection, service, method string) bool {
+	if al == nil {
+		return false
+	}
+	if (dir == inbound && al.opts.SkipInbound) || (dir == outbound && al.opts.SkipOutbound) {
+		return false
+	}
+	if al.methods != nil && !matchesMethod(al.methods, service, method) {
+		return false
+	}
+	if matchesMethod(al.exclude, service, method) {
+		return false
+	}
+	if rate := al.opts.SampleRate; rate > 0 && rate < 1 {
+		return al.rng.Float64() < rate
+	}
+	return true
+}
+
+func (al *accessLogger) log(entry AccessLogEntry) {
+	if al.opts.Writer == nil {
+		al.logger.WithFields(
+			LogField{"direction", entry.Direction},
+			LogField{"caller", entry.Caller},
+			LogField{"service", entry.Service},
+			LogField{"method", entry.Method},
+			LogField{"format", entry.Format},
+			LogField{"peer", entry.Peer},
+			LogField{"duration", entry.Duration},
+			LogField{"requestBytes", entry.RequestBytes},
+			LogField{"responseBytes", entry.ResponseBytes},
+			LogField{"outcome", entry.Outcome},
+			LogField{"traceID", entry.TraceID},
+		).Info("Call completed.")
+		return
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		al.logger.WithFields(ErrField(err)).Warn("Failed to marshal access log entry.")
+		return
+	}
+	line = append(line, '\n')
+
+	al.writerMut.Lock()
+	defer al.writerMut.Unlock()
+	if _, err := al.opts.Writer.Write(line); err != nil {
+		al.logger.WithFields(ErrField(err)).Warn("Failed to write access log entry.")
+	}
+}
+
+// accessLoggerFor returns the access logger for calls to or from the given
+// service: the subchannel's access logger if it has one, or the channel's.
+func (ccc channelConnectionCommon) accessLoggerFor(service string) *accessLogger {
+	if ccc.subChannels.hasAccessLog.Load() {
+		if sc, ok := ccc.subChannels.get(service); ok {
+			sc.RLock()
+			al := sc.accessLog
+			sc.RUnlock()
+			if al != nil {
+				return al
+			}
+		}
+	}
+	return ccc.accessLog
+}
+
+// AccessLog is a SubChannelOption that logs every call to or from the
+// subchannel's service, instead of using the channel's access log options.
+func AccessLog(opts AccessLogOptions) SubChannelOption {
+	return func(s *SubChannel) {
+		s.Lock()
+		s.accessLog = newAccessLogger(&opts, s.logger)
+		s.Unlock()
+		s.topChannel.subChannels.hasAccessLog.Store(true)
+	}
+}
+
+func accessLogOutcome(appErr, sysErr bool, code SystemErrCode) string {
+	switch {
+	case sysErr:
+		return code.MetricsKey()
+	case appErr:
+		return accessLogAppError
+	default:
+		return accessLogSuccess
+	}
+}
+
+// logAccess writes an access log entry for the inbound call, if enabled.
+func (response *InboundCallResponse) logAccess(now time.Time, latency time.Duration) {
+	call := response.call
+	al := response.conn.accessLoggerFor(call.ServiceName())
+	if !al.enabled(inbound, call.ServiceName(), call.MethodString()) {
+		return
+	}
+
+	al.log(AccessLogEntry{
+		Time:          now,
+		Direction:     inbound.String(),
+		Caller:        call.CallerName(),
+		Service:       call.ServiceName(),
+		Method:        call.MethodString(),
+		Format:        call.Format().String(),
+		Peer:          response.conn.remotePeerInfo.HostPort,
+		Duration:      latency,
+		RequestBytes:  call.bytesRead,
+		ResponseBytes: response.bytesWritten,
+		Outcome:       accessLogOutcome(response.applicationError, response.systemError, response.systemErrCode),
+		TraceID:       accessLogTraceID(call.tracing, response.otelSpan),
+	})
+}
+
+// logAccess writes an access log entry for the outbound call attempt, if enabled.
+func (response *OutboundCallResponse) logAccess(now time.Time, latency time.Duration, unexpected error) {
+	call := response.call
+	service, method := response.callStatsKey.service, response.callStatsKey.method
+	al := call.conn.accessLoggerFor(service)
+	if !al.enabled(outbound, service, method) {
+		return
+	}
+
+	appErr := unexpected == nil && response.ApplicationError()
+	al.log(AccessLogEntry{
+		Time:          now,
+		Direction:     outbound.String(),
+		Caller:        call.callReq.Headers[CallerName],
+		Service:       service,
+		Method:        method,
+		Format:        call.callReq.Headers[ArgScheme],
+		Peer:          call.conn.remotePeerInfo.HostPort,
+		Duration:      latency,
+		RequestBytes:  call.bytesWritten,
+		ResponseBytes: response.bytesRead,
+		Outcome:       accessLogOutcome(appErr, unexpected != nil, GetSystemErrorCode(unexpected)),
+		TraceID:       accessLogTraceID(call.callReq.Tracing, response.otelSpan),
+	})
+}
+
+func accessLogTraceID(span Span, otelSpan trace.Span) string {
+	if otelSpan != nil {
+		if sc := otelSpan.SpanContext(); sc.HasTraceID() {
+			return sc.TraceID().String()
+		}
+	}
+	if span.TraceID() != 0 {
+		return fmt.Sprintf("%016x", span.TraceID())
+	}
+	return ""
+}
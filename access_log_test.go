@@ -0,0 +1,209 @@
+// Copyright (c) 2015 Uber Technologies, Inc.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package tchannel_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/uber/tchannel-go"
+
+	"github.com/uber/tchannel-go/raw"
+	"github.com/uber/tchannel-go/testutils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// accessLogBuffer is a concurrency-safe buffer of access log lines.
+type accessLogBuffer struct {
+	sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *accessLogBuffer) Write(p []byte) (int, error) {
+	b.Lock()
+	defer b.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *accessLogBuffer) entries(t testing.TB) []AccessLogEntry {
+	b.Lock()
+	defer b.Unlock()
+
+	var entries []AccessLogEntry
+	scanner := bufio.NewScanner(bytes.NewReader(b.buf.Bytes()))
+	for scanner.Scan() {
+		var entry AccessLogEntry
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &entry), "Failed to unmarshal access log line")
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func waitForAccessLogs(t testing.TB, b *accessLogBuffer, n int) []AccessLogEntry {
+	var entries []AccessLogEntry
+	testutils.WaitFor(time.Second, func() bool {
+		entries = b.entries(t)
+		return len(entries) >= n
+	})
+	require.Len(t, entries, n, "Unexpected number of access log entries")
+	return entries
+}
+
+func TestAccessLog(t *testing.T) {
+	serverLog := &accessLogBuffer{}
+	// Trace IDs are only logged when calls are traced.
+	opts, provider, _ := otelTestOpts()
+	opts.AccessLog = &AccessLogOptions{Writer: serverLog, SkipOutbound: true}
+
+	testutils.WithTestServer(t, opts, func(t testing.TB, ts *testutils.TestServer) {
+		ts.Register(raw.Wrap(newTestHandler(t)), "echo")
+		ts.Register(raw.Wrap(newTestHandler(t)), "app-error")
+		ts.Register(raw.Wrap(newTestHandler(t)), "busy")
+
+		clientLog := &accessLogBuffer{}
+		clientOpts := testutils.NewOpts()
+		clientOpts.TracerProvider = provider
+		clientOpts.AccessLog = &AccessLogOptions{Writer: clientLog, SkipInbound: true}
+		client := ts.NewClient(clientOpts)
+
+		ctx, cancel := NewContext(time.Second)
+		defer cancel()
+
+		arg3 := testutils.RandBytes(1000)
+		_, _, _, err := raw.Call(ctx, client, ts.HostPort(), ts.ServiceName(), "echo", []byte("arg2"), arg3)
+		require.NoError(t, err, "echo failed")
+		_, _, _, err = raw.Call(ctx, client, ts.HostPort(), ts.ServiceName(), "app-error", nil, nil)
+		require.NoError(t, err, "app-error failed")
+		_, _, _, err = raw.Call(ctx, client, ts.HostPort(), ts.ServiceName(), "busy", nil, nil)
+		require.Error(t, err, "busy should fail")
+
+		wantOutcomes := []string{"success", "app-error", "busy"}
+		for _, tt := range []struct {
+			log       *accessLogBuffer
+			direction string
+			peer      string
+		}{
+			{serverLog, "inbound", client.PeerInfo().HostPort},
+			{clientLog, "outbound", ts.HostPort()},
+		} {
+			entries := waitForAccessLogs(t, tt.log, len(wantOutcomes))
+			for i, entry := range entries {
+				assert.Equal(t, tt.direction, entry.Direction, "Unexpected direction")
+				assert.Equal(t, client.ServiceName(), entry.Caller, "Unexpected caller")
+				assert.Equal(t, ts.ServiceName(), entry.Service, "Unexpected service")
+				assert.Equal(t, "raw", entry.Format, "Unexpected format")
+				assert.Equal(t, wantOutcomes[i], entry.Outcome, "Unexpected outcome for %v", entry.Method)
+				assert.NotEmpty(t, entry.TraceID, "Missing trace ID")
+				assert.False(t, entry.Time.IsZero(), "Missing time")
+				if tt.direction == "outbound" {
+					assert.Equal(t, tt.peer, entry.Peer, "Unexpected peer")
+				}
+			}
+
+			echo := entries[0]
+			assert.Equal(t, "echo", echo.Method, "Unexpected method")
+			assert.True(t, echo.RequestBytes > int64(len(arg3)), "Request bytes %v should include args", echo.RequestBytes)
+			assert.True(t, echo.ResponseBytes > int64(len(arg3)), "Response bytes %v should include args", echo.ResponseBytes)
+		}
+	})
+}
+
+func TestAccessLogMethodFilter(t *testing.T) {
+	serverLog := &accessLogBuffer{}
+	opts := testutils.NewOpts().NoRelay()
+	opts.AccessLog = &AccessLogOptions{
+		Writer:         serverLog,
+		Methods:        []string{"echo", "testService::app-error"},
+		ExcludeMethods: []string{"testService::echo"},
+	}
+
+	testutils.WithTestServer(t, opts, func(t testing.TB, ts *testutils.TestServer) {
+		ts.Register(raw.Wrap(newTestHandler(t)), "echo")
+		ts.Register(raw.Wrap(newTestHandler(t)), "app-error")
+
+		ctx, cancel := NewContext(time.Second)
+		defer cancel()
+
+		client := ts.NewClient(nil)
+		for _, method := range []string{"echo", "app-error", "echo"} {
+			_, _, _, err := raw.Call(ctx, client, ts.HostPort(), ts.ServiceName(), method, nil, nil)
+			require.NoError(t, err, "%v failed", method)
+		}
+
+		entries := waitForAccessLogs(t, serverLog, 1)
+		assert.Equal(t, "app-error", entries[0].Method, "Only app-error should be logged")
+	})
+}
+
+func TestAccessLogSubChannel(t *testing.T) {
+	var logBuf bytes.Buffer
+	var logMut sync.Mutex
+	logger := NewLogger(writerFunc(func(p []byte) (int, error) {
+		logMut.Lock()
+		defer logMut.Unlock()
+		return logBuf.Write(p)
+	}))
+
+	subLog := &accessLogBuffer{}
+	opts := testutils.NewOpts().NoRelay()
+	opts.Logger = logger
+	opts.AccessLog = &AccessLogOptions{SkipOutbound: true}
+
+	testutils.WithTestServer(t, opts, func(t testing.TB, ts *testutils.TestServer) {
+		ts.Register(raw.Wrap(newTestHandler(t)), "echo")
+		sc := ts.Server().GetSubChannel("other", AccessLog(AccessLogOptions{Writer: subLog}))
+		sc.Register(raw.Wrap(newTestHandler(t)), "echo")
+
+		ctx, cancel := NewContext(time.Second)
+		defer cancel()
+
+		client := ts.NewClient(nil)
+		for _, service := range []string{ts.ServiceName(), "other"} {
+			_, _, _, err := raw.Call(ctx, client, ts.HostPort(), service, "echo", nil, nil)
+			require.NoError(t, err, "Call to %v failed", service)
+		}
+
+		entries := waitForAccessLogs(t, subLog, 1)
+		assert.Equal(t, "other", entries[0].Service, "Subchannel should use its own access log")
+
+		// Without a writer, the channel's calls are logged using the Logger.
+		assert.True(t, testutils.WaitFor(time.Second, func() bool {
+			logMut.Lock()
+			defer logMut.Unlock()
+			return strings.Contains(logBuf.String(), "Call completed.")
+		}), "Missing access log in Logger output")
+		logMut.Lock()
+		defer logMut.Unlock()
+		assert.Equal(t, 1, strings.Count(logBuf.String(), "Call completed."), "Unexpected access logs")
+		assert.Contains(t, logBuf.String(), "{service "+ts.ServiceName()+"}", "Missing service field")
+	})
+}
+
+type writerFunc func(p []byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) { return f(p) }
@@ -0,0 +1,371 @@
+// Copyright (c) 2015 Uber Technologies, Inc.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package tchannel
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/atomic"
+)
+
+const (
+	_defaultCallStatsWindow     = time.Minute
+	_defaultCallStatsMaxMethods = 1000
+
+	// _callStatsMinWindow is the smallest window, so each slot is at least
+	// a millisecond.
+	_callStatsMinWindow = _callStatsSlots * time.Millisecond
+
+	// _callStatsOther is used as the service and method for calls that are
+	// not tracked separately since MaxMethods has been reached.
+	_callStatsOther = "_other"
+
+	// _callStatsSlots is the number of slots a window is split into. Stats
+	// expire one slot at a time, so a snapshot covers between
+	// (slots-1)/slots and all of the window.
+	_callStatsSlots = 6
+
+	// Latencies are tracked in buckets with exponentially growing bounds,
+	// from _callStatsMinLatency up to _callStatsMinLatency << (_callStatsLatencyBuckets-2).
+	// The last bucket has no upper bound.
+	_callStatsMinLatency     = 100 * time.Microsecond
+	_callStatsLatencyBuckets = 22
+)
+
+// CallStatsOptions configures in-memory per-method call statistics.
+type CallStatsOptions struct {
+	// Enabled keeps call counts, error counts and latency percentiles for
+	// each service, method and direction, which are reported by IntrospectState.
+	Enabled bool
+
+	// Window is the duration of the rolling window that stats are kept for.
+	// Defaults to 1 minute, and windows shorter than 6ms are rounded up.
+	Window time.Duration
+
+	// MaxMethods is the maximum number of (service, method, direction)
+	// combinations that are tracked separately. Methods without calls for
+	// a window are removed, and once the maximum is reached, calls to new
+	// methods are tracked with "_other" as the service and method.
+	// Defaults to 1000.
+	MaxMethods int
+}
+
+// CallStatsRuntimeState is a snapshot of the per-method call statistics.
+type CallStatsRuntimeState struct {
+	// Window is the duration of the rolling window covered by the stats.
+	Window time.Duration `json:"window"`
+
+	// Methods contains stats for every method with calls in the window,
+	// sorted by service, method and direction.
+	Methods []MethodStatsRuntimeState `json:"methods"`
+}
+
+// MethodStatsRuntimeState is the call statistics for a single method and direction.
+type MethodStatsRuntimeState struct {
+	Service   string `json:"service"`
+	Method    string `json:"method"`
+	Direction string `json:"direction"`
+
+	// Calls is the number of calls, including failed calls.
+	Calls int64 `json:"calls"`
+
+	// AppErrors is the number of calls that returned an application error.
+	AppErrors int64 `json:"appErrors"`
+
+	// SystemErrors is the number of calls that failed with a system error,
+	// keyed by the error code's metrics key.
+	SystemErrors map[string]int64 `json:"systemErrors,omitempty"`
+
+	// Latency percentiles are estimated from exponential buckets, so they
+	// are approximate.
+	LatencyP50 time.Duration `json:"latencyP50"`
+	LatencyP90 time.Duration `json:"latencyP90"`
+	LatencyP99 time.Duration `json:"latencyP99"`
+}
+
+type callStatsKey struct {
+	service   string
+	method    string
+	direction connectionDirection
+}
+
+// callStatsSlot holds the stats for calls that completed in a single slot of the window.
+type callStatsSlot struct {
+	// index identifies the slot's time range, it is the completion time
+	// divided by the slot duration.
+	index        int64
+	calls        int64
+	appErrors    int64
+	systemErrors map[SystemErrCode]int64
+	latencies    [_callStatsLatencyBuckets]int64
+}
+
+type methodStats struct {
+	sync.Mutex
+	slots [_callStatsSlots]callStatsSlot
+
+	// lastIndex is the slot index of the most recent call, used to remove
+	// idle methods. Once removed is set, calls must not be recorded.
+	lastIndex int64
+	removed   bool
+}
+
+// callStats keeps per-method call statistics for a channel over a rolling window.
+type callStats struct {
+	window     time.Duration
+	slotSize   time.Duration
+	maxMethods int
+	timeNow    func() time.Time
+
+	// lastSweep is the slot index when idle methods were last removed.
+	lastSweep atomic.Int64
+
+	mut     sync.RWMutex
+	methods map[callStatsKey]*methodStats
+}
+
+func newCallStats(opts CallStatsOptions, timeNow func() time.Time) *callStats {
+	if !opts.Enabled {
+		return nil
+	}
+
+	window := opts.Window
+	if window <= 0 {
+		window = _defaultCallStatsWindow
+	} else if window < _callStatsMinWindow {
+		window = _callStatsMinWindow
+	}
+	maxMethods := opts.MaxMethods
+	if maxMethods <= 0 {
+		maxMethods = _defaultCallStatsMaxMethods
+	}
+	return &callStats{
+		window:     window,
+		slotSize:   window / _callStatsSlots,
+		maxMethods: maxMethods,
+		timeNow:    timeNow,
+		methods:    make(map[callStatsKey]*methodStats),
+	}
+}
+
+func (cs *callStats) getMethod(key callStatsKey) *methodStats {
+	cs.mut.RLock()
+	ms, ok := cs.methods[key]
+	cs.mut.RUnlock()
+	if ok {
+		return ms
+	}
+
+	cs.mut.Lock()
+	defer cs.mut.Unlock()
+	if ms, ok := cs.methods[key]; ok {
+		return ms
+	}
+	if len(cs.methods) >= cs.maxMethods {
+		key = callStatsKey{_callStatsOther, _callStatsOther, key.direction}
+		if ms, ok := cs.methods[key]; ok {
+			return ms
+		}
+	}
+	ms = &methodStats{}
+	cs.methods[key] = ms
+	return ms
+}
+
+// maybeRemoveIdle removes methods without calls in the window ending at
+// index, at most once per slot.
+func (cs *callStats) maybeRemoveIdle(index int64) {
+	last := cs.lastSweep.Load()
+	if last >= index || !cs.lastSweep.CAS(last, index) {
+		return
+	}
+
+	minIndex := index - _callStatsSlots + 1
+
+	cs.mut.Lock()
+	defer cs.mut.Unlock()
+	for k, ms := range cs.methods {
+		ms.Lock()
+		if ms.lastIndex < minIndex {
+			ms.removed = true
+			delete(cs.methods, k)
+		}
+		ms.Unlock()
+	}
+}
+
+// record adds a completed call to the stats. errCode is only used if sysErr is set.
+// It is a no-op if call stats are not enabled.
+func (cs *callStats) record(key callStatsKey, latency time.Duration, appErr, sysErr bool, errCode SystemErrCode) {
+	if cs == nil {
+		return
+	}
+
+	index := cs.timeNow().UnixNano() / int64(cs.slotSize)
+	cs.maybeRemoveIdle(index)
+
+	ms := cs.getMethod(key)
+	ms.Lock()
+	for ms.removed {
+		// The method was removed after getMethod returned it, so get a new one.
+		ms.Unlock()
+		ms = cs.getMethod(key)
+		ms.Lock()
+	}
+	defer ms.Unlock()
+
+	ms.lastIndex = index
+	slot := &ms.slots[index%_callStatsSlots]
+	if slot.index != index {
+		*slot = callStatsSlot{index: index}
+	}
+
+	slot.calls++
+	slot.latencies[latencyBucket(latency)]++
+	if sysErr {
+		if slot.systemErrors == nil {
+			slot.systemErrors = make(map[SystemErrCode]int64)
+		}
+		slot.systemErrors[errCode]++
+	} else if appErr {
+		slot.appErrors++
+	}
+}
+
+// IntrospectState returns a snapshot of the stats for calls in the current window.
+func (cs *callStats) IntrospectState() *CallStatsRuntimeState {
+	if cs == nil {
+		return nil
+	}
+
+	minIndex := cs.timeNow().UnixNano()/int64(cs.slotSize) - _callStatsSlots + 1
+
+	cs.mut.RLock()
+	keys := make([]callStatsKey, 0, len(cs.methods))
+	methods := make([]*methodStats, 0, len(cs.methods))
+	for k, ms := range cs.methods {
+		keys = append(keys, k)
+		methods = append(methods, ms)
+	}
+	cs.mut.RUnlock()
+
+	state := &CallStatsRuntimeState{Window: cs.window}
+	for i, ms := range methods {
+		if s, ok := ms.introspect(keys[i], minIndex); ok {
+			state.Methods = append(state.Methods, s)
+		}
+	}
+
+	sort.Slice(state.Methods, func(i, j int) bool {
+		a, b := state.Methods[i], state.Methods[j]
+		if a.Service != b.Service {
+			return a.Service < b.Service
+		}
+		if a.Method != b.Method {
+			return a.Method < b.Method
+		}
+		return a.Direction < b.Direction
+	})
+	return state
+}
+
+// introspect merges all slots with an index of at least minIndex.
+// It returns false if there were no calls in those slots.
+func (ms *methodStats) introspect(key callStatsKey, minIndex int64) (MethodStatsRuntimeState, bool) {
+	state := MethodStatsRuntimeState{
+		Service:   key.service,
+		Method:    key.method,
+		Direction: key.direction.String(),
+	}
+	var latencies [_callStatsLatencyBuckets]int64
+
+	ms.Lock()
+	for i := range ms.slots {
+		slot := &ms.slots[i]
+		if slot.calls == 0 || slot.index < minIndex {
+			continue
+		}
+
+		state.Calls += slot.calls
+		state.AppErrors += slot.appErrors
+		for code, n := range slot.systemErrors {
+			if state.SystemErrors == nil {
+				state.SystemErrors = make(map[string]int64)
+			}
+			state.SystemErrors[code.MetricsKey()] += n
+		}
+		for b, n := range slot.latencies {
+			latencies[b] += n
+		}
+	}
+	ms.Unlock()
+
+	if state.Calls == 0 {
+		return state, false
+	}
+
+	state.LatencyP50 = latencyPercentile(&latencies, state.Calls, 0.5)
+	state.LatencyP90 = latencyPercentile(&latencies, state.Calls, 0.9)
+	state.LatencyP99 = latencyPercentile(&latencies, state.Calls, 0.99)
+	return state, true
+}
+
+// latencyBucketBounds returns the lower and upper bounds of the given bucket.
+func latencyBucketBounds(b int) (time.Duration, time.Duration) {
+	if b == 0 {
+		return 0, _callStatsMinLatency
+	}
+	lower := _callStatsMinLatency << uint(b-1)
+	if b == _callStatsLatencyBuckets-1 {
+		return lower, lower
+	}
+	return lower, lower * 2
+}
+
+func latencyBucket(d time.Duration) int {
+	b := 0
+	for bound := _callStatsMinLatency; d >= bound && b < _callStatsLatencyBuckets-1; bound *= 2 {
+		b++
+	}
+	return b
+}
+
+// latencyPercentile estimates the latency at percentile p by interpolating
+// within the bucket that contains it.
+func latencyPercentile(latencies *[_callStatsLatencyBuckets]int64, total int64, p float64) time.Duration {
+	rank := p * float64(total)
+	var seen int64
+	for b, n := range latencies {
+		if n == 0 || float64(seen+n) < rank {
+			seen += n
+			continue
+		}
+
+		lower, upper := latencyBucketBounds(b)
+		fraction := (rank - float64(seen)) / float64(n)
+		return lower + time.Duration(fraction*float64(upper-lower))
+	}
+
+	_, upper := latencyBucketBounds(_callStatsLatencyBuckets - 1)
+	return upper
+}
@@ -0,0 +1,131 @@
+// Copyright (c) 2015 Uber Technologies, Inc.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package tchannel
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLatencyBucket(t *testing.T) {
+	tests := []struct {
+		latency time.Duration
+		want    int
+	}{
+		{0, 0},
+		{99 * time.Microsecond, 0},
+		{100 * time.Microsecond, 1},
+		{199 * time.Microsecond, 1},
+		{200 * time.Microsecond, 2},
+		{time.Hour, _callStatsLatencyBuckets - 1},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, latencyBucket(tt.latency), "Unexpected bucket for %v", tt.latency)
+	}
+}
+
+func TestCallStatsPercentiles(t *testing.T) {
+	now := time.Unix(1000, 0)
+	cs := newCallStats(CallStatsOptions{Enabled: true}, func() time.Time { return now })
+	key := callStatsKey{"svc", "method", inbound}
+
+	// 90 calls at ~1ms, and 10 calls at ~100ms.
+	for i := 0; i < 90; i++ {
+		cs.record(key, time.Millisecond, false, false, ErrCodeInvalid)
+	}
+	for i := 0; i < 10; i++ {
+		cs.record(key, 100*time.Millisecond, false, true, ErrCodeTimeout)
+	}
+
+	state := cs.IntrospectState()
+	require.Len(t, state.Methods, 1, "Expected stats for a single method")
+	got := state.Methods[0]
+	assert.EqualValues(t, 100, got.Calls, "Unexpected calls")
+	assert.Equal(t, map[string]int64{"timeout": 10}, got.SystemErrors, "Unexpected system errors")
+
+	// 1ms falls in [800us, 1.6ms) and 100ms falls in [51.2ms, 102.4ms).
+	assert.True(t, got.LatencyP50 >= 800*time.Microsecond && got.LatencyP50 < 1600*time.Microsecond,
+		"Unexpected p50: %v", got.LatencyP50)
+	assert.True(t, got.LatencyP90 >= 800*time.Microsecond && got.LatencyP90 <= 1600*time.Microsecond,
+		"Unexpected p90: %v", got.LatencyP90)
+	assert.True(t, got.LatencyP99 >= 51200*time.Microsecond && got.LatencyP99 < 102400*time.Microsecond,
+		"Unexpected p99: %v", got.LatencyP99)
+
+	// Calls expire one slot at a time as the window moves.
+	now = now.Add(50 * time.Second)
+	cs.record(key, time.Millisecond, false, false, ErrCodeInvalid)
+	now = now.Add(20 * time.Second)
+	state = cs.IntrospectState()
+	require.Len(t, state.Methods, 1, "Expected stats for a single method")
+	assert.EqualValues(t, 1, state.Methods[0].Calls, "Expected older calls to expire")
+}
+
+func TestCallStatsMinWindow(t *testing.T) {
+	now := time.Unix(1000, 0)
+	cs := newCallStats(CallStatsOptions{Enabled: true, Window: time.Nanosecond}, func() time.Time { return now })
+	assert.Equal(t, _callStatsMinWindow, cs.window, "Window should be rounded up")
+
+	cs.record(callStatsKey{"svc", "method", inbound}, time.Millisecond, false, false, ErrCodeInvalid)
+	state := cs.IntrospectState()
+	require.Len(t, state.Methods, 1, "Expected stats for a single method")
+	assert.EqualValues(t, 1, state.Methods[0].Calls, "Unexpected calls")
+}
+
+func TestCallStatsRemoveIdle(t *testing.T) {
+	now := time.Unix(1000, 0)
+	cs := newCallStats(CallStatsOptions{Enabled: true}, func() time.Time { return now })
+
+	cs.record(callStatsKey{"svc", "idle", inbound}, time.Millisecond, false, false, ErrCodeInvalid)
+	cs.record(callStatsKey{"svc", "active", inbound}, time.Millisecond, false, false, ErrCodeInvalid)
+
+	now = now.Add(50 * time.Second)
+	cs.record(callStatsKey{"svc", "active", inbound}, time.Millisecond, false, false, ErrCodeInvalid)
+	assert.Len(t, cs.methods, 2, "Methods with calls in the window should not be removed")
+
+	now = now.Add(20 * time.Second)
+	cs.record(callStatsKey{"svc", "active", inbound}, time.Millisecond, false, false, ErrCodeInvalid)
+	assert.Len(t, cs.methods, 1, "Idle method should be removed")
+	assert.Contains(t, cs.methods, callStatsKey{"svc", "active", inbound}, "Active method should be kept")
+}
+
+func TestCallStatsMaxMethods(t *testing.T) {
+	now := time.Unix(1000, 0)
+	cs := newCallStats(CallStatsOptions{Enabled: true, MaxMethods: 2}, func() time.Time { return now })
+
+	for _, method := range []string{"m1", "m2", "m3", "m4", "m1"} {
+		cs.record(callStatsKey{"svc", method, inbound}, time.Millisecond, false, false, ErrCodeInvalid)
+	}
+
+	state := cs.IntrospectState()
+	calls := make(map[string]int64)
+	for _, m := range state.Methods {
+		calls[m.Service+"::"+m.Method] = m.Calls
+	}
+	assert.Equal(t, map[string]int64{
+		"svc::m1":        2,
+		"svc::m2":        1,
+		"_other::_other": 2,
+	}, calls, "Unexpected calls per method")
+}
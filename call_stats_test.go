@@ -0,0 +1,137 @@
+// Copyright (c) 2015 Uber Technologies, Inc.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package tchannel_test
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/uber/tchannel-go"
+
+	"github.com/uber/tchannel-go/json"
+	"github.com/uber/tchannel-go/raw"
+	"github.com/uber/tchannel-go/testutils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func findMethodStats(state *CallStatsRuntimeState, service, method, direction string) (MethodStatsRuntimeState, bool) {
+	for _, s := range state.Methods {
+		if s.Service == service && s.Method == method && s.Direction == direction {
+			return s, true
+		}
+	}
+	return MethodStatsRuntimeState{}, false
+}
+
+func TestCallStats(t *testing.T) {
+	clock := testutils.NewStubClock(time.Date(2015, 2, 1, 10, 10, 0, 0, time.UTC))
+	callStatsOpts := CallStatsOptions{Enabled: true, Window: time.Minute}
+	opts := testutils.NewOpts().
+		NoRelay().
+		SetTimeNow(clock.Now).
+		SetCallStats(callStatsOpts)
+
+	testutils.WithTestServer(t, opts, func(t testing.TB, ts *testutils.TestServer) {
+		ts.Register(raw.Wrap(newTestHandler(t)), "echo")
+		ts.Register(raw.Wrap(newTestHandler(t)), "app-error")
+		ts.Register(raw.Wrap(newTestHandler(t)), "busy")
+
+		client := ts.NewClient(testutils.NewOpts().
+			SetTimeNow(clock.Now).
+			SetCallStats(callStatsOpts))
+
+		ctx, cancel := NewContext(time.Second)
+		defer cancel()
+
+		for i := 0; i < 3; i++ {
+			_, _, _, err := raw.Call(ctx, client, ts.HostPort(), ts.ServiceName(), "echo", nil, nil)
+			require.NoError(t, err, "echo failed")
+		}
+		_, _, resp, err := raw.Call(ctx, client, ts.HostPort(), ts.ServiceName(), "app-error", nil, nil)
+		require.NoError(t, err, "app-error failed")
+		require.True(t, resp.ApplicationError(), "Expected application error")
+		_, _, _, err = raw.Call(ctx, client, ts.HostPort(), ts.ServiceName(), "busy", nil, nil)
+		require.Error(t, err, "busy should fail")
+
+		wantStats := []struct {
+			method       string
+			calls        int64
+			appErrors    int64
+			systemErrors map[string]int64
+		}{
+			{method: "app-error", calls: 1, appErrors: 1},
+			{method: "busy", calls: 1, systemErrors: map[string]int64{"busy": 1}},
+			{method: "echo", calls: 3},
+		}
+
+		for _, tt := range []struct {
+			ch        *Channel
+			direction string
+		}{
+			{ts.Server(), "inbound"},
+			{client, "outbound"},
+		} {
+			var state *CallStatsRuntimeState
+			// Inbound stats are recorded after the response is sent.
+			testutils.WaitFor(time.Second, func() bool {
+				state = tt.ch.IntrospectState(nil).CallStats
+				busy, _ := findMethodStats(state, ts.ServiceName(), "busy", tt.direction)
+				return busy.Calls > 0
+			})
+			require.NotNil(t, state, "Missing call stats for %v", tt.direction)
+			assert.Equal(t, time.Minute, state.Window, "Unexpected window")
+
+			for _, want := range wantStats {
+				got, ok := findMethodStats(state, ts.ServiceName(), want.method, tt.direction)
+				require.True(t, ok, "Missing %v stats for %v", tt.direction, want.method)
+				assert.Equal(t, want.calls, got.Calls, "Unexpected calls for %v %v", tt.direction, want.method)
+				assert.Equal(t, want.appErrors, got.AppErrors, "Unexpected app errors for %v %v", tt.direction, want.method)
+				assert.Equal(t, want.systemErrors, got.SystemErrors, "Unexpected system errors for %v %v", tt.direction, want.method)
+			}
+		}
+
+		// Stats are exposed through the introspection endpoint.
+		jsonCtx, jsonCancel := json.NewContext(time.Second)
+		defer jsonCancel()
+		var introspected struct {
+			CallStats *CallStatsRuntimeState `json:"callStats"`
+		}
+		peer := client.Peers().GetOrAdd(ts.HostPort())
+		require.NoError(t, json.CallPeer(jsonCtx, peer, "tchannel", "_gometa_introspect", nil, &introspected),
+			"Call _gometa_introspect failed")
+		require.NotNil(t, introspected.CallStats, "Missing call stats in introspection response")
+		echo, ok := findMethodStats(introspected.CallStats, ts.ServiceName(), "echo", "inbound")
+		require.True(t, ok, "Missing echo stats in introspection response")
+		assert.EqualValues(t, 3, echo.Calls, "Unexpected echo calls in introspection response")
+
+		// Once the window passes, stats are dropped.
+		clock.Elapse(2 * time.Minute)
+		assert.Empty(t, client.IntrospectState(nil).CallStats.Methods, "Expected stats to expire")
+	})
+}
+
+func TestCallStatsDisabled(t *testing.T) {
+	testutils.WithTestServer(t, nil, func(t testing.TB, ts *testutils.TestServer) {
+		assert.Nil(t, ts.Server().IntrospectState(nil).CallStats, "Call stats should be disabled by default")
+	})
+}
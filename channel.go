@@ -34,6 +34,7 @@ import (
 	"github.com/uber/tchannel-go/tnet"
 
 	"github.com/opentracing/opentracing-go"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/atomic"
 	"golang.org/x/net/context"
 )
@@ -66,6 +67,11 @@ type ChannelOptions struct {
 	// The logger to use for this channel
 	Logger Logger
 
+	// LogSampling limits how often similar messages are logged by the channel,
+	// see NewSampledLogger. If TimeNow is not set, the channel's TimeNow is used.
+	// By default, all messages are logged.
+	LogSampling *LogSamplingOptions
+
 	// The host:port selection implementation to use for relaying. This is an
 	// unstable API - breaking changes are likely.
 	RelayHost RelayHost
@@ -94,9 +100,24 @@ type ChannelOptions struct {
 	// This is an unstable API - breaking changes are likely.
 	RelayTimerVerification bool
 
+	// RelayTracing enables a tracing span for every relayed call, using the
+	// channel's tracer. The relayed frame's tracing fields are rewritten so
+	// the destination's span is a child of the relay span.
+	// OpenTracing tracers must support Zipkin-style span IDs.
+	RelayTracing bool
+
 	// The reporter to use for reporting stats for this channel.
 	StatsReporter StatsReporter
 
+	// AccessLog enables an access log entry for every inbound and outbound call.
+	// Subchannels can override these options using the AccessLog SubChannelOption.
+	// By default, calls are not logged.
+	AccessLog *AccessLogOptions
+
+	// CallStats configures in-memory per-method call statistics, which are
+	// reported by IntrospectState. By default, they are not kept.
+	CallStats CallStatsOptions
+
 	// TimeNow is a variable for overriding time.Now in unit tests.
 	// Note: This is not a stable part of the API and may change.
 	TimeNow func() time.Time
@@ -120,6 +141,15 @@ type ChannelOptions struct {
 	// If not set, opentracing.GlobalTracer() is used.
 	Tracer opentracing.Tracer
 
+	// TracerProvider is an OpenTelemetry TracerProvider used to create tracing spans.
+	// If set, OpenTelemetry is used instead of OpenTracing, and Tracer is ignored.
+	// The span context is propagated using W3C traceparent application headers for
+	// the JSON and Thrift formats. The headers use the same "$tracing$" prefix as
+	// OpenTracing, so they are only understood by other tchannel-go peers. Other
+	// formats and peers use the frame's tracing field, which only has the low
+	// 64 bits of the trace ID.
+	TracerProvider trace.TracerProvider
+
 	// Handler is an alternate handler for all inbound requests, overriding the
 	// default handler that delegates to a subchannel.
 	Handler Handler
@@ -184,6 +214,7 @@ type Channel struct {
 	relayMaxConnTimeout time.Duration
 	relayMaxTombs       uint64
 	relayTimerVerify    bool
+	relayTracing        bool
 	internalHandlers    *handlerMap
 	handler             Handler
 	onPeerStatusChanged func(*Peer)
@@ -208,7 +239,10 @@ type channelConnectionCommon struct {
 	log           Logger
 	relayLocal    map[string]struct{}
 	statsReporter StatsReporter
+	callStats     *callStats
+	accessLog     *accessLogger
 	tracer        opentracing.Tracer
+	otelTracer    trace.Tracer
 	subChannels   *subChannelMap
 	timeNow       func() time.Time
 	timeTicker    func(time.Duration) *time.Ticker
@@ -264,6 +298,14 @@ func NewChannel(serviceName string, opts *ChannelOptions) (*Channel, error) {
 		timeTicker = time.NewTicker
 	}
 
+	if opts.LogSampling != nil {
+		samplingOpts := *opts.LogSampling
+		if samplingOpts.TimeNow == nil {
+			samplingOpts.TimeNow = timeNow
+		}
+		logger = NewSampledLogger(logger, samplingOpts)
+	}
+
 	chID := _nextChID.Inc()
 	logger = logger.WithFields(
 		LogField{"serviceName", serviceName},
@@ -294,10 +336,13 @@ func NewChannel(serviceName string, opts *ChannelOptions) (*Channel, error) {
 			log:           logger,
 			relayLocal:    toStringSet(opts.RelayLocalHandlers),
 			statsReporter: statsReporter,
+			callStats:     newCallStats(opts.CallStats, timeNow),
+			accessLog:     newAccessLogger(opts.AccessLog, logger),
 			subChannels:   &subChannelMap{},
 			timeNow:       timeNow,
 			timeTicker:    timeTicker,
 			tracer:        opts.Tracer,
+			otelTracer:    newOTelTracer(opts.TracerProvider),
 		},
 		chID:                chID,
 		connectionOptions:   opts.DefaultConnectionOptions.withDefaults(),
@@ -306,11 +351,16 @@ func NewChannel(serviceName string, opts *ChannelOptions) (*Channel, error) {
 		relayMaxConnTimeout: opts.RelayMaxConnectionTimeout,
 		relayMaxTombs:       opts.RelayMaxTombs,
 		relayTimerVerify:    opts.RelayTimerVerification,
+		relayTracing:        opts.RelayTracing,
 		dialer:              dialCtx,
 		connContext:         opts.ConnContext,
 		closed:              make(chan struct{}),
 	}
-	ch.peers = newRootPeerList(ch, opts.OnPeerStatusChanged).newChild()
+	ch.peers = newRootPeerList(ch, opts.OnPeerStatusChanged, peerOptions{
+		connsPerPeer:  ch.connectionOptions.ConnectionsPerPeer,
+		connSelection: ch.connectionOptions.ConnectionSelection,
+		timeNow:       timeNow,
+	}).newChild()
 
 	switch {
 	case len(opts.SkipHandlerMethods) > 0 && opts.Handler != nil:
@@ -552,6 +602,8 @@ func (ch *Channel) serve() {
 				OnActive:           ch.inboundConnectionActive,
 				OnCloseStateChange: ch.connectionCloseStateChange,
 				OnExchangeUpdated:  ch.exchangeUpdated,
+				OnHealthChange:     ch.connectionHealthChanged,
+				Reconnect:          ch.reconnect,
 			}
 			if _, err := ch.inboundHandshake(context.Background(), netConn, events); err != nil {
 				netConn.Close()
@@ -618,6 +670,8 @@ func (ch *Channel) Connect(ctx context.Context, hostPort string) (*Connection, e
 		OnActive:           ch.outboundConnectionActive,
 		OnCloseStateChange: ch.connectionCloseStateChange,
 		OnExchangeUpdated:  ch.exchangeUpdated,
+		OnHealthChange:     ch.connectionHealthChanged,
+		Reconnect:          ch.reconnect,
 	}
 
 	if err := ctx.Err(); err != nil {
@@ -680,6 +734,25 @@ func (ch *Channel) exchangeUpdated(c *Connection) {
 	ch.updatePeer(p)
 }
 
+// connectionHealthChanged informs third parties that a peer's connection was
+// degraded or recovered, and updates the peer's score.
+func (ch *Channel) connectionHealthChanged(c *Connection) {
+	p, ok := ch.RootPeers().Get(c.remotePeerInfo.HostPort)
+	if !ok {
+		return
+	}
+
+	p.onStatusChanged(p)
+	ch.updatePeer(p)
+}
+
+// reconnect creates a new outbound connection to replace a connection that
+// failed health checks.
+func (ch *Channel) reconnect(ctx context.Context, hostPort string) error {
+	_, err := ch.RootPeers().GetOrAdd(hostPort).Connect(ctx)
+	return err
+}
+
 // updatePeer updates the score of the peer and update it's position in heap as well.
 func (ch *Channel) updatePeer(p *Peer) {
 	ch.peers.onPeerChange(p)
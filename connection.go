@@ -158,6 +158,20 @@ type ConnectionOptions struct {
 	// MaxCloseTime controls how long we allow a connection to complete pending
 	// calls before shutting down. Only used if it is non-zero.
 	MaxCloseTime time.Duration
+
+	// ConnectionsPerPeer is the target number of outbound connections to each
+	// peer that calls are made to. After the first call to a peer, connections
+	// are created in the background until the target is reached, and closed
+	// connections are replaced. By default, a single connection is used.
+	ConnectionsPerPeer int
+
+	// ConnectionSelection is the strategy used to select one of a peer's
+	// active connections for a call. Defaults to ConnectionSelectRandom.
+	ConnectionSelection ConnectionSelection
+
+	// Metrics configures optional connection-level metrics.
+	// By default, connection-level metrics are not reported.
+	Metrics ConnectionMetricsOptions
 }
 
 // connectionEvents are the events that can be triggered by a connection.
@@ -170,6 +184,14 @@ type connectionEvents struct {
 
 	// OnExchangeUpdated is called when a message exchange added or removed.
 	OnExchangeUpdated func(c *Connection)
+
+	// OnHealthChange is called when a connection is degraded by failed health
+	// checks, or recovers from being degraded.
+	OnHealthChange func(c *Connection)
+
+	// Reconnect is called to create a new connection to the given host:port
+	// when a connection is replaced due to failed health checks.
+	Reconnect func(ctx context.Context, hostPort string) error
 }
 
 // Connection represents a connection to a remote peer.
@@ -194,6 +216,7 @@ type Connection struct {
 	nextMessageID    atomic.Uint32
 	events           connectionEvents
 	commonStatsTags  map[string]string
+	connStats        *connectionStats
 	relay            *Relayer
 	baseContext      context.Context
 
@@ -216,6 +239,10 @@ type Connection struct {
 	healthCheckQuit    context.CancelFunc
 	healthCheckDone    chan struct{}
 	healthCheckHistory *healthHistory
+	// degraded is set when the connection fails health checks with HealthCheckDegrade.
+	degraded atomic.Bool
+	// chosenCount is the number of times the connection was selected for a call.
+	chosenCount atomic.Uint64
 
 	// lastActivity{Read,Write} is used to track how long the connection has been
 	// idle for the recieve and send connections respectively. (unix time, nano)
@@ -273,6 +300,7 @@ func (co ConnectionOptions) withDefaults() ConnectionOptions {
 		co.SendBufferSize = DefaultConnectionBufferSize
 	}
 	co.HealthChecks = co.HealthChecks.withDefaults()
+	co.Metrics = co.Metrics.withDefaults()
 	return co
 }
 
@@ -345,6 +373,7 @@ func (ch *Channel) newConnection(baseCtx context.Context, conn net.Conn, initial
 		handler:            ch.handler,
 		events:             events,
 		commonStatsTags:    ch.commonStatsTags,
+		connStats:          ch.newConnectionStats(connDirection, remotePeer.statsHostPort()),
 		healthCheckHistory: newHealthHistory(),
 		lastActivityRead:   *atomic.NewInt64(timeNow),
 		lastActivityWrite:  *atomic.NewInt64(timeNow),
@@ -370,9 +399,13 @@ func (ch *Channel) newConnection(baseCtx context.Context, conn net.Conn, initial
 
 	// Connections are activated as soon as they are created.
 	c.callOnActive()
+	c.connStats.opened()
 
 	go c.readFrames(connID)
 	go c.writeFrames(connID)
+	if c.connStats != nil {
+		go c.reportSendQueue(connID)
+	}
 	return c
 }
 
@@ -677,6 +710,7 @@ func (c *Connection) readFrames(_ uint32) {
 		}
 
 		c.updateLastActivityRead(frame)
+		c.connStats.frameRecvd(frame.Header.messageType, frame.Header.FrameSize())
 
 		var releaseFrame bool
 		if c.relay == nil {
@@ -757,12 +791,14 @@ func (c *Connection) writeFrames(_ uint32) {
 			}
 
 			c.updateLastActivityWrite(f)
+			msgType, size := f.Header.messageType, f.Header.FrameSize()
 			err := f.WriteOut(c.conn)
 			c.opts.FramePool.Release(f)
 			if err != nil {
 				c.connectionError("write frames", err)
 				return
 			}
+			c.connStats.frameSent(msgType, size)
 		case <-c.stopCh:
 			// If there are frames in sendCh, we want to drain them.
 			if len(c.sendCh) > 0 {
@@ -879,6 +915,7 @@ func (c *Connection) close(fields ...LogField) error {
 	}); err != nil {
 		return err
 	}
+	c.connStats.closed(fields)
 
 	// Set a read deadline with any close timeout. This will cause a i/o timeout
 	// if the connection isn't closed by then.
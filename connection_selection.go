@@ -0,0 +1,90 @@
+// Copyright (c) 2015 Uber Technologies, Inc.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package tchannel
+
+// ConnectionSelection is the strategy used to select a connection for a call
+// when a peer has multiple active connections.
+type ConnectionSelection int
+
+const (
+	// ConnectionSelectRandom selects a random active connection.
+	ConnectionSelectRandom ConnectionSelection = iota
+
+	// ConnectionSelectRoundRobin cycles through the active connections.
+	ConnectionSelectRoundRobin
+
+	// ConnectionSelectLeastPending selects the active connection with the
+	// fewest pending outbound calls.
+	ConnectionSelectLeastPending
+)
+
+// selectConnLocked selects an active connection using the peer's connection
+// selection strategy. Connections degraded by health checks are only selected
+// if there are no other active connections. The peer must be read-locked.
+func (p *Peer) selectConnLocked(allConns int) *Connection {
+	// We cycle through the connection list, starting at a random point
+	// to avoid always choosing the same connection.
+	var startOffset int
+	if p.opts.connSelection == ConnectionSelectRoundRobin {
+		startOffset = int(p.nextConn.Inc() % uint64(allConns))
+	} else if allConns > 1 {
+		startOffset = peerRng.Intn(allConns)
+	}
+
+	var (
+		selected, degraded             *Connection
+		minPending, minPendingDegraded int
+		leastLoaded                    = p.opts.connSelection == ConnectionSelectLeastPending
+	)
+	for i := 0; i < allConns; i++ {
+		conn := p.getConn((i + startOffset) % allConns)
+		if !conn.IsActive() {
+			continue
+		}
+		if conn.degraded.Load() {
+			if pending := conn.outbound.count(); degraded == nil || (leastLoaded && pending < minPendingDegraded) {
+				degraded, minPendingDegraded = conn, pending
+			}
+			continue
+		}
+		if !leastLoaded {
+			return conn
+		}
+		if pending := conn.outbound.count(); selected == nil || pending < minPending {
+			selected, minPending = conn, pending
+		}
+	}
+	if selected == nil {
+		return degraded
+	}
+	return selected
+}
+
+// ensureConnectionsPerPeer starts creating connections in the background
+// until the peer has ConnectionsPerPeer outbound connections.
+func (p *Peer) ensureConnectionsPerPeer() {
+	if p.opts.connsPerPeer <= 1 {
+		return
+	}
+	p.connsPerPeerOnce.Do(func() {
+		p.setMinConnections(nil /* ConnectionOptions */, p.opts.connsPerPeer)
+	})
+}
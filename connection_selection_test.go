@@ -0,0 +1,255 @@
+// Copyright (c) 2015 Uber Technologies, Inc.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package tchannel_test
+
+import (
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/uber/tchannel-go"
+
+	"github.com/uber/tchannel-go/raw"
+	"github.com/uber/tchannel-go/testutils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+	"golang.org/x/net/context"
+)
+
+func TestConnectionsPerPeerRoundRobin(t *testing.T) {
+	testutils.WithTestServer(t, nil, func(t testing.TB, ts *testutils.TestServer) {
+		client := ts.NewClient(testutils.NewOpts().SetConnectionsPerPeer(3, ConnectionSelectRoundRobin))
+
+		ctx, cancel := NewContext(time.Second)
+		defer cancel()
+
+		peer := client.Peers().Add(ts.HostPort())
+		_, err := peer.GetConnection(ctx)
+		require.NoError(t, err, "GetConnection failed")
+		waitForOutbound(t, peer, 3)
+
+		chosen := make(map[*Connection]int)
+		for i := 0; i < 9; i++ {
+			conn, err := peer.GetConnection(ctx)
+			require.NoError(t, err, "GetConnection failed")
+			chosen[conn]++
+		}
+		assert.Len(t, chosen, 3, "Expected calls to be spread across all connections")
+		for _, count := range chosen {
+			assert.Equal(t, 3, count, "Expected round-robin selection")
+		}
+
+		var totalChosen uint64
+		state := peer.IntrospectState(&IntrospectionOptions{})
+		require.Len(t, state.ConnectionLoad, 3, "Unexpected connection load")
+		for _, load := range state.ConnectionLoad {
+			assert.Equal(t, "outbound", load.Direction, "Unexpected direction")
+			assert.True(t, load.Active, "Connection should be active")
+			totalChosen += load.ChosenCount
+		}
+		// The first GetConnection created a new connection rather than choosing one.
+		assert.EqualValues(t, 9, totalChosen, "Unexpected total chosen count")
+	})
+}
+
+func TestConnectionsPerPeerLeastPending(t *testing.T) {
+	testutils.WithTestServer(t, nil, func(t testing.TB, ts *testutils.TestServer) {
+		const numCalls = 3
+
+		var started sync.WaitGroup
+		started.Add(numCalls)
+		unblock := make(chan struct{})
+		testutils.RegisterFunc(ts.Server(), "block", func(ctx context.Context, args *raw.Args) (*raw.Res, error) {
+			started.Done()
+			<-unblock
+			return &raw.Res{}, nil
+		})
+
+		client := ts.NewClient(testutils.NewOpts().SetConnectionsPerPeer(numCalls, ConnectionSelectLeastPending))
+
+		ctx, cancel := NewContext(time.Second)
+		defer cancel()
+
+		peer := client.Peers().Add(ts.HostPort())
+		_, err := peer.GetConnection(ctx)
+		require.NoError(t, err, "GetConnection failed")
+		waitForOutbound(t, peer, numCalls)
+
+		var wg sync.WaitGroup
+		for i := 0; i < numCalls; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, _, _, err := raw.Call(ctx, client, ts.HostPort(), ts.ServiceName(), "block", nil, nil)
+				assert.NoError(t, err, "Call failed")
+			}()
+
+			// Wait for the call to be pending before starting the next call.
+			require.True(t, testutils.WaitFor(time.Second, func() bool {
+				return peer.NumPendingOutbound() == i+1
+			}), "Call did not start")
+		}
+		started.Wait()
+
+		state := peer.IntrospectState(&IntrospectionOptions{})
+		for _, load := range state.ConnectionLoad {
+			assert.Equal(t, 1, load.PendingOutbound, "Expected calls to be spread by least pending")
+		}
+
+		close(unblock)
+		wg.Wait()
+	})
+}
+
+func TestConnectionSelectionDefault(t *testing.T) {
+	testutils.WithTestServer(t, nil, func(t testing.TB, ts *testutils.TestServer) {
+		client := ts.NewClient(nil)
+
+		ctx, cancel := NewContext(time.Second)
+		defer cancel()
+
+		peer := client.Peers().Add(ts.HostPort())
+		_, err := peer.GetConnection(ctx)
+		require.NoError(t, err, "GetConnection failed")
+
+		// Without ConnectionsPerPeer, no additional connections are created.
+		time.Sleep(testutils.Timeout(10 * time.Millisecond))
+		_, outbound := peer.NumConnections()
+		assert.Equal(t, 1, outbound, "Expected a single connection")
+
+		state := peer.IntrospectState(&IntrospectionOptions{})
+		require.Len(t, state.ConnectionLoad, 1, "Unexpected connection load")
+		assert.Equal(t, ConnectionLoadState{
+			ID:          state.OutboundConnections[0].ID,
+			Direction:   "outbound",
+			Active:      true,
+			ChosenCount: 0,
+		}, state.ConnectionLoad[0], "Unexpected connection load")
+	})
+}
+
+func TestConnectionsPerPeerRemovedPeerStopsDialing(t *testing.T) {
+	server := testutils.NewServer(t, nil)
+	hostPort := server.PeerInfo().HostPort
+
+	client := testutils.NewClient(t, testutils.NewOpts().SetConnectionsPerPeer(2, ConnectionSelectRoundRobin))
+	defer client.Close()
+
+	ctx, cancel := NewContext(time.Second)
+	defer cancel()
+
+	peer := client.RootPeers().GetOrAdd(hostPort)
+	_, err := peer.GetConnection(ctx)
+	require.NoError(t, err, "GetConnection failed")
+	waitForOutbound(t, peer, 2)
+
+	// Closing the server closes all connections to the peer, so it's removed
+	// from the root peer list.
+	server.Close()
+	require.True(t, testutils.WaitFor(time.Second, func() bool {
+		_, ok := client.RootPeers().Get(hostPort)
+		return !ok
+	}), "Peer was not removed from the root peer list")
+
+	// The removed peer should not dial the host:port again.
+	ln, err := net.Listen("tcp", hostPort)
+	require.NoError(t, err, "Listen failed")
+	defer ln.Close()
+
+	var accepted atomic.Int32
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			accepted.Inc()
+			conn.Close()
+		}
+	}()
+
+	time.Sleep(testutils.Timeout(500 * time.Millisecond))
+	assert.Zero(t, accepted.Load(), "Removed peer should not create connections")
+}
+
+func TestConnectionSelectionSkipsDegraded(t *testing.T) {
+	errFrame := getErrorFrame(t)
+	opts := testutils.NewOpts().NoRelay()
+	testutils.WithTestServer(t, opts, func(t testing.TB, ts *testutils.TestServer) {
+		// Only fail the first health check, so only one connection is degraded.
+		var failedPing atomic.Bool
+		frameRelay, cancel := testutils.FrameRelay(t, ts.HostPort(), func(outgoing bool, f *Frame) *Frame {
+			if strings.Contains(f.Header.String(), "PingRes") && failedPing.CAS(false, true) {
+				errFrame.Header.ID = f.Header.ID
+				f = errFrame
+			}
+			return f
+		})
+		defer cancel()
+
+		ft := testutils.NewFakeTicker()
+		client := ts.NewClient(testutils.NewOpts().
+			SetTimeTicker(ft.New).
+			SetConnectionsPerPeer(2, ConnectionSelectRoundRobin).
+			SetHealthChecks(HealthCheckOptions{
+				Interval:        time.Second,
+				FailuresToClose: 1,
+				FailureAction:   HealthCheckDegrade,
+			}).
+			AddLogFilter("Failed active health check.", 1).
+			AddLogFilter("Unexpected ping response.", 1).
+			AddLogFilter("Connection degraded due to failed health checks.", 1))
+		// Close the client before the frame relay, so it doesn't replace the
+		// connections that the frame relay closes.
+		defer client.Close()
+
+		ctx, cancel := NewContext(time.Second)
+		defer cancel()
+
+		peer := client.Peers().Add(frameRelay)
+		_, err := peer.GetConnection(ctx)
+		require.NoError(t, err, "GetConnection failed")
+		waitForOutbound(t, peer, 2)
+
+		// A single tick runs a health check on one of the connections.
+		ft.TryTick()
+		require.True(t, testutils.WaitFor(time.Second, func() bool {
+			var degraded int
+			for _, conn := range peer.IntrospectState(&IntrospectionOptions{}).OutboundConnections {
+				if conn.Degraded {
+					degraded++
+				}
+			}
+			return degraded == 1
+		}), "Expected one degraded connection")
+		assert.False(t, peer.Degraded(), "Peer should have a healthy connection")
+
+		for i := 0; i < 4; i++ {
+			conn, err := peer.GetConnection(ctx)
+			require.NoError(t, err, "GetConnection failed")
+			assert.False(t, introspectConn(conn).Degraded, "Degraded connection should not be selected")
+		}
+	})
+}
@@ -0,0 +1,259 @@
+// Copyright (c) 2015 Uber Technologies, Inc.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package tchannel
+
+import (
+	"strings"
+	"time"
+)
+
+const _defaultSendQueueReportInterval = 10 * time.Second
+
+// ConnectionMetricsOptions configures optional connection-level metrics,
+// which are reported using the channel's StatsReporter.
+type ConnectionMetricsOptions struct {
+	// Enabled turns on connection-level metrics: connections opened and
+	// closed, handshake latency and failures, frames and bytes by message
+	// type, send queue depth, and active health check RTTs and failures.
+	// By default, only per-call metrics are reported.
+	Enabled bool
+
+	// PerPeer adds the remote host:port as a "remote-hostport" tag to
+	// connection-level metrics. This may create many metrics for channels
+	// that talk to a large number of peers.
+	PerPeer bool
+
+	// SendQueueInterval is how often the send queue depth of each connection
+	// is reported. Defaults to 10 seconds.
+	SendQueueInterval time.Duration
+}
+
+func (mo ConnectionMetricsOptions) withDefaults() ConnectionMetricsOptions {
+	if mo.SendQueueInterval <= 0 {
+		mo.SendQueueInterval = _defaultSendQueueReportInterval
+	}
+	return mo
+}
+
+// connectionStats reports connection-level metrics. A nil *connectionStats
+// is valid and reports nothing, which is used when metrics are not enabled.
+type connectionStats struct {
+	reporter StatsReporter
+	tags     map[string]string
+
+	// frameTags are the tags for frame metrics by message type, created
+	// up-front to avoid allocating tags for every frame.
+	frameTags        map[messageType]map[string]string
+	unknownFrameTags map[string]string
+}
+
+var _frameMetricsTypes = []messageType{
+	messageTypeInitReq,
+	messageTypeInitRes,
+	messageTypeCallReq,
+	messageTypeCallRes,
+	messageTypeCallReqContinue,
+	messageTypeCallResContinue,
+	messageTypePingReq,
+	messageTypePingRes,
+	messageTypeError,
+}
+
+// metricsKey returns a string representation of the message type that's
+// suitable for inclusion in metric tags.
+func (i messageType) metricsKey() string {
+	switch i {
+	case messageTypeInitReq:
+		return "init-req"
+	case messageTypeInitRes:
+		return "init-res"
+	case messageTypeCallReq:
+		return "call-req"
+	case messageTypeCallRes:
+		return "call-res"
+	case messageTypeCallReqContinue:
+		return "call-req-continue"
+	case messageTypeCallResContinue:
+		return "call-res-continue"
+	case messageTypePingReq:
+		return "ping-req"
+	case messageTypePingRes:
+		return "ping-res"
+	case messageTypeError:
+		return "error"
+	default:
+		return "unknown"
+	}
+}
+
+// statsHostPort returns the remote host:port to use in connection-level
+// metric tags. Ephemeral peers return an empty host:port, since their
+// socket address would create a new metric for every connection.
+func (p PeerInfo) statsHostPort() string {
+	if p.IsEphemeral {
+		return ""
+	}
+	return p.HostPort
+}
+
+// connectionStatsTags returns the tags used for connection-level metrics.
+// remoteHostPort is only added if per-peer metrics are enabled, and it's
+// not empty.
+func (ch *Channel) connectionStatsTags(connDir connectionDirection, remoteHostPort string) map[string]string {
+	tags := make(map[string]string, len(ch.commonStatsTags)+2)
+	for k, v := range ch.commonStatsTags {
+		tags[k] = v
+	}
+	tags["direction"] = connDir.String()
+	if ch.connectionOptions.Metrics.PerPeer && remoteHostPort != "" {
+		tags["remote-hostport"] = remoteHostPort
+	}
+	return tags
+}
+
+func (ch *Channel) newConnectionStats(connDir connectionDirection, remoteHostPort string) *connectionStats {
+	if !ch.connectionOptions.Metrics.Enabled {
+		return nil
+	}
+
+	tags := ch.connectionStatsTags(connDir, remoteHostPort)
+	cs := &connectionStats{
+		reporter:         ch.statsReporter,
+		tags:             tags,
+		frameTags:        make(map[messageType]map[string]string, len(_frameMetricsTypes)),
+		unknownFrameTags: withStatsTag(tags, "message-type", messageType(0).metricsKey()),
+	}
+	for _, t := range _frameMetricsTypes {
+		cs.frameTags[t] = withStatsTag(tags, "message-type", t.metricsKey())
+	}
+	return cs
+}
+
+// reportHandshake reports the latency or failure of a connection handshake.
+func (ch *Channel) reportHandshake(connDir connectionDirection, remoteHostPort string, start time.Time, err error) {
+	if !ch.connectionOptions.Metrics.Enabled {
+		return
+	}
+
+	tags := ch.connectionStatsTags(connDir, remoteHostPort)
+	if err != nil {
+		tags["error"] = GetSystemErrorCode(err).MetricsKey()
+		ch.statsReporter.IncCounter("connection.handshake.failed", tags, 1)
+		return
+	}
+	ch.statsReporter.RecordTimer("connection.handshake.latency", tags, ch.timeNow().Sub(start))
+}
+
+func (cs *connectionStats) opened() {
+	if cs == nil {
+		return
+	}
+	cs.reporter.IncCounter("connection.opened", cs.tags, 1)
+}
+
+// closed reports that a connection started closing, using the "reason"
+// field passed to Connection.close as the reason tag.
+func (cs *connectionStats) closed(fields []LogField) {
+	if cs == nil {
+		return
+	}
+
+	reason := "unknown"
+	for _, f := range fields {
+		if f.Key != "reason" {
+			continue
+		}
+		if s, ok := f.Value.(string); ok {
+			reason = strings.ToLower(strings.Replace(s, " ", "-", -1))
+		}
+	}
+	cs.reporter.IncCounter("connection.closed", withStatsTag(cs.tags, "reason", reason), 1)
+}
+
+func (cs *connectionStats) healthCheckRTT(rtt time.Duration) {
+	if cs == nil {
+		return
+	}
+	cs.reporter.RecordTimer("connection.health-checks.rtt", cs.tags, rtt)
+}
+
+func (cs *connectionStats) healthCheckFailed() {
+	if cs == nil {
+		return
+	}
+	cs.reporter.IncCounter("connection.health-checks.failed", cs.tags, 1)
+}
+
+func (cs *connectionStats) tagsForFrame(t messageType) map[string]string {
+	if tags, ok := cs.frameTags[t]; ok {
+		return tags
+	}
+	return cs.unknownFrameTags
+}
+
+func (cs *connectionStats) frameSent(t messageType, size uint16) {
+	if cs == nil {
+		return
+	}
+	tags := cs.tagsForFrame(t)
+	cs.reporter.IncCounter("connection.frames.sent", tags, 1)
+	cs.reporter.IncCounter("connection.bytes.sent", tags, int64(size))
+}
+
+func (cs *connectionStats) frameRecvd(t messageType, size uint16) {
+	if cs == nil {
+		return
+	}
+	tags := cs.tagsForFrame(t)
+	cs.reporter.IncCounter("connection.frames.recvd", tags, 1)
+	cs.reporter.IncCounter("connection.bytes.recvd", tags, int64(size))
+}
+
+// reportSendQueue periodically reports the number of frames waiting in the
+// send channel, and the number of bytes in the socket's send queue, until
+// the connection is closed.
+// We accept connID on the stack so can more easily debug panics or leaked goroutines.
+func (c *Connection) reportSendQueue(_ uint32) {
+	ticker := time.NewTicker(c.opts.Metrics.SendQueueInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-c.stopCh:
+			return
+		}
+
+		c.connStats.reporter.UpdateGauge("connection.send-queue.frames", c.connStats.tags, int64(len(c.sendCh)))
+		if usage, _, err := c.sendBufSize(); err == nil {
+			c.connStats.reporter.UpdateGauge("connection.send-queue.bytes", c.connStats.tags, int64(usage))
+		}
+	}
+}
+
+func withStatsTag(tags map[string]string, key, value string) map[string]string {
+	newTags := make(map[string]string, len(tags)+1)
+	for k, v := range tags {
+		newTags[k] = v
+	}
+	newTags[key] = value
+	return newTags
+}
@@ -0,0 +1,204 @@
+// Copyright (c) 2015 Uber Technologies, Inc.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package tchannel_test
+
+import (
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	. "github.com/uber/tchannel-go"
+
+	"github.com/uber/tchannel-go/raw"
+	"github.com/uber/tchannel-go/testutils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func connectionStatsTags(ch *Channel, direction, remoteHostPort string, extra ...string) map[string]string {
+	host, _ := os.Hostname()
+	tags := map[string]string{
+		"app":       ch.PeerInfo().ProcessName,
+		"host":      host,
+		"service":   ch.PeerInfo().ServiceName,
+		"direction": direction,
+	}
+	if remoteHostPort != "" {
+		tags["remote-hostport"] = remoteHostPort
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		tags[extra[i]] = extra[i+1]
+	}
+	return tags
+}
+
+func (r *recordingStatsReporter) lookup(name string, tags map[string]string) (statsValue, bool) {
+	r.Lock()
+	defer r.Unlock()
+
+	v, ok := r.Values[name][tagsToString(tags)]
+	if !ok {
+		return statsValue{}, false
+	}
+	return *v, true
+}
+
+func TestConnectionMetrics(t *testing.T) {
+	metricsOpts := ConnectionMetricsOptions{
+		Enabled:           true,
+		PerPeer:           true,
+		SendQueueInterval: 10 * time.Millisecond,
+	}
+	serverStats := newRecordingStatsReporter()
+	serverOpts := testutils.NewOpts().
+		NoRelay().
+		SetStatsReporter(serverStats).
+		SetConnectionMetrics(metricsOpts)
+
+	testutils.WithTestServer(t, serverOpts, func(t testing.TB, ts *testutils.TestServer) {
+		ts.Register(raw.Wrap(newTestHandler(t)), "echo")
+
+		clientStats := newRecordingStatsReporter()
+		client := ts.NewClient(testutils.NewOpts().
+			SetStatsReporter(clientStats).
+			SetConnectionMetrics(metricsOpts))
+
+		ctx, cancel := NewContext(time.Second)
+		defer cancel()
+		_, _, _, err := raw.Call(ctx, client, ts.HostPort(), ts.ServiceName(), "echo", []byte("arg2"), []byte("arg3"))
+		require.NoError(t, err, "Call failed")
+
+		hostPort := ts.HostPort()
+		outTags := connectionStatsTags(client, "outbound", hostPort)
+
+		opened, ok := clientStats.lookup("connection.opened", outTags)
+		require.True(t, ok, "Missing connection.opened for outbound connection")
+		assert.EqualValues(t, 1, opened.count, "Unexpected connection.opened")
+
+		handshake, ok := clientStats.lookup("connection.handshake.latency", outTags)
+		require.True(t, ok, "Missing connection.handshake.latency for outbound connection")
+		assert.Len(t, handshake.timers, 1, "Unexpected number of handshake timers")
+
+		for _, tt := range []struct {
+			stats   *recordingStatsReporter
+			metric  string
+			tags    map[string]string
+			msgType string
+		}{
+			{clientStats, "connection.frames.sent", outTags, "call-req"},
+			{clientStats, "connection.frames.recvd", outTags, "call-res"},
+			{clientStats, "connection.bytes.sent", outTags, "call-req"},
+			{clientStats, "connection.bytes.recvd", outTags, "call-res"},
+		} {
+			tags := connectionStatsTags(client, "outbound", hostPort, "message-type", tt.msgType)
+			assert.True(t, testutils.WaitFor(time.Second, func() bool {
+				v, ok := tt.stats.lookup(tt.metric, tags)
+				return ok && v.count > 0
+			}), "Missing %v for %v frames", tt.metric, tt.msgType)
+		}
+
+		assert.True(t, testutils.WaitFor(time.Second, func() bool {
+			_, ok := clientStats.lookup("connection.send-queue.frames", outTags)
+			return ok
+		}), "Missing connection.send-queue.frames gauge")
+
+		// The client is ephemeral, so inbound metrics do not have its host:port,
+		// as its socket address would create a new metric for every connection.
+		inTags := connectionStatsTags(ts.Server(), "inbound", "")
+		_, ok = serverStats.lookup("connection.handshake.latency", inTags)
+		assert.True(t, ok, "Missing inbound handshake latency without remote-hostport")
+		_, ok = serverStats.lookup("connection.opened", inTags)
+		assert.True(t, ok, "Missing inbound connection.opened without remote-hostport")
+
+		// A listening client's host:port from the handshake is used.
+		listening := ts.NewServer(nil)
+		_, _, _, err = raw.Call(ctx, listening, ts.HostPort(), ts.ServiceName(), "echo", nil, nil)
+		require.NoError(t, err, "Call failed")
+		inTags = connectionStatsTags(ts.Server(), "inbound", listening.PeerInfo().HostPort)
+		_, ok = serverStats.lookup("connection.handshake.latency", inTags)
+		assert.True(t, ok, "Missing inbound handshake latency for listening client")
+		_, ok = serverStats.lookup("connection.opened", inTags)
+		assert.True(t, ok, "Missing inbound connection.opened for listening client")
+
+		serverStats.Lock()
+		serverHandshakes := len(serverStats.Values["connection.handshake.latency"])
+		serverStats.Unlock()
+		assert.Equal(t, 2, serverHandshakes, "Unexpected inbound handshake metrics")
+
+		client.Close()
+		closedTags := connectionStatsTags(client, "outbound", hostPort, "reason", "channel-closing")
+		closed, ok := clientStats.lookup("connection.closed", closedTags)
+		require.True(t, ok, "Missing connection.closed with reason")
+		assert.EqualValues(t, 1, closed.count, "Unexpected connection.closed")
+	})
+}
+
+func TestConnectionMetricsHandshakeFailed(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err, "Listen failed")
+	defer ln.Close()
+
+	go func() {
+		conn, err := ln.Accept()
+		if err == nil {
+			conn.Close()
+		}
+	}()
+
+	clientStats := newRecordingStatsReporter()
+	opts := testutils.NewOpts().
+		SetStatsReporter(clientStats).
+		SetConnectionMetrics(ConnectionMetricsOptions{Enabled: true}).
+		AddLogFilter("Failed during connection handshake.", 1)
+	client := testutils.NewClient(t, opts)
+	defer client.Close()
+
+	ctx, cancel := NewContext(time.Second)
+	defer cancel()
+	_, err = client.Connect(ctx, ln.Addr().String())
+	require.Error(t, err, "Connect should fail when the handshake fails")
+
+	tags := connectionStatsTags(client, "outbound", "", "error", "network-error")
+	failed, ok := clientStats.lookup("connection.handshake.failed", tags)
+	require.True(t, ok, "Missing connection.handshake.failed")
+	assert.EqualValues(t, 1, failed.count, "Unexpected connection.handshake.failed")
+}
+
+func TestConnectionMetricsDisabled(t *testing.T) {
+	serverStats := newRecordingStatsReporter()
+	opts := testutils.NewOpts().NoRelay().SetStatsReporter(serverStats)
+	testutils.WithTestServer(t, opts, func(t testing.TB, ts *testutils.TestServer) {
+		ts.Register(raw.Wrap(newTestHandler(t)), "echo")
+
+		ctx, cancel := NewContext(time.Second)
+		defer cancel()
+		_, _, _, err := raw.Call(ctx, ts.NewClient(nil), ts.HostPort(), ts.ServiceName(), "echo", nil, nil)
+		require.NoError(t, err, "Call failed")
+
+		serverStats.Lock()
+		defer serverStats.Unlock()
+		for name := range serverStats.Values {
+			assert.NotContains(t, name, "connection.", "Unexpected connection-level metric")
+		}
+	})
+}
@@ -0,0 +1,325 @@
+// Copyright (c) 2015 Uber Technologies, Inc.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/uber/tchannel-go"
+	"github.com/uber/tchannel-go/raw"
+	tthrift "github.com/uber/tchannel-go/thrift"
+	"github.com/uber/tchannel-go/thrift/dynamic"
+
+	"golang.org/x/net/context"
+)
+
+// HTTP headers used to control the TChannel call.
+const (
+	// formatHeader selects the format: "json" (default), "raw" or "thrift".
+	formatHeader          = "X-Tchannel-Format"
+	timeoutHeader         = "X-Tchannel-Timeout"
+	shardKeyHeader        = "X-Tchannel-Shard-Key"
+	routingKeyHeader      = "X-Tchannel-Routing-Key"
+	routingDelegateHeader = "X-Tchannel-Routing-Delegate"
+
+	// appHeaderPrefix is the prefix for HTTP headers that are forwarded as
+	// TChannel application headers in requests and responses. Since HTTP
+	// header names are case-insensitive, application header keys are
+	// forwarded in lower case.
+	appHeaderPrefix = "X-Tchannel-Header-"
+
+	// appErrorHeader is set in the response when the call fails with an
+	// application error.
+	appErrorHeader = "X-Tchannel-Application-Error"
+)
+
+const (
+	contentTypeJSON   = "application/json"
+	contentTypeBinary = "application/octet-stream"
+)
+
+type gateway struct {
+	ch             *tchannel.Channel
+	idls           []*dynamic.IDL
+	defaultTimeout time.Duration
+}
+
+// response is the result of a TChannel call to be written as an HTTP response.
+type response struct {
+	headers     map[string]string
+	appError    bool
+	contentType string
+	body        []byte
+}
+
+func newGateway(ch *tchannel.Channel, idls []*dynamic.IDL, defaultTimeout time.Duration) *gateway {
+	return &gateway{
+		ch:             ch,
+		idls:           idls,
+		defaultTimeout: defaultTimeout,
+	}
+}
+
+func (g *gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "only POST is supported")
+		return
+	}
+
+	// The method may contain "/", so only split on the first "/".
+	parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/"), "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		writeError(w, http.StatusNotFound, "path must be /{service}/{method}")
+		return
+	}
+	service, method := parts[0], parts[1]
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("failed to read body: %v", err))
+		return
+	}
+
+	ctx, cancel, err := g.newContext(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	defer cancel()
+
+	var res *response
+	switch format := tchannel.Format(r.Header.Get(formatHeader)); format {
+	case "", tchannel.JSON:
+		res, err = g.callJSON(ctx, service, method, body)
+	case tchannel.Raw:
+		res, err = g.callRaw(ctx, service, method, body)
+	case tchannel.Thrift:
+		res, err = g.callThrift(ctx, service, method, body)
+	default:
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("unsupported format %q", format))
+		return
+	}
+	if err != nil {
+		writeCallError(w, err)
+		return
+	}
+
+	for k, v := range res.headers {
+		w.Header().Set(appHeaderPrefix+k, v)
+	}
+	if res.appError {
+		w.Header().Set(appErrorHeader, "true")
+	}
+	w.Header().Set("Content-Type", res.contentType)
+	w.Write(res.body)
+}
+
+// newContext creates a context for the call using the request's HTTP headers.
+func (g *gateway) newContext(r *http.Request) (tchannel.ContextWithHeaders, context.CancelFunc, error) {
+	timeout := g.defaultTimeout
+	if v := r.Header.Get(timeoutHeader); v != "" {
+		var err error
+		if timeout, err = time.ParseDuration(v); err != nil {
+			return nil, nil, fmt.Errorf("invalid %v: %v", timeoutHeader, err)
+		}
+	}
+
+	headers := make(map[string]string)
+	for k, vs := range r.Header {
+		if strings.HasPrefix(k, appHeaderPrefix) && len(vs) > 0 {
+			headers[strings.ToLower(strings.TrimPrefix(k, appHeaderPrefix))] = vs[0]
+		}
+	}
+
+	ctx, cancel := tchannel.NewContextBuilder(timeout).
+		SetParentContext(r.Context()).
+		SetHeaders(headers).
+		SetShardKey(r.Header.Get(shardKeyHeader)).
+		SetRoutingKey(r.Header.Get(routingKeyHeader)).
+		SetRoutingDelegate(r.Header.Get(routingDelegateHeader)).
+		Build()
+	return ctx, cancel, nil
+}
+
+// call makes a call with the given format, using arg2Fn to get arg2 for each attempt.
+func (g *gateway) call(ctx context.Context, service, method string, format tchannel.Format,
+	arg2Fn func(call *tchannel.OutboundCall) ([]byte, error), arg3 []byte) (*response, []byte, error) {
+
+	var (
+		respArg2 []byte
+		res      *response
+	)
+	sc := g.ch.GetSubChannel(service)
+	err := g.ch.RunWithRetry(ctx, func(ctx context.Context, rs *tchannel.RequestState) error {
+		call, err := sc.BeginCall(ctx, method, &tchannel.CallOptions{
+			Format:       format,
+			RequestState: rs,
+		})
+		if err != nil {
+			return err
+		}
+
+		arg2, err := arg2Fn(call)
+		if err != nil {
+			return err
+		}
+
+		var (
+			respArg3 []byte
+			resp     *tchannel.OutboundCallResponse
+		)
+		respArg2, respArg3, resp, err = raw.WriteArgs(call, arg2, arg3)
+		if err != nil {
+			return err
+		}
+
+		res = &response{appError: resp.ApplicationError(), body: respArg3}
+		return nil
+	})
+	return res, respArg2, err
+}
+
+func (g *gateway) callRaw(ctx tchannel.ContextWithHeaders, service, method string, body []byte) (*response, error) {
+	noArg2 := func(*tchannel.OutboundCall) ([]byte, error) { return nil, nil }
+	res, _, err := g.call(ctx, service, method, tchannel.Raw, noArg2, body)
+	if err != nil {
+		return nil, err
+	}
+
+	res.contentType = contentTypeBinary
+	return res, nil
+}
+
+func (g *gateway) callJSON(ctx tchannel.ContextWithHeaders, service, method string, body []byte) (*response, error) {
+	if !json.Valid(body) {
+		return nil, tchannel.NewSystemError(tchannel.ErrCodeBadRequest, "request body is not valid JSON")
+	}
+
+	headersArg := func(call *tchannel.OutboundCall) ([]byte, error) {
+		return json.Marshal(tchannel.InjectOutboundSpan(call.Response(), ctx.Headers()))
+	}
+	res, respArg2, err := g.call(ctx, service, method, tchannel.JSON, headersArg, body)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(respArg2) > 0 {
+		if err := json.Unmarshal(respArg2, &res.headers); err != nil {
+			return nil, fmt.Errorf("failed to parse response headers: %v", err)
+		}
+	}
+	res.contentType = contentTypeJSON
+	return res, nil
+}
+
+func (g *gateway) callThrift(ctx tchannel.ContextWithHeaders, service, method string, body []byte) (*response, error) {
+	sep := strings.Index(method, "::")
+	if sep == -1 {
+		return nil, tchannel.NewSystemError(tchannel.ErrCodeBadRequest, "thrift method must be of the form Service::method")
+	}
+	thriftService, thriftMethod := method[:sep], method[sep+2:]
+
+	idl, err := g.findIDL(thriftService, thriftMethod)
+	if err != nil {
+		return nil, tchannel.NewSystemError(tchannel.ErrCodeBadRequest, err.Error())
+	}
+
+	var args map[string]interface{}
+	if len(body) > 0 {
+		decoder := json.NewDecoder(bytes.NewReader(body))
+		decoder.UseNumber()
+		if err := decoder.Decode(&args); err != nil {
+			return nil, tchannel.NewSystemError(tchannel.ErrCodeBadRequest, "failed to parse arguments: %v", err)
+		}
+	}
+
+	// Encode the arguments before making the call, so that invalid arguments
+	// are reported as a bad request rather than a failed call.
+	m, err := idl.Method(thriftService, thriftMethod)
+	if err != nil {
+		return nil, err
+	}
+	if err := tthrift.WriteStruct(ioutil.Discard, m.Args(args)); err != nil {
+		return nil, tchannel.NewSystemError(tchannel.ErrCodeBadRequest, "invalid arguments: %v", err)
+	}
+
+	res := &response{contentType: contentTypeJSON}
+	client := dynamic.NewClient(idl, tthrift.NewClient(g.ch, service, nil))
+	result, err := client.Call(ctx, thriftService, thriftMethod, args)
+	if exc, ok := err.(*dynamic.Exception); ok {
+		res.appError = true
+		result, err = map[string]interface{}{exc.Name: exc.Value}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	res.headers = ctx.ResponseHeaders()
+	if res.body, err = json.Marshal(result); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// findIDL returns the loaded IDL that contains the given Thrift method.
+func (g *gateway) findIDL(service, method string) (*dynamic.IDL, error) {
+	for _, idl := range g.idls {
+		if _, err := idl.Method(service, method); err == nil {
+			return idl, nil
+		}
+	}
+	return nil, fmt.Errorf("no Thrift IDL contains %v::%v", service, method)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", contentTypeJSON)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": msg})
+}
+
+// writeCallError writes the error from a call, mapping the TChannel system
+// error code to a matching HTTP status.
+func writeCallError(w http.ResponseWriter, err error) {
+	code := tchannel.GetSystemErrorCode(err)
+
+	status := http.StatusBadGateway
+	switch code {
+	case tchannel.ErrCodeBadRequest:
+		status = http.StatusBadRequest
+	case tchannel.ErrCodeTimeout:
+		status = http.StatusGatewayTimeout
+	case tchannel.ErrCodeBusy, tchannel.ErrCodeDeclined:
+		status = http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Content-Type", contentTypeJSON)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{
+		"error": err.Error(),
+		"code":  code.MetricsKey(),
+	})
+}
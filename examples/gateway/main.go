@@ -0,0 +1,107 @@
+// Copyright (c) 2015 Uber Technologies, Inc.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// gateway runs an HTTP server that translates each POST /{service}/{method}
+// into a TChannel call, so that clients without a TChannel implementation
+// (browsers, curl, other languages) can call TChannel services.
+package main
+
+import (
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/jessevdk/go-flags"
+	"github.com/uber/tchannel-go"
+	"github.com/uber/tchannel-go/hyperbahn"
+	"github.com/uber/tchannel-go/thrift/dynamic"
+)
+
+var options = struct {
+	// HostPort is the address that the HTTP server listens on.
+	HostPort string `short:"l" long:"listen" default:":8080" description:"The host:port for the HTTP server to listen on"`
+
+	CallerName string `short:"c" long:"caller" default:"tchannel-gateway" description:"The caller name used for outbound TChannel calls"`
+
+	Timeout time.Duration `short:"t" long:"timeout" default:"1s" description:"The default timeout for each call, which can be overridden per request"`
+
+	// Peers can be specified multiple times to add multiple peers.
+	Peers []string `short:"p" long:"peer" description:"A host:port to send calls to"`
+
+	// HyperbahnNodes can be specified multiple times to add multiple Hyperbahn nodes.
+	HyperbahnNodes     []string `long:"hyperbahn" description:"A Hyperbahn node host:port used to route calls"`
+	HyperbahnNodesFile string   `long:"hyperbahnFile" description:"A JSON file containing the list of Hyperbahn nodes"`
+
+	// ThriftFiles can be specified multiple times to load multiple IDLs.
+	ThriftFiles []string `long:"thrift" description:"A Thrift IDL used to transcode JSON for thrift calls"`
+}{}
+
+func parseArgs() {
+	if _, err := flags.Parse(&options); err != nil {
+		os.Exit(-1)
+	}
+
+	hasHyperbahn := len(options.HyperbahnNodes) > 0 || options.HyperbahnNodesFile != ""
+	if len(options.Peers) == 0 && !hasHyperbahn {
+		log.Fatalf("At least one peer or Hyperbahn node must be specified")
+	}
+	if len(options.Peers) > 0 && hasHyperbahn {
+		log.Fatalf("Peers and Hyperbahn nodes cannot both be specified")
+	}
+}
+
+func main() {
+	parseArgs()
+
+	ch, err := tchannel.NewChannel(options.CallerName, nil)
+	if err != nil {
+		log.Fatalf("NewChannel failed: %v", err)
+	}
+
+	if len(options.Peers) > 0 {
+		for _, hostPort := range options.Peers {
+			ch.Peers().Add(hostPort)
+		}
+	} else {
+		// The Hyperbahn client adds the Hyperbahn nodes as peers, and they
+		// route calls for all services.
+		config := hyperbahn.Configuration{
+			InitialNodes:     options.HyperbahnNodes,
+			InitialNodesFile: options.HyperbahnNodesFile,
+		}
+		if _, err := hyperbahn.NewClient(ch, config, nil); err != nil {
+			log.Fatalf("hyperbahn.NewClient failed: %v", err)
+		}
+	}
+
+	var idls []*dynamic.IDL
+	for _, f := range options.ThriftFiles {
+		idl, err := dynamic.Parse(f)
+		if err != nil {
+			log.Fatalf("Failed to load Thrift IDL: %v", err)
+		}
+		idls = append(idls, idl)
+	}
+
+	gw := newGateway(ch, idls, options.Timeout)
+	log.Printf("gateway listening on %v", options.HostPort)
+	log.Fatal(http.ListenAndServe(options.HostPort, gw))
+}
@@ -97,6 +97,10 @@ func (s *tchanAdminServer) Methods() []string {
 	}
 }
 
+func (s *tchanAdminServer) ThriftIDL() (map[string]string, string) {
+	return tchanThriftIDLs, tchanThriftIDLEntryPoint
+}
+
 func (s *tchanAdminServer) Handle(ctx thrift.Context, methodName string, protocol athrift.TProtocol) (bool, athrift.TStruct, error) {
 	switch methodName {
 	case "clearAll":
@@ -109,14 +113,49 @@ func (s *tchanAdminServer) Handle(ctx thrift.Context, methodName string, protoco
 	}
 }
 
+func (s *tchanAdminServer) NewArgs(methodName string) (athrift.TStruct, bool) {
+	switch methodName {
+	case "clearAll":
+		return &AdminClearAllArgs{}, true
+
+	case "HealthCheck":
+		if s, ok := s.TChanServer.(thrift.TChanArgsServer); ok {
+			return s.NewArgs(methodName)
+		}
+	}
+	return nil, false
+}
+
+func (s *tchanAdminServer) HandleArgs(ctx thrift.Context, methodName string, args athrift.TStruct) (bool, athrift.TStruct, error) {
+	switch methodName {
+	case "clearAll":
+		if req, ok := args.(*AdminClearAllArgs); ok {
+			return s.handleDecodedClearAll(ctx, req)
+		}
+
+	case "HealthCheck":
+		if s, ok := s.TChanServer.(thrift.TChanArgsServer); ok {
+			return s.HandleArgs(ctx, methodName, args)
+		}
+	default:
+		return false, nil, fmt.Errorf("method %v not found in service %v", methodName, s.Service())
+	}
+	return false, nil, fmt.Errorf("unexpected arguments %T for method %v", args, methodName)
+}
+
 func (s *tchanAdminServer) handleClearAll(ctx thrift.Context, protocol athrift.TProtocol) (bool, athrift.TStruct, error) {
 	var req AdminClearAllArgs
-	var res AdminClearAllResult
 
 	if err := req.Read(protocol); err != nil {
 		return false, nil, err
 	}
 
+	return s.handleDecodedClearAll(ctx, &req)
+}
+
+func (s *tchanAdminServer) handleDecodedClearAll(ctx thrift.Context, req *AdminClearAllArgs) (bool, athrift.TStruct, error) {
+	var res AdminClearAllResult
+
 	err :=
 		s.handler.ClearAll(ctx)
 
@@ -223,6 +262,10 @@ func (s *tchanKeyValueServer) Methods() []string {
 	}
 }
 
+func (s *tchanKeyValueServer) ThriftIDL() (map[string]string, string) {
+	return tchanThriftIDLs, tchanThriftIDLEntryPoint
+}
+
 func (s *tchanKeyValueServer) Handle(ctx thrift.Context, methodName string, protocol athrift.TProtocol) (bool, athrift.TStruct, error) {
 	switch methodName {
 	case "Get":
@@ -237,14 +280,55 @@ func (s *tchanKeyValueServer) Handle(ctx thrift.Context, methodName string, prot
 	}
 }
 
+func (s *tchanKeyValueServer) NewArgs(methodName string) (athrift.TStruct, bool) {
+	switch methodName {
+	case "Get":
+		return &KeyValueGetArgs{}, true
+	case "Set":
+		return &KeyValueSetArgs{}, true
+
+	case "HealthCheck":
+		if s, ok := s.TChanServer.(thrift.TChanArgsServer); ok {
+			return s.NewArgs(methodName)
+		}
+	}
+	return nil, false
+}
+
+func (s *tchanKeyValueServer) HandleArgs(ctx thrift.Context, methodName string, args athrift.TStruct) (bool, athrift.TStruct, error) {
+	switch methodName {
+	case "Get":
+		if req, ok := args.(*KeyValueGetArgs); ok {
+			return s.handleDecodedGet(ctx, req)
+		}
+	case "Set":
+		if req, ok := args.(*KeyValueSetArgs); ok {
+			return s.handleDecodedSet(ctx, req)
+		}
+
+	case "HealthCheck":
+		if s, ok := s.TChanServer.(thrift.TChanArgsServer); ok {
+			return s.HandleArgs(ctx, methodName, args)
+		}
+	default:
+		return false, nil, fmt.Errorf("method %v not found in service %v", methodName, s.Service())
+	}
+	return false, nil, fmt.Errorf("unexpected arguments %T for method %v", args, methodName)
+}
+
 func (s *tchanKeyValueServer) handleGet(ctx thrift.Context, protocol athrift.TProtocol) (bool, athrift.TStruct, error) {
 	var req KeyValueGetArgs
-	var res KeyValueGetResult
 
 	if err := req.Read(protocol); err != nil {
 		return false, nil, err
 	}
 
+	return s.handleDecodedGet(ctx, &req)
+}
+
+func (s *tchanKeyValueServer) handleDecodedGet(ctx thrift.Context, req *KeyValueGetArgs) (bool, athrift.TStruct, error) {
+	var res KeyValueGetResult
+
 	r, err :=
 		s.handler.Get(ctx, req.Key)
 
@@ -272,12 +356,17 @@ func (s *tchanKeyValueServer) handleGet(ctx thrift.Context, protocol athrift.TPr
 
 func (s *tchanKeyValueServer) handleSet(ctx thrift.Context, protocol athrift.TProtocol) (bool, athrift.TStruct, error) {
 	var req KeyValueSetArgs
-	var res KeyValueSetResult
 
 	if err := req.Read(protocol); err != nil {
 		return false, nil, err
 	}
 
+	return s.handleDecodedSet(ctx, &req)
+}
+
+func (s *tchanKeyValueServer) handleDecodedSet(ctx thrift.Context, req *KeyValueSetArgs) (bool, athrift.TStruct, error) {
+	var res KeyValueSetResult
+
 	err :=
 		s.handler.Set(ctx, req.Key, req.Value)
 
@@ -350,6 +439,10 @@ func (s *tchanBaseServiceServer) Methods() []string {
 	}
 }
 
+func (s *tchanBaseServiceServer) ThriftIDL() (map[string]string, string) {
+	return tchanThriftIDLs, tchanThriftIDLEntryPoint
+}
+
 func (s *tchanBaseServiceServer) Handle(ctx thrift.Context, methodName string, protocol athrift.TProtocol) (bool, athrift.TStruct, error) {
 	switch methodName {
 	case "HealthCheck":
@@ -360,14 +453,41 @@ func (s *tchanBaseServiceServer) Handle(ctx thrift.Context, methodName string, p
 	}
 }
 
+func (s *tchanBaseServiceServer) NewArgs(methodName string) (athrift.TStruct, bool) {
+	switch methodName {
+	case "HealthCheck":
+		return &BaseServiceHealthCheckArgs{}, true
+
+	}
+	return nil, false
+}
+
+func (s *tchanBaseServiceServer) HandleArgs(ctx thrift.Context, methodName string, args athrift.TStruct) (bool, athrift.TStruct, error) {
+	switch methodName {
+	case "HealthCheck":
+		if req, ok := args.(*BaseServiceHealthCheckArgs); ok {
+			return s.handleDecodedHealthCheck(ctx, req)
+		}
+
+	default:
+		return false, nil, fmt.Errorf("method %v not found in service %v", methodName, s.Service())
+	}
+	return false, nil, fmt.Errorf("unexpected arguments %T for method %v", args, methodName)
+}
+
 func (s *tchanBaseServiceServer) handleHealthCheck(ctx thrift.Context, protocol athrift.TProtocol) (bool, athrift.TStruct, error) {
 	var req BaseServiceHealthCheckArgs
-	var res BaseServiceHealthCheckResult
 
 	if err := req.Read(protocol); err != nil {
 		return false, nil, err
 	}
 
+	return s.handleDecodedHealthCheck(ctx, &req)
+}
+
+func (s *tchanBaseServiceServer) handleDecodedHealthCheck(ctx thrift.Context, req *BaseServiceHealthCheckArgs) (bool, athrift.TStruct, error) {
+	var res BaseServiceHealthCheckResult
+
 	r, err :=
 		s.handler.HealthCheck(ctx)
 
@@ -379,3 +499,38 @@ func (s *tchanBaseServiceServer) handleHealthCheck(ctx thrift.Context, protocol
 
 	return err == nil, &res, nil
 }
+
+// tchanThriftIDLs are the Thrift IDL files that this package was generated from.
+var tchanThriftIDLs = map[string]string{
+	"keyvalue.thrift": "service baseService {\n" +
+		"  string HealthCheck()\n" +
+		"}\n" +
+		"\n" +
+		"exception KeyNotFound {\n" +
+		"  1: string key\n" +
+		"}\n" +
+		"\n" +
+		"exception InvalidKey {}\n" +
+		"\n" +
+		"service KeyValue extends baseService {\n" +
+		"  // If the key does not start with a letter, InvalidKey is returned.\n" +
+		"  // If the key does not exist, KeyNotFound is returned.\n" +
+		"  string Get(1: string key) throws (\n" +
+		"    1: KeyNotFound notFound\n" +
+		"    2: InvalidKey invalidKey)\n" +
+		"\n" +
+		"  // Set returns InvalidKey is an invalid key is sent.\n" +
+		"  void Set(1: string key, 2: string value) throws (\n" +
+		"    1: InvalidKey invalidKey\n" +
+		"  )\n" +
+		"}\n" +
+		"\n" +
+		"// Returned when the user is not authorized for the Admin service.\n" +
+		"exception NotAuthorized {}\n" +
+		"\n" +
+		"service Admin extends baseService {\n" +
+		"  void clearAll() throws (1: NotAuthorized notAuthorized)\n" +
+		"}\n",
+}
+
+const tchanThriftIDLEntryPoint = "keyvalue.thrift"
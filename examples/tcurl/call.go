@@ -0,0 +1,311 @@
+// Copyright (c) 2015 Uber Technologies, Inc.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/uber/tchannel-go"
+	"github.com/uber/tchannel-go/raw"
+	tthrift "github.com/uber/tchannel-go/thrift"
+	"github.com/uber/tchannel-go/thrift/dynamic"
+
+	"golang.org/x/net/context"
+)
+
+type callCommand struct {
+	Format string `short:"f" long:"format" choice:"raw" choice:"json" choice:"thrift" description:"The format of the call, which defaults to thrift if --thrift is set, and json otherwise"`
+	Thrift string `long:"thrift" description:"The Thrift IDL used to convert the JSON arguments and result for thrift calls, which is fetched from the service's meta endpoint if not set"`
+
+	Arg2 string `short:"2" long:"arg2" description:"arg2 for raw calls, use @file to read from a file"`
+	Arg3 string `short:"3" long:"arg3" description:"The request body, use @file to read from a file or - to read from stdin"`
+
+	Args struct {
+		Service string `positional-arg-name:"service" description:"The service to call"`
+		Method  string `positional-arg-name:"method" description:"The method to call, which is Service::method for thrift"`
+	} `positional-args:"yes" required:"yes"`
+}
+
+// attempt records the timing of a single call attempt.
+type attempt struct {
+	peer     string
+	duration time.Duration
+	err      error
+}
+
+// callResult is the response of a call along with the attempts made.
+type callResult struct {
+	arg2, arg3 []byte
+	appError   bool
+	attempts   []attempt
+	duration   time.Duration
+}
+
+// headersFn returns arg2 for a call attempt, which can depend on the call
+// since tracing headers are added for each attempt.
+type headersFn func(call *tchannel.OutboundCall) ([]byte, error)
+
+func (c *callCommand) Execute([]string) error {
+	format := tchannel.Format(c.Format)
+	if format == "" {
+		format = tchannel.JSON
+		if c.Thrift != "" {
+			format = tchannel.Thrift
+		}
+	}
+
+	arg2, err := readInput(c.Arg2)
+	if err != nil {
+		return err
+	}
+	arg3, err := readInput(c.Arg3)
+	if err != nil {
+		return err
+	}
+
+	ch, err := newChannel()
+	if err != nil {
+		return err
+	}
+	defer ch.Close()
+
+	ctx, cancel, err := newContext()
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	switch format {
+	case tchannel.Raw:
+		return c.callRaw(ctx, ch, arg2, arg3)
+	case tchannel.JSON:
+		return c.callJSON(ctx, ch, arg3)
+	default:
+		return c.callThrift(ctx, ch, arg3)
+	}
+}
+
+func (c *callCommand) callRaw(ctx tchannel.ContextWithHeaders, ch *tchannel.Channel, arg2, arg3 []byte) error {
+	rawArg2 := func(*tchannel.OutboundCall) ([]byte, error) { return arg2, nil }
+	res, err := makeCall(ctx, ch, c.Args.Service, c.Args.Method, tchannel.Raw, rawArg2, arg3)
+	if err != nil {
+		return err
+	}
+
+	verbosef("arg2: %q", res.arg2)
+	stdout.Write(res.arg3)
+	return checkAppError(res)
+}
+
+func (c *callCommand) callJSON(ctx tchannel.ContextWithHeaders, ch *tchannel.Channel, arg3 []byte) error {
+	if len(arg3) == 0 {
+		arg3 = []byte("{}")
+	}
+	if !json.Valid(arg3) {
+		return fmt.Errorf("arg3 is not valid JSON")
+	}
+
+	jsonHeaders := func(call *tchannel.OutboundCall) ([]byte, error) {
+		return json.Marshal(tchannel.InjectOutboundSpan(call.Response(), ctx.Headers()))
+	}
+	res, err := makeCall(ctx, ch, c.Args.Service, c.Args.Method, tchannel.JSON, jsonHeaders, arg3)
+	if err != nil {
+		return err
+	}
+
+	var headers map[string]string
+	if len(res.arg2) > 0 {
+		if err := json.Unmarshal(res.arg2, &headers); err != nil {
+			return fmt.Errorf("failed to parse response headers: %v", err)
+		}
+	}
+	printHeaders(headers)
+
+	var body interface{}
+	if err := json.Unmarshal(res.arg3, &body); err != nil {
+		return fmt.Errorf("failed to parse response: %v", err)
+	}
+	if err := printJSON(body); err != nil {
+		return err
+	}
+	return checkAppError(res)
+}
+
+func (c *callCommand) callThrift(ctx tchannel.ContextWithHeaders, ch *tchannel.Channel, arg3 []byte) error {
+	var (
+		idl *dynamic.IDL
+		err error
+	)
+	if c.Thrift != "" {
+		idl, err = dynamic.Parse(c.Thrift)
+	} else {
+		verbosef("Fetching Thrift IDL for %v\n", c.Args.Service)
+		idl, err = parseFetchedIDL(ctx, ch, c.Args.Service)
+	}
+	if err != nil {
+		return err
+	}
+
+	parts := strings.SplitN(c.Args.Method, "::", 2)
+	if len(parts) != 2 {
+		return fmt.Errorf("thrift method must be of the form Service::method")
+	}
+	m, err := idl.Method(parts[0], parts[1])
+	if err != nil {
+		return err
+	}
+
+	var args map[string]interface{}
+	if len(arg3) > 0 {
+		decoder := json.NewDecoder(bytes.NewReader(arg3))
+		decoder.UseNumber()
+		if err := decoder.Decode(&args); err != nil {
+			return fmt.Errorf("failed to parse arguments: %v", err)
+		}
+	}
+
+	argsBuf := &bytes.Buffer{}
+	if err := tthrift.WriteStruct(argsBuf, m.Args(args)); err != nil {
+		return fmt.Errorf("failed to encode arguments: %v", err)
+	}
+
+	thriftHeaders := func(call *tchannel.OutboundCall) ([]byte, error) {
+		buf := &bytes.Buffer{}
+		err := tthrift.WriteHeaders(buf, tchannel.InjectOutboundSpan(call.Response(), ctx.Headers()))
+		return buf.Bytes(), err
+	}
+	res, err := makeCall(ctx, ch, c.Args.Service, c.Args.Method, tchannel.Thrift, thriftHeaders, argsBuf.Bytes())
+	if err != nil {
+		return err
+	}
+
+	headers, err := tthrift.ReadHeaders(bytes.NewReader(res.arg2))
+	if err != nil {
+		return fmt.Errorf("failed to read response headers: %v", err)
+	}
+	printHeaders(headers)
+
+	result := m.Result()
+	if err := tthrift.ReadStruct(bytes.NewReader(res.arg3), result); err != nil {
+		return fmt.Errorf("failed to read response: %v", err)
+	}
+
+	var out interface{} = result.Value["success"]
+	if res.appError {
+		out = result.Value
+	}
+	if err := printJSON(out); err != nil {
+		return err
+	}
+	return checkAppError(res)
+}
+
+// makeCall makes a call with retries, recording the timing of each attempt.
+func makeCall(ctx context.Context, ch *tchannel.Channel, service, method string,
+	format tchannel.Format, arg2Fn headersFn, arg3 []byte) (*callResult, error) {
+
+	res := &callResult{}
+	sc := ch.GetSubChannel(service)
+	start := time.Now()
+	err := ch.RunWithRetry(ctx, func(ctx context.Context, rs *tchannel.RequestState) error {
+		attemptStart := time.Now()
+		call, err := sc.BeginCall(ctx, method, &tchannel.CallOptions{
+			Format:       format,
+			RequestState: rs,
+		})
+		if err == nil {
+			err = writeArgs(call, arg2Fn, arg3, res)
+		}
+
+		a := attempt{duration: time.Since(attemptStart), err: err}
+		if call != nil {
+			a.peer = call.RemotePeer().HostPort
+		}
+		res.attempts = append(res.attempts, a)
+		return err
+	})
+	res.duration = time.Since(start)
+
+	for i, a := range res.attempts {
+		if a.err != nil {
+			verbosef("attempt %v to %v failed after %v: %v", i+1, a.peer, a.duration, a.err)
+		} else {
+			verbosef("attempt %v to %v succeeded after %v", i+1, a.peer, a.duration)
+		}
+	}
+	verbosef("total time: %v", res.duration)
+	return res, err
+}
+
+func writeArgs(call *tchannel.OutboundCall, arg2Fn headersFn, arg3 []byte, res *callResult) error {
+	arg2, err := arg2Fn(call)
+	if err != nil {
+		return err
+	}
+
+	respArg2, respArg3, resp, err := raw.WriteArgs(call, arg2, arg3)
+	if err != nil {
+		return err
+	}
+
+	res.arg2, res.arg3, res.appError = respArg2, respArg3, resp.ApplicationError()
+	return nil
+}
+
+// readInput returns the bytes for an argument, which may refer to a file
+// using "@file", or stdin using "-".
+func readInput(arg string) ([]byte, error) {
+	switch {
+	case arg == "-":
+		return ioutil.ReadAll(os.Stdin)
+	case strings.HasPrefix(arg, "@"):
+		return ioutil.ReadFile(arg[1:])
+	}
+	return []byte(arg), nil
+}
+
+func checkAppError(res *callResult) error {
+	if res.appError {
+		return fmt.Errorf("call failed with an application error")
+	}
+	return nil
+}
+
+func printHeaders(headers map[string]string) {
+	for k, v := range headers {
+		verbosef("header %v: %v", k, v)
+	}
+}
+
+func printJSON(v interface{}) error {
+	out, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(stdout, string(out))
+	return nil
+}
@@ -0,0 +1,85 @@
+// Copyright (c) 2015 Uber Technologies, Inc.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	"fmt"
+
+	tthrift "github.com/uber/tchannel-go/thrift"
+	"github.com/uber/tchannel-go/thrift/gen-go/meta"
+)
+
+type healthCommand struct {
+	Type string `long:"type" default:"process" choice:"process" choice:"traffic" description:"The type of health check"`
+
+	Args struct {
+		Service string `positional-arg-name:"service" description:"The service to check"`
+	} `positional-args:"yes" required:"yes"`
+}
+
+var healthTypes = map[string]meta.HealthRequestType{
+	"process": meta.HealthRequestType_PROCESS,
+	"traffic": meta.HealthRequestType_TRAFFIC,
+}
+
+func (c *healthCommand) Execute([]string) error {
+	ch, err := newChannel()
+	if err != nil {
+		return err
+	}
+	defer ch.Close()
+
+	ctx, cancel, err := newContext()
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	healthType := healthTypes[c.Type]
+	args := &meta.MetaHealthArgs{Hr: &meta.HealthRequest{Type: &healthType}}
+	var result meta.MetaHealthResult
+
+	client := tthrift.NewClient(ch, c.Args.Service, nil)
+	success, err := client.Call(ctx, "Meta", "health", args, &result)
+	if err != nil {
+		return err
+	}
+	if !success || result.Success == nil {
+		return fmt.Errorf("health call returned no result")
+	}
+
+	status := result.Success
+	out := map[string]interface{}{"ok": status.Ok}
+	if status.Message != nil {
+		out["message"] = *status.Message
+	}
+	if status.State != nil {
+		out["state"] = status.State.String()
+	}
+	if err := printJSON(out); err != nil {
+		return err
+	}
+
+	if !status.Ok {
+		return fmt.Errorf("service %v is not healthy", c.Args.Service)
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+// Copyright (c) 2015 Uber Technologies, Inc.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/uber/tchannel-go"
+	tthrift "github.com/uber/tchannel-go/thrift"
+	"github.com/uber/tchannel-go/thrift/dynamic"
+	"github.com/uber/tchannel-go/thrift/gen-go/meta"
+)
+
+type idlCommand struct {
+	Args struct {
+		Service string `positional-arg-name:"service" description:"The service to fetch the Thrift IDL for"`
+	} `positional-args:"yes" required:"yes"`
+}
+
+func (c *idlCommand) Execute([]string) error {
+	ch, err := newChannel()
+	if err != nil {
+		return err
+	}
+	defer ch.Close()
+
+	ctx, cancel, err := newContext()
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	idls, err := fetchIDL(ctx, ch, c.Args.Service)
+	if err != nil {
+		return err
+	}
+
+	return printJSON(idls)
+}
+
+// fetchIDL calls the Thrift meta endpoint of the given service to get the
+// Thrift IDL that it was generated from.
+func fetchIDL(ctx tchannel.ContextWithHeaders, ch *tchannel.Channel, service string) (*meta.ThriftIDLs, error) {
+	var result meta.MetaThriftIDLResult
+
+	client := tthrift.NewClient(ch, service, nil)
+	success, err := client.Call(ctx, "Meta", "thriftIDL", &meta.MetaThriftIDLArgs{}, &result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch Thrift IDL for %v: %v", service, err)
+	}
+	if !success || result.Success == nil {
+		return nil, fmt.Errorf("thriftIDL call for %v returned no result", service)
+	}
+	return result.Success, nil
+}
+
+// parseFetchedIDL fetches the Thrift IDL for the given service and parses it.
+func parseFetchedIDL(ctx tchannel.ContextWithHeaders, ch *tchannel.Channel, service string) (*dynamic.IDL, error) {
+	idls, err := fetchIDL(ctx, ch, service)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string]string, len(idls.Idls))
+	for filename, contents := range idls.Idls {
+		files[string(filename)] = contents
+	}
+	return dynamic.ParseFiles(files, string(idls.EntryPoint))
+}
@@ -0,0 +1,156 @@
+// Copyright (c) 2015 Uber Technologies, Inc.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// tcurl makes ad-hoc TChannel calls from the command line, using the raw,
+// json or thrift formats, and prints the response along with timings.
+//
+// Usage:
+//
+//	tcurl -p 127.0.0.1:12345 call keyvalue KeyValue::Get --thrift keyvalue.thrift -3 '{"key": "foo"}'
+//	tcurl -p 127.0.0.1:12345 health keyvalue
+//	tcurl -p 127.0.0.1:12345 idl keyvalue
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jessevdk/go-flags"
+	"github.com/uber/tchannel-go"
+	"golang.org/x/net/context"
+)
+
+// globalOptions are the options shared by all commands.
+type globalOptions struct {
+	CallerName string `short:"c" long:"caller" default:"tcurl" description:"The caller name used for outbound calls"`
+
+	// Peers can be specified multiple times to add multiple peers.
+	Peers    []string `short:"p" long:"peer" description:"A host:port to send calls to"`
+	PeerList string   `short:"P" long:"peerList" description:"A JSON file containing a list of host:ports to send calls to"`
+
+	Timeout time.Duration `short:"t" long:"timeout" default:"1s" description:"The timeout for the call, including retries"`
+
+	// Retries is the number of retries, so the number of attempts is Retries + 1.
+	Retries int    `long:"retries" default:"0" description:"The number of times to retry the call"`
+	RetryOn string `long:"retryOn" default:"connection" choice:"connection" choice:"never" choice:"nonIdempotent" choice:"unexpected" choice:"idempotent" description:"The types of errors to retry on"`
+
+	// Headers can be specified multiple times to add multiple headers.
+	Headers         []string `short:"H" long:"header" description:"An application header to send, as key:value"`
+	ShardKey        string   `long:"shardKey" description:"The shard key for the call"`
+	RoutingKey      string   `long:"routingKey" description:"The routing key for the call"`
+	RoutingDelegate string   `long:"routingDelegate" description:"The routing delegate for the call"`
+
+	Verbose bool `short:"v" long:"verbose" description:"Print response headers and timings to stderr"`
+}
+
+var (
+	options globalOptions
+	stdout  = os.Stdout
+	stderr  = os.Stderr
+)
+
+var retryOnNames = map[string]tchannel.RetryOn{
+	"connection":    tchannel.RetryConnectionError,
+	"never":         tchannel.RetryNever,
+	"nonIdempotent": tchannel.RetryNonIdempotent,
+	"unexpected":    tchannel.RetryUnexpected,
+	"idempotent":    tchannel.RetryIdempotent,
+}
+
+func main() {
+	parser := flags.NewParser(&options, flags.Default)
+	parser.AddCommand("call", "Make a call",
+		"Make a call to the given service and method using the raw, json or thrift format.", &callCommand{})
+	parser.AddCommand("health", "Call the Thrift health endpoint",
+		"Call the Thrift meta health endpoint of the given service.", &healthCommand{})
+	parser.AddCommand("idl", "Fetch the Thrift IDL",
+		"Fetch the Thrift IDL of the given service from the Thrift meta endpoint.", &idlCommand{})
+
+	if _, err := parser.Parse(); err != nil {
+		if flagsErr, ok := err.(*flags.Error); ok && flagsErr.Type == flags.ErrHelp {
+			os.Exit(0)
+		}
+		os.Exit(1)
+	}
+}
+
+// newChannel returns a channel with the peers specified in the options added.
+func newChannel() (*tchannel.Channel, error) {
+	peers := options.Peers
+	if options.PeerList != "" {
+		contents, err := ioutil.ReadFile(options.PeerList)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read peer list: %v", err)
+		}
+
+		var filePeers []string
+		if err := json.Unmarshal(contents, &filePeers); err != nil {
+			return nil, fmt.Errorf("failed to parse peer list %v: %v", options.PeerList, err)
+		}
+		peers = append(peers, filePeers...)
+	}
+	if len(peers) == 0 {
+		return nil, fmt.Errorf("at least one peer must be specified using --peer or --peerList")
+	}
+
+	ch, err := tchannel.NewChannel(options.CallerName, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, hostPort := range peers {
+		ch.Peers().Add(hostPort)
+	}
+	return ch, nil
+}
+
+// newContext returns a context for a call using the options.
+func newContext() (tchannel.ContextWithHeaders, context.CancelFunc, error) {
+	headers := make(map[string]string, len(options.Headers))
+	for _, h := range options.Headers {
+		parts := strings.SplitN(h, ":", 2)
+		if len(parts) != 2 {
+			return nil, nil, fmt.Errorf("header %q must be specified as key:value", h)
+		}
+		headers[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	}
+
+	ctx, cancel := tchannel.NewContextBuilder(options.Timeout).
+		SetHeaders(headers).
+		SetShardKey(options.ShardKey).
+		SetRoutingKey(options.RoutingKey).
+		SetRoutingDelegate(options.RoutingDelegate).
+		SetRetryOptions(&tchannel.RetryOptions{
+			MaxAttempts: options.Retries + 1,
+			RetryOn:     retryOnNames[options.RetryOn],
+		}).
+		Build()
+	return ctx, cancel, nil
+}
+
+// verbosef prints to stderr if verbose output is enabled.
+func verbosef(format string, args ...interface{}) {
+	if options.Verbose {
+		fmt.Fprintf(stderr, format+"\n", args...)
+	}
+}
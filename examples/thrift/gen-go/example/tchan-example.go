@@ -86,6 +86,10 @@ func (s *tchanBaseServer) Methods() []string {
 	}
 }
 
+func (s *tchanBaseServer) ThriftIDL() (map[string]string, string) {
+	return tchanThriftIDLs, tchanThriftIDLEntryPoint
+}
+
 func (s *tchanBaseServer) Handle(ctx thrift.Context, methodName string, protocol athrift.TProtocol) (bool, athrift.TStruct, error) {
 	switch methodName {
 	case "BaseCall":
@@ -96,14 +100,41 @@ func (s *tchanBaseServer) Handle(ctx thrift.Context, methodName string, protocol
 	}
 }
 
+func (s *tchanBaseServer) NewArgs(methodName string) (athrift.TStruct, bool) {
+	switch methodName {
+	case "BaseCall":
+		return &BaseBaseCallArgs{}, true
+
+	}
+	return nil, false
+}
+
+func (s *tchanBaseServer) HandleArgs(ctx thrift.Context, methodName string, args athrift.TStruct) (bool, athrift.TStruct, error) {
+	switch methodName {
+	case "BaseCall":
+		if req, ok := args.(*BaseBaseCallArgs); ok {
+			return s.handleDecodedBaseCall(ctx, req)
+		}
+
+	default:
+		return false, nil, fmt.Errorf("method %v not found in service %v", methodName, s.Service())
+	}
+	return false, nil, fmt.Errorf("unexpected arguments %T for method %v", args, methodName)
+}
+
 func (s *tchanBaseServer) handleBaseCall(ctx thrift.Context, protocol athrift.TProtocol) (bool, athrift.TStruct, error) {
 	var req BaseBaseCallArgs
-	var res BaseBaseCallResult
 
 	if err := req.Read(protocol); err != nil {
 		return false, nil, err
 	}
 
+	return s.handleDecodedBaseCall(ctx, &req)
+}
+
+func (s *tchanBaseServer) handleDecodedBaseCall(ctx thrift.Context, req *BaseBaseCallArgs) (bool, athrift.TStruct, error) {
+	var res BaseBaseCallResult
+
 	err :=
 		s.handler.BaseCall(ctx)
 
@@ -208,6 +239,10 @@ func (s *tchanFirstServer) Methods() []string {
 	}
 }
 
+func (s *tchanFirstServer) ThriftIDL() (map[string]string, string) {
+	return tchanThriftIDLs, tchanThriftIDLEntryPoint
+}
+
 func (s *tchanFirstServer) Handle(ctx thrift.Context, methodName string, protocol athrift.TProtocol) (bool, athrift.TStruct, error) {
 	switch methodName {
 	case "AppError":
@@ -224,14 +259,61 @@ func (s *tchanFirstServer) Handle(ctx thrift.Context, methodName string, protoco
 	}
 }
 
+func (s *tchanFirstServer) NewArgs(methodName string) (athrift.TStruct, bool) {
+	switch methodName {
+	case "AppError":
+		return &FirstAppErrorArgs{}, true
+	case "Echo":
+		return &FirstEchoArgs{}, true
+	case "Healthcheck":
+		return &FirstHealthcheckArgs{}, true
+
+	case "BaseCall":
+		if s, ok := s.TChanServer.(thrift.TChanArgsServer); ok {
+			return s.NewArgs(methodName)
+		}
+	}
+	return nil, false
+}
+
+func (s *tchanFirstServer) HandleArgs(ctx thrift.Context, methodName string, args athrift.TStruct) (bool, athrift.TStruct, error) {
+	switch methodName {
+	case "AppError":
+		if req, ok := args.(*FirstAppErrorArgs); ok {
+			return s.handleDecodedAppError(ctx, req)
+		}
+	case "Echo":
+		if req, ok := args.(*FirstEchoArgs); ok {
+			return s.handleDecodedEcho(ctx, req)
+		}
+	case "Healthcheck":
+		if req, ok := args.(*FirstHealthcheckArgs); ok {
+			return s.handleDecodedHealthcheck(ctx, req)
+		}
+
+	case "BaseCall":
+		if s, ok := s.TChanServer.(thrift.TChanArgsServer); ok {
+			return s.HandleArgs(ctx, methodName, args)
+		}
+	default:
+		return false, nil, fmt.Errorf("method %v not found in service %v", methodName, s.Service())
+	}
+	return false, nil, fmt.Errorf("unexpected arguments %T for method %v", args, methodName)
+}
+
 func (s *tchanFirstServer) handleAppError(ctx thrift.Context, protocol athrift.TProtocol) (bool, athrift.TStruct, error) {
 	var req FirstAppErrorArgs
-	var res FirstAppErrorResult
 
 	if err := req.Read(protocol); err != nil {
 		return false, nil, err
 	}
 
+	return s.handleDecodedAppError(ctx, &req)
+}
+
+func (s *tchanFirstServer) handleDecodedAppError(ctx thrift.Context, req *FirstAppErrorArgs) (bool, athrift.TStruct, error) {
+	var res FirstAppErrorResult
+
 	err :=
 		s.handler.AppError(ctx)
 
@@ -245,12 +327,17 @@ func (s *tchanFirstServer) handleAppError(ctx thrift.Context, protocol athrift.T
 
 func (s *tchanFirstServer) handleEcho(ctx thrift.Context, protocol athrift.TProtocol) (bool, athrift.TStruct, error) {
 	var req FirstEchoArgs
-	var res FirstEchoResult
 
 	if err := req.Read(protocol); err != nil {
 		return false, nil, err
 	}
 
+	return s.handleDecodedEcho(ctx, &req)
+}
+
+func (s *tchanFirstServer) handleDecodedEcho(ctx thrift.Context, req *FirstEchoArgs) (bool, athrift.TStruct, error) {
+	var res FirstEchoResult
+
 	r, err :=
 		s.handler.Echo(ctx, req.Msg)
 
@@ -265,12 +352,17 @@ func (s *tchanFirstServer) handleEcho(ctx thrift.Context, protocol athrift.TProt
 
 func (s *tchanFirstServer) handleHealthcheck(ctx thrift.Context, protocol athrift.TProtocol) (bool, athrift.TStruct, error) {
 	var req FirstHealthcheckArgs
-	var res FirstHealthcheckResult
 
 	if err := req.Read(protocol); err != nil {
 		return false, nil, err
 	}
 
+	return s.handleDecodedHealthcheck(ctx, &req)
+}
+
+func (s *tchanFirstServer) handleDecodedHealthcheck(ctx thrift.Context, req *FirstHealthcheckArgs) (bool, athrift.TStruct, error) {
+	var res FirstHealthcheckResult
+
 	r, err :=
 		s.handler.Healthcheck(ctx)
 
@@ -336,6 +428,10 @@ func (s *tchanSecondServer) Methods() []string {
 	}
 }
 
+func (s *tchanSecondServer) ThriftIDL() (map[string]string, string) {
+	return tchanThriftIDLs, tchanThriftIDLEntryPoint
+}
+
 func (s *tchanSecondServer) Handle(ctx thrift.Context, methodName string, protocol athrift.TProtocol) (bool, athrift.TStruct, error) {
 	switch methodName {
 	case "Test":
@@ -346,14 +442,41 @@ func (s *tchanSecondServer) Handle(ctx thrift.Context, methodName string, protoc
 	}
 }
 
+func (s *tchanSecondServer) NewArgs(methodName string) (athrift.TStruct, bool) {
+	switch methodName {
+	case "Test":
+		return &SecondTestArgs{}, true
+
+	}
+	return nil, false
+}
+
+func (s *tchanSecondServer) HandleArgs(ctx thrift.Context, methodName string, args athrift.TStruct) (bool, athrift.TStruct, error) {
+	switch methodName {
+	case "Test":
+		if req, ok := args.(*SecondTestArgs); ok {
+			return s.handleDecodedTest(ctx, req)
+		}
+
+	default:
+		return false, nil, fmt.Errorf("method %v not found in service %v", methodName, s.Service())
+	}
+	return false, nil, fmt.Errorf("unexpected arguments %T for method %v", args, methodName)
+}
+
 func (s *tchanSecondServer) handleTest(ctx thrift.Context, protocol athrift.TProtocol) (bool, athrift.TStruct, error) {
 	var req SecondTestArgs
-	var res SecondTestResult
 
 	if err := req.Read(protocol); err != nil {
 		return false, nil, err
 	}
 
+	return s.handleDecodedTest(ctx, &req)
+}
+
+func (s *tchanSecondServer) handleDecodedTest(ctx thrift.Context, req *SecondTestArgs) (bool, athrift.TStruct, error) {
+	var res SecondTestResult
+
 	err :=
 		s.handler.Test(ctx)
 
@@ -364,3 +487,27 @@ func (s *tchanSecondServer) handleTest(ctx thrift.Context, protocol athrift.TPro
 
 	return err == nil, &res, nil
 }
+
+// tchanThriftIDLs are the Thrift IDL files that this package was generated from.
+var tchanThriftIDLs = map[string]string{
+	"example.thrift": "struct HealthCheckRes {\n" +
+		"  1: bool healthy,\n" +
+		"  2: string msg,\n" +
+		"}\n" +
+		"\n" +
+		"service Base {\n" +
+		"  void BaseCall()\n" +
+		"}\n" +
+		"\n" +
+		"service First extends Base {\n" +
+		"  string Echo(1:string msg)\n" +
+		"  HealthCheckRes Healthcheck()\n" +
+		"  void AppError()\n" +
+		"}\n" +
+		"\n" +
+		"service Second {\n" +
+		"  void Test()\n" +
+		"}\n",
+}
+
+const tchanThriftIDLEntryPoint = "example.thrift"
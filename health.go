@@ -21,6 +21,7 @@
 package tchannel
 
 import (
+	"sort"
 	"sync"
 	"time"
 
@@ -28,12 +29,49 @@ import (
 )
 
 const (
-	_defaultHealthCheckTimeout         = time.Second
-	_defaultHealthCheckFailuresToClose = 5
+	_defaultHealthCheckTimeout           = time.Second
+	_defaultHealthCheckFailuresToClose   = 5
+	_defaultHealthCheckReconnectAttempts = 3
+	_defaultHealthCheckReconnectBackoff  = 100 * time.Millisecond
 
 	_healthHistorySize = 256
 )
 
+// _healthCheckRTTBuckets are the upper bounds of the buckets used to track
+// the round-trip time of successful health checks.
+var _healthCheckRTTBuckets = []time.Duration{
+	time.Millisecond,
+	2 * time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+}
+
+// HealthCheckFailureAction is the action taken when a connection fails
+// FailuresToClose consecutive health checks.
+type HealthCheckFailureAction int
+
+const (
+	// HealthCheckClose closes the connection. This is the default action.
+	HealthCheckClose HealthCheckFailureAction = iota
+
+	// HealthCheckDegrade keeps the connection open, but marks it as degraded
+	// until a health check succeeds. Peers where all connections are degraded
+	// are only preferred over unconnected peers by the default peer scoring,
+	// and OnPeerStatusChanged is called when a connection is degraded or recovers.
+	HealthCheckDegrade
+
+	// HealthCheckReconnect creates a new connection to the peer, retrying with
+	// backoff, and then closes the connection. Connections to ephemeral peers
+	// are closed without reconnecting.
+	HealthCheckReconnect
+)
+
 // HealthCheckOptions are the parameters to configure active TChannel health
 // checks. These are not intended to check application level health, but
 // TCP connection health (similar to TCP keep-alives). The health checks use
@@ -48,9 +86,41 @@ type HealthCheckOptions struct {
 	Timeout time.Duration
 
 	// FailuresToClose is the number of consecutive health check failures that
-	// will cause this connection to be closed.
+	// will cause the FailureAction to be taken, which closes the connection
+	// by default.
 	// If no value is specified, it defaults to 5.
 	FailuresToClose int
+
+	// FailureAction is the action taken once FailuresToClose consecutive
+	// health checks have failed.
+	FailureAction HealthCheckFailureAction
+
+	// ReconnectAttempts is the maximum number of attempts to create a new
+	// connection when using HealthCheckReconnect.
+	// If no value is specified, it defaults to 3.
+	ReconnectAttempts int
+
+	// ReconnectBackoff is the time to wait after the first failed attempt
+	// to reconnect, which doubles after every failed attempt.
+	// If no value is specified, it defaults to 100ms.
+	ReconnectBackoff time.Duration
+}
+
+// HealthCheckRTTBucket is the number of successful health checks with a
+// round-trip time less than or equal to UpperBound.
+type HealthCheckRTTBucket struct {
+	UpperBound time.Duration `json:"upperBound"`
+	Count      int           `json:"count"`
+}
+
+// HealthCheckRTTState is a histogram of the round-trip times of successful
+// health checks on a connection. The last bucket has no upper bound.
+type HealthCheckRTTState struct {
+	Count   int                    `json:"count"`
+	Min     time.Duration          `json:"min"`
+	Max     time.Duration          `json:"max"`
+	Total   time.Duration          `json:"total"`
+	Buckets []HealthCheckRTTBucket `json:"buckets"`
 }
 
 type healthHistory struct {
@@ -60,11 +130,20 @@ type healthHistory struct {
 
 	insertAt int
 	total    int
+
+	// rttCounts has a count for every bucket in _healthCheckRTTBuckets,
+	// and an extra bucket for round-trip times above the last bucket.
+	rttCounts []int
+	rttCount  int
+	rttMin    time.Duration
+	rttMax    time.Duration
+	rttTotal  time.Duration
 }
 
 func newHealthHistory() *healthHistory {
 	return &healthHistory{
-		states: make([]bool, _healthHistorySize),
+		states:    make([]bool, _healthHistorySize),
+		rttCounts: make([]int, len(_healthCheckRTTBuckets)+1),
 	}
 }
 
@@ -92,6 +171,50 @@ func (hh *healthHistory) asBools() []bool {
 	return copyStates
 }
 
+func (hh *healthHistory) addRTT(d time.Duration) {
+	hh.Lock()
+	defer hh.Unlock()
+
+	bucket := sort.Search(len(_healthCheckRTTBuckets), func(i int) bool {
+		return d <= _healthCheckRTTBuckets[i]
+	})
+	hh.rttCounts[bucket]++
+	if hh.rttCount == 0 || d < hh.rttMin {
+		hh.rttMin = d
+	}
+	if d > hh.rttMax {
+		hh.rttMax = d
+	}
+	hh.rttCount++
+	hh.rttTotal += d
+}
+
+// rttState returns the round-trip time histogram, or nil if there have been
+// no successful health checks.
+func (hh *healthHistory) rttState() *HealthCheckRTTState {
+	hh.RLock()
+	defer hh.RUnlock()
+
+	if hh.rttCount == 0 {
+		return nil
+	}
+
+	buckets := make([]HealthCheckRTTBucket, len(hh.rttCounts))
+	for i, count := range hh.rttCounts {
+		buckets[i].Count = count
+		if i < len(_healthCheckRTTBuckets) {
+			buckets[i].UpperBound = _healthCheckRTTBuckets[i]
+		}
+	}
+	return &HealthCheckRTTState{
+		Count:   hh.rttCount,
+		Min:     hh.rttMin,
+		Max:     hh.rttMax,
+		Total:   hh.rttTotal,
+		Buckets: buckets,
+	}
+}
+
 func (hco HealthCheckOptions) enabled() bool {
 	return hco.Interval > 0
 }
@@ -103,6 +226,14 @@ func (hco HealthCheckOptions) withDefaults() HealthCheckOptions {
 	if hco.FailuresToClose == 0 {
 		hco.FailuresToClose = _defaultHealthCheckFailuresToClose
 	}
+	if hco.FailureAction == HealthCheckReconnect {
+		if hco.ReconnectAttempts == 0 {
+			hco.ReconnectAttempts = _defaultHealthCheckReconnectAttempts
+		}
+		if hco.ReconnectBackoff == 0 {
+			hco.ReconnectBackoff = _defaultHealthCheckReconnectBackoff
+		}
+	}
 	return hco
 }
 
@@ -125,14 +256,21 @@ func (c *Connection) healthCheck(connID uint32) {
 		}
 
 		ctx, cancel := context.WithTimeout(c.healthCheckCtx, opts.Timeout)
+		start := c.timeNow()
 		err := c.ping(ctx)
+		rtt := c.timeNow().Sub(start)
 		cancel()
 		c.healthCheckHistory.add(err == nil)
 		if err == nil {
+			c.healthCheckHistory.addRTT(rtt)
+			c.connStats.healthCheckRTT(rtt)
 			if c.log.Enabled(LogLevelDebug) {
 				c.log.Debug("Performed successful active health check.")
 			}
 			consecutiveFailures = 0
+			if c.setDegraded(false) {
+				c.log.Info("Connection passed active health check, no longer degraded.")
+			}
 			continue
 		}
 
@@ -144,20 +282,87 @@ func (c *Connection) healthCheck(connID uint32) {
 		}
 
 		consecutiveFailures++
+		c.connStats.healthCheckFailed()
 		c.log.WithFields(LogFields{
 			{"consecutiveFailures", consecutiveFailures},
 			ErrField(err),
 			{"failuresToClose", opts.FailuresToClose},
 		}...).Warn("Failed active health check.")
 
-		if consecutiveFailures >= opts.FailuresToClose {
-			c.close(LogFields{
-				{"reason", "health check failure"},
-				ErrField(err),
-			}...)
+		if consecutiveFailures < opts.FailuresToClose {
+			continue
+		}
+
+		switch opts.FailureAction {
+		case HealthCheckDegrade:
+			if c.setDegraded(true) {
+				c.log.WithFields(ErrField(err)).Warn("Connection degraded due to failed health checks.")
+			}
+			continue
+		case HealthCheckReconnect:
+			c.healthCheckReconnect(opts)
+		}
+
+		c.close(LogFields{
+			{"reason", "health check failure"},
+			ErrField(err),
+		}...)
+		return
+	}
+}
+
+// healthCheckReconnect creates a new connection to the remote peer, so that
+// new calls can use it before this connection is closed.
+func (c *Connection) healthCheckReconnect(opts HealthCheckOptions) {
+	hostPort := c.outboundHP
+	if hostPort == "" && !c.remotePeerInfo.IsEphemeral {
+		hostPort = c.remotePeerInfo.HostPort
+	}
+	if hostPort == "" || c.events.Reconnect == nil {
+		c.log.Debug("Cannot reconnect to ephemeral peer after failed health checks.")
+		return
+	}
+
+	backoff := opts.ReconnectBackoff
+	for attempt := 1; ; attempt++ {
+		ctx, cancel := context.WithTimeout(c.healthCheckCtx, opts.Timeout)
+		err := c.events.Reconnect(ctx, hostPort)
+		cancel()
+		if err == nil {
+			c.log.WithFields(LogField{"attempt", attempt}).Info("Reconnected after failed health checks.")
 			return
 		}
+
+		logger := c.log.WithFields(
+			LogField{"attempt", attempt},
+			LogField{"reconnectAttempts", opts.ReconnectAttempts},
+			ErrField(err),
+		)
+		if attempt >= opts.ReconnectAttempts {
+			logger.Warn("Failed to reconnect after failed health checks.")
+			return
+		}
+		logger.Info("Failed reconnect attempt after failed health checks, will retry.")
+
+		select {
+		case <-time.After(backoff):
+		case <-c.healthCheckCtx.Done():
+			return
+		}
+		backoff *= 2
+	}
+}
+
+// setDegraded sets whether the connection is degraded due to health check
+// failures, and returns whether the value changed.
+func (c *Connection) setDegraded(degraded bool) bool {
+	if !c.degraded.CAS(!degraded, degraded) {
+		return false
+	}
+	if f := c.events.OnHealthChange; f != nil {
+		f(c)
 	}
+	return true
 }
 
 func (c *Connection) stopHealthCheck() {
@@ -31,6 +31,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
 )
 
 func TestHealthCheckStopBeforeStart(t *testing.T) {
@@ -206,6 +207,133 @@ func TestHealthCheckIntegration(t *testing.T) {
 	}
 }
 
+func TestHealthCheckDegrade(t *testing.T) {
+	pingResponses := []bool{false, false, false, true}
+	wantDegraded := []bool{false, true, true, false}
+
+	errFrame := getErrorFrame(t)
+	opts := testutils.NewOpts().NoRelay()
+	testutils.WithTestServer(t, opts, func(t testing.TB, ts *testutils.TestServer) {
+		var pingCount int
+		frameRelay, cancel := testutils.FrameRelay(t, ts.HostPort(), func(outgoing bool, f *Frame) *Frame {
+			if strings.Contains(f.Header.String(), "PingRes") {
+				success := pingResponses[pingCount]
+				pingCount++
+				if !success {
+					errFrame.Header.ID = f.Header.ID
+					f = errFrame
+				}
+			}
+			return f
+		})
+		defer cancel()
+
+		var statusChanges atomic.Int32
+		stats := newRecordingStatsReporter()
+		ft := testutils.NewFakeTicker()
+		opts := testutils.NewOpts().
+			SetTimeTicker(ft.New).
+			SetStatsReporter(stats).
+			SetConnectionMetrics(ConnectionMetricsOptions{Enabled: true}).
+			SetHealthChecks(HealthCheckOptions{
+				Interval:        time.Second,
+				FailuresToClose: 2,
+				FailureAction:   HealthCheckDegrade,
+			}).
+			SetOnPeerStatusChanged(func(*Peer) { statusChanges.Inc() }).
+			AddLogFilter("Failed active health check.", 3).
+			AddLogFilter("Unexpected ping response.", 3).
+			AddLogFilter("Connection degraded due to failed health checks.", 1)
+		client := ts.NewClient(opts)
+
+		ctx, cancel := NewContext(time.Second)
+		defer cancel()
+
+		peer := client.RootPeers().GetOrAdd(frameRelay)
+		conn, err := peer.GetConnection(ctx)
+		require.NoError(t, err, "Failed to get connection")
+		baseStatusChanges := statusChanges.Load()
+
+		for i := range pingResponses {
+			ft.TryTick()
+			waitForNHealthChecks(t, conn, i+1)
+			assert.True(t, testutils.WaitFor(time.Second, func() bool {
+				return introspectConn(conn).Degraded == wantDegraded[i]
+			}), "Unexpected degraded state after health check %v", i+1)
+			assert.Equal(t, wantDegraded[i], peer.Degraded(), "Unexpected peer degraded state after health check %v", i+1)
+			assert.True(t, conn.IsActive(), "Degraded connection should not be closed")
+		}
+
+		// The peer status changes when the connection is degraded, and when it recovers.
+		assert.True(t, testutils.WaitFor(time.Second, func() bool {
+			return statusChanges.Load()-baseStatusChanges == 2
+		}), "Expected 2 peer status changes, got %v", statusChanges.Load()-baseStatusChanges)
+
+		stats.Lock()
+		defer stats.Unlock()
+		var failed int64
+		for _, v := range stats.Values["connection.health-checks.failed"] {
+			failed += v.count
+		}
+		var rtts int
+		for _, v := range stats.Values["connection.health-checks.rtt"] {
+			rtts += len(v.timers)
+		}
+		assert.EqualValues(t, 3, failed, "Unexpected failed health checks stat")
+		assert.Equal(t, 1, rtts, "Unexpected health check RTT stat")
+
+		rttState := introspectConn(conn).HealthCheckRTT
+		require.NotNil(t, rttState, "Missing health check RTT state")
+		assert.Equal(t, 1, rttState.Count, "Unexpected number of health check RTTs")
+	})
+}
+
+func TestHealthCheckReconnect(t *testing.T) {
+	errFrame := getErrorFrame(t)
+	opts := testutils.NewOpts().NoRelay()
+	testutils.WithTestServer(t, opts, func(t testing.TB, ts *testutils.TestServer) {
+		frameRelay, cancel := testutils.FrameRelay(t, ts.HostPort(), func(outgoing bool, f *Frame) *Frame {
+			if strings.Contains(f.Header.String(), "PingRes") {
+				errFrame.Header.ID = f.Header.ID
+				f = errFrame
+			}
+			return f
+		})
+		defer cancel()
+
+		ft := testutils.NewFakeTicker()
+		opts := testutils.NewOpts().
+			SetTimeTicker(ft.New).
+			SetHealthChecks(HealthCheckOptions{
+				Interval:        time.Second,
+				FailuresToClose: 1,
+				FailureAction:   HealthCheckReconnect,
+			}).
+			AddLogFilter("Failed active health check.", 1).
+			AddLogFilter("Unexpected ping response.", 1)
+		client := ts.NewClient(opts)
+
+		ctx, cancel := NewContext(time.Second)
+		defer cancel()
+
+		peer := client.RootPeers().GetOrAdd(frameRelay)
+		conn, err := peer.GetConnection(ctx)
+		require.NoError(t, err, "Failed to get connection")
+
+		ft.TryTick()
+		require.True(t, testutils.WaitFor(time.Second, func() bool {
+			return !conn.IsActive()
+		}), "Connection should be closed after failed health check")
+
+		_, outbound := peer.NumConnections()
+		assert.Equal(t, 1, outbound, "Expected a new connection to replace the failed connection")
+
+		newConn, err := peer.GetConnection(ctx)
+		require.NoError(t, err, "Failed to get connection")
+		assert.NotEqual(t, conn, newConn, "Expected new connection")
+	})
+}
+
 func waitForNHealthChecks(t testing.TB, conn *Connection, n int) {
 	require.True(t, testutils.WaitFor(time.Second, func() bool {
 		return len(introspectConn(conn).HealthChecks) >= n
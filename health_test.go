@@ -26,6 +26,7 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestHealthCheckEnabled(t *testing.T) {
@@ -57,6 +58,16 @@ func TestHealthCheckOptionsDefaults(t *testing.T) {
 			opts: HealthCheckOptions{Timeout: 2 * time.Second, FailuresToClose: 3},
 			want: HealthCheckOptions{Timeout: 2 * time.Second, FailuresToClose: 3},
 		},
+		{
+			opts: HealthCheckOptions{FailureAction: HealthCheckReconnect},
+			want: HealthCheckOptions{
+				Timeout:           _defaultHealthCheckTimeout,
+				FailuresToClose:   _defaultHealthCheckFailuresToClose,
+				FailureAction:     HealthCheckReconnect,
+				ReconnectAttempts: _defaultHealthCheckReconnectAttempts,
+				ReconnectBackoff:  _defaultHealthCheckReconnectBackoff,
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -79,3 +90,45 @@ func TestHealthHistory(t *testing.T) {
 	}
 
 }
+
+func TestHealthHistoryRTT(t *testing.T) {
+	hh := newHealthHistory()
+	assert.Nil(t, hh.rttState(), "No RTT state expected without successful health checks")
+
+	rtts := []time.Duration{
+		500 * time.Microsecond,
+		time.Millisecond,
+		3 * time.Millisecond,
+		3 * time.Millisecond,
+		2 * time.Second,
+	}
+	for _, rtt := range rtts {
+		hh.addRTT(rtt)
+	}
+
+	state := hh.rttState()
+	require.NotNil(t, state, "Missing RTT state")
+	assert.Equal(t, 5, state.Count, "Unexpected count")
+	assert.Equal(t, 500*time.Microsecond, state.Min, "Unexpected min")
+	assert.Equal(t, 2*time.Second, state.Max, "Unexpected max")
+	assert.Equal(t, 2007500*time.Microsecond, state.Total, "Unexpected total")
+
+	counts := make(map[time.Duration]int)
+	for _, b := range state.Buckets {
+		counts[b.UpperBound] = b.Count
+	}
+	assert.Len(t, state.Buckets, len(_healthCheckRTTBuckets)+1, "Unexpected number of buckets")
+	assert.Equal(t, map[time.Duration]int{
+		time.Millisecond:       2,
+		2 * time.Millisecond:   0,
+		5 * time.Millisecond:   2,
+		10 * time.Millisecond:  0,
+		25 * time.Millisecond:  0,
+		50 * time.Millisecond:  0,
+		100 * time.Millisecond: 0,
+		250 * time.Millisecond: 0,
+		500 * time.Millisecond: 0,
+		time.Second:            0,
+		0:                      1, // no upper bound
+	}, counts, "Unexpected bucket counts")
+}
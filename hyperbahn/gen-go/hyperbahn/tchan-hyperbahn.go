@@ -78,6 +78,10 @@ func (s *tchanHyperbahnServer) Methods() []string {
 	}
 }
 
+func (s *tchanHyperbahnServer) ThriftIDL() (map[string]string, string) {
+	return tchanThriftIDLs, tchanThriftIDLEntryPoint
+}
+
 func (s *tchanHyperbahnServer) Handle(ctx thrift.Context, methodName string, protocol athrift.TProtocol) (bool, athrift.TStruct, error) {
 	switch methodName {
 	case "discover":
@@ -88,14 +92,41 @@ func (s *tchanHyperbahnServer) Handle(ctx thrift.Context, methodName string, pro
 	}
 }
 
+func (s *tchanHyperbahnServer) NewArgs(methodName string) (athrift.TStruct, bool) {
+	switch methodName {
+	case "discover":
+		return &HyperbahnDiscoverArgs{}, true
+
+	}
+	return nil, false
+}
+
+func (s *tchanHyperbahnServer) HandleArgs(ctx thrift.Context, methodName string, args athrift.TStruct) (bool, athrift.TStruct, error) {
+	switch methodName {
+	case "discover":
+		if req, ok := args.(*HyperbahnDiscoverArgs); ok {
+			return s.handleDecodedDiscover(ctx, req)
+		}
+
+	default:
+		return false, nil, fmt.Errorf("method %v not found in service %v", methodName, s.Service())
+	}
+	return false, nil, fmt.Errorf("unexpected arguments %T for method %v", args, methodName)
+}
+
 func (s *tchanHyperbahnServer) handleDiscover(ctx thrift.Context, protocol athrift.TProtocol) (bool, athrift.TStruct, error) {
 	var req HyperbahnDiscoverArgs
-	var res HyperbahnDiscoverResult
 
 	if err := req.Read(protocol); err != nil {
 		return false, nil, err
 	}
 
+	return s.handleDecodedDiscover(ctx, &req)
+}
+
+func (s *tchanHyperbahnServer) handleDecodedDiscover(ctx thrift.Context, req *HyperbahnDiscoverArgs) (bool, athrift.TStruct, error) {
+	var res HyperbahnDiscoverResult
+
 	r, err :=
 		s.handler.Discover(ctx, req.Query)
 
@@ -120,3 +151,44 @@ func (s *tchanHyperbahnServer) handleDiscover(ctx thrift.Context, protocol athri
 
 	return err == nil, &res, nil
 }
+
+// tchanThriftIDLs are the Thrift IDL files that this package was generated from.
+var tchanThriftIDLs = map[string]string{
+	"hyperbahn.thrift": "exception NoPeersAvailable {\n" +
+		"    1: required string message\n" +
+		"    2: required string serviceName\n" +
+		"}\n" +
+		"\n" +
+		"exception InvalidServiceName {\n" +
+		"    1: required string message\n" +
+		"    2: required string serviceName\n" +
+		"}\n" +
+		"\n" +
+		"struct DiscoveryQuery {\n" +
+		"    1: required string serviceName\n" +
+		"}\n" +
+		"\n" +
+		"union IpAddress {\n" +
+		"  1: i32 ipv4\n" +
+		"}\n" +
+		"\n" +
+		"struct ServicePeer {\n" +
+		"  1: required IpAddress ip\n" +
+		"  2: required i32 port\n" +
+		"}\n" +
+		"\n" +
+		"struct DiscoveryResult {\n" +
+		"  1: required list<ServicePeer> peers\n" +
+		"}\n" +
+		"\n" +
+		"service Hyperbahn {\n" +
+		"    DiscoveryResult discover(\n" +
+		"        1: required DiscoveryQuery query\n" +
+		"    ) throws (\n" +
+		"        1: NoPeersAvailable noPeersAvailable\n" +
+		"        2: InvalidServiceName invalidServiceName\n" +
+		"    )\n" +
+		"}",
+}
+
+const tchanThriftIDLEntryPoint = "hyperbahn.thrift"
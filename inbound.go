@@ -27,6 +27,8 @@ import (
 
 	"github.com/opentracing/opentracing-go"
 	"github.com/opentracing/opentracing-go/ext"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/net/context"
 )
 
@@ -83,9 +85,16 @@ func (c *Connection) handleCallReq(frame *Frame) bool {
 	response.call = call
 	response.calledAt = now
 	response.timeNow = c.timeNow
-	response.span = c.extractInboundSpan(callReq)
-	if response.span != nil {
-		mex.ctx = opentracing.ContextWithSpan(mex.ctx, response.span)
+	if c.otelTracer != nil {
+		response.otelSpan = c.extractInboundOTelSpan(callReq, now)
+		if response.otelSpan != nil {
+			mex.ctx = trace.ContextWithSpan(mex.ctx, response.otelSpan)
+		}
+	} else {
+		response.span = c.extractInboundSpan(callReq)
+		if response.span != nil {
+			mex.ctx = opentracing.ContextWithSpan(mex.ctx, response.span)
+		}
 	}
 	response.mex = mex
 	response.conn = c
@@ -109,8 +118,10 @@ func (c *Connection) handleCallReq(frame *Frame) bool {
 
 	call.mex = mex
 	call.initialFragment = initialFragment
+	call.bytesRead = int64(frame.Header.PayloadSize())
 	call.serviceName = string(callReq.Service)
 	call.headers = callReq.Headers
+	call.tracing = callReq.Tracing
 	call.response = response
 	call.log = c.log.WithFields(LogField{"In-Call", callReq.ID()})
 	call.messageForFragment = func(initial bool) message { return new(callReqContinue) }
@@ -120,6 +131,7 @@ func (c *Connection) handleCallReq(frame *Frame) bool {
 
 	response.statsReporter = c.statsReporter
 	response.commonStatsTags = call.commonStatsTags
+	response.callStats = c.callStats
 
 	setResponseHeaders(call.headers, response.headers)
 	go c.dispatchInbound(c.connID, callReq.ID(), call, frame)
@@ -167,6 +179,10 @@ func (c *Connection) dispatchInbound(_ uint32, _ uint32, call *InboundCall, fram
 	if span := call.response.span; span != nil {
 		span.SetOperationName(call.methodString)
 	}
+	if span := call.response.otelSpan; span != nil {
+		span.SetName(call.methodString)
+		span.SetAttributes(attribute.String("rpc.method", call.methodString))
+	}
 
 	// TODO(prashant): This is an expensive way to check for cancellation. Use a heap for timeouts.
 	go func() {
@@ -213,6 +229,7 @@ type InboundCall struct {
 	method          []byte
 	methodString    string
 	headers         transportHeaders
+	tracing         Span
 	statsReporter   StatsReporter
 	commonStatsTags map[string]string
 }
@@ -328,10 +345,13 @@ type InboundCallResponse struct {
 	timeNow          func() time.Time
 	applicationError bool
 	systemError      bool
+	systemErrCode    SystemErrCode
 	headers          transportHeaders
 	span             opentracing.Span
+	otelSpan         trace.Span
 	statsReporter    StatsReporter
 	commonStatsTags  map[string]string
+	callStats        *callStats
 }
 
 // SendSystemError returns a system error response to the peer.  The call is considered
@@ -343,6 +363,7 @@ func (response *InboundCallResponse) SendSystemError(err error) error {
 	// Fail all future attempts to read fragments
 	response.state = reqResWriterComplete
 	response.systemError = true
+	response.systemErrCode = GetSystemErrorCode(err)
 	response.doneSending()
 	response.call.releasePreviousFragment()
 
@@ -398,6 +419,9 @@ func (response *InboundCallResponse) doneSending() {
 		}
 		span.FinishWithOptions(opentracing.FinishOptions{FinishTime: now})
 	}
+	if span := response.otelSpan; span != nil {
+		finishOTelSpan(span, nil, response.applicationError || response.systemError, now)
+	}
 
 	latency := now.Sub(response.calledAt)
 	response.statsReporter.RecordTimer("inbound.calls.latency", response.commonStatsTags, latency)
@@ -410,6 +434,10 @@ func (response *InboundCallResponse) doneSending() {
 	} else {
 		response.statsReporter.IncCounter("inbound.calls.success", response.commonStatsTags, 1)
 	}
+	response.callStats.record(
+		callStatsKey{response.call.ServiceName(), response.call.MethodString(), inbound},
+		latency, response.applicationError, response.systemError, response.systemErrCode)
+	response.logAccess(now, latency)
 
 	// Cancel the context since the response is complete.
 	response.cancel()
@@ -89,6 +89,9 @@ type RuntimeState struct {
 
 	// RuntimeVersion is the version information about the runtime and the library.
 	RuntimeVersion RuntimeVersion `json:"runtimeVersion"`
+
+	// CallStats is the per-method call statistics, if enabled using ChannelOptions.CallStats.
+	CallStats *CallStatsRuntimeState `json:"callStats,omitempty"`
 }
 
 // GoRuntimeStateOptions are the options used when getting Go runtime state.
@@ -155,6 +158,8 @@ type ConnectionRuntimeState struct {
 	OutboundExchange  ExchangeSetRuntimeState `json:"outboundExchange"`
 	Relayer           RelayerRuntimeState     `json:"relayer"`
 	HealthChecks      []bool                  `json:"healthChecks,omitempty"`
+	HealthCheckRTT    *HealthCheckRTTState    `json:"healthCheckRTT,omitempty"`
+	Degraded          bool                    `json:"degraded,omitempty"`
 	LastActivityRead  int64                   `json:"lastActivityRead"`
 	LastActivityWrite int64                   `json:"lastActivityWrite"`
 	SendChQueued      int                     `json:"sendChQueued"`
@@ -207,6 +212,20 @@ type PeerRuntimeState struct {
 	InboundConnections  []ConnectionRuntimeState `json:"inboundConnections"`
 	ChosenCount         uint64                   `json:"chosenCount"`
 	SCCount             uint32                   `json:"scCount"`
+	ConnectionLoad      []ConnectionLoadState    `json:"connectionLoad"`
+	DialFailures        int                      `json:"dialFailures"`
+	NextDialTime        time.Time                `json:"nextDialTime"`
+	LastDialError       string                   `json:"lastDialError,omitempty"`
+}
+
+// ConnectionLoadState is the load on a single connection to a peer.
+type ConnectionLoadState struct {
+	ID              uint32 `json:"id"`
+	Direction       string `json:"direction"`
+	Active          bool   `json:"active"`
+	ChosenCount     uint64 `json:"chosenCount"`
+	PendingOutbound int    `json:"pendingOutbound"`
+	PendingInbound  int    `json:"pendingInbound"`
 }
 
 // IntrospectState returns the RuntimeState for this channel.
@@ -244,6 +263,7 @@ func (ch *Channel) IntrospectState(opts *IntrospectionOptions) *RuntimeState {
 		InactiveConnections: getConnectionRuntimeState(inactiveConns, opts),
 		OtherChannels:       ch.IntrospectOthers(opts),
 		RuntimeVersion:      introspectRuntimeVersion(),
+		CallStats:           ch.callStats.IntrospectState(),
 	}
 }
 
@@ -344,13 +364,42 @@ func (p *Peer) IntrospectState(opts *IntrospectionOptions) PeerRuntimeState {
 	p.RLock()
 	defer p.RUnlock()
 
-	return PeerRuntimeState{
+	state := PeerRuntimeState{
 		HostPort:            p.hostPort,
 		InboundConnections:  getConnectionRuntimeState(p.inboundConnections, opts),
 		OutboundConnections: getConnectionRuntimeState(p.outboundConnections, opts),
 		ChosenCount:         p.chosenCount.Load(),
 		SCCount:             p.scCount,
+		ConnectionLoad:      p.connectionLoadLocked(),
+	}
+
+	p.dialMut.RLock()
+	state.DialFailures = p.dialFailures
+	state.NextDialTime = p.nextDialTime
+	if p.lastDialErr != nil {
+		state.LastDialError = p.lastDialErr.Error()
+	}
+	p.dialMut.RUnlock()
+	return state
+}
+
+// connectionLoadLocked returns the load on each of the peer's connections.
+// The peer must be read-locked.
+func (p *Peer) connectionLoadLocked() []ConnectionLoadState {
+	numConns := len(p.inboundConnections) + len(p.outboundConnections)
+	load := make([]ConnectionLoadState, numConns)
+	for i := range load {
+		c := p.getConn(i)
+		load[i] = ConnectionLoadState{
+			ID:              c.connID,
+			Direction:       c.connDirection.String(),
+			Active:          c.IsActive(),
+			ChosenCount:     c.chosenCount.Load(),
+			PendingOutbound: c.outbound.count(),
+			PendingInbound:  c.inbound.count(),
+		}
 	}
+	return load
 }
 
 // IntrospectState returns the runtime state for this connection.
@@ -372,6 +421,8 @@ func (c *Connection) IntrospectState(opts *IntrospectionOptions) ConnectionRunti
 		InboundExchange:   c.inbound.IntrospectState(opts),
 		OutboundExchange:  c.outbound.IntrospectState(opts),
 		HealthChecks:      c.healthCheckHistory.asBools(),
+		HealthCheckRTT:    c.healthCheckHistory.rttState(),
+		Degraded:          c.degraded.Load(),
 		LastActivityRead:  c.lastActivityRead.Load(),
 		LastActivityWrite: c.lastActivityWrite.Load(),
 		SendChQueued:      len(c.sendCh),
@@ -0,0 +1,255 @@
+// Copyright (c) 2015 Uber Technologies, Inc.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package tchannel
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+const _defaultLogSamplingWindow = time.Second
+
+// LogSamplingOptions configures a sampled logger, which limits how often
+// similar messages are logged. Messages are similar if they have the same
+// level, message (or format string) and values for KeyFields.
+type LogSamplingOptions struct {
+	// Window is the period over which similar messages are counted.
+	// Defaults to 1 second.
+	Window time.Duration
+
+	// First is the number of similar messages that are logged in each window
+	// before messages are sampled. Defaults to 1.
+	First int
+
+	// Thereafter is the sampling rate after First messages have been logged
+	// in a window: every Thereafter-th similar message is logged. If zero,
+	// all remaining similar messages in the window are suppressed.
+	Thereafter int
+
+	// KeyFields are the names of fields whose values are used, along with the
+	// level and message, to decide whether messages are similar.
+	KeyFields []string
+
+	// TimeNow is a variable for overriding time.Now in unit tests.
+	// Note: This is not a stable part of the API and may change.
+	TimeNow func() time.Time
+}
+
+func (o LogSamplingOptions) withDefaults() LogSamplingOptions {
+	if o.Window <= 0 {
+		o.Window = _defaultLogSamplingWindow
+	}
+	if o.First <= 0 {
+		o.First = 1
+	}
+	if o.TimeNow == nil {
+		o.TimeNow = time.Now
+	}
+	return o
+}
+
+// logSampler tracks similar messages, and is shared by a sampled logger and
+// all loggers created from it using WithFields.
+type logSampler struct {
+	opts LogSamplingOptions
+
+	sync.Mutex
+	// lastSweep is when entries for past windows were last removed.
+	lastSweep time.Time
+	entries   map[string]*logSamplerEntry
+}
+
+type logSamplerEntry struct {
+	windowStart time.Time
+	count       int
+	suppressed  int
+
+	// logger and level are used to log a summary of suppressed messages.
+	logger Logger
+	level  LogLevel
+	msg    string
+}
+
+type sampledLogger struct {
+	logger  Logger
+	sampler *logSampler
+}
+
+// NewSampledLogger returns a logger that limits how often similar messages
+// are logged, to avoid overwhelming logs when errors repeat on hot paths.
+// Once a window ends, a summary with the number of suppressed messages is
+// logged the next time anything is logged. Fatal messages are never suppressed.
+func NewSampledLogger(logger Logger, opts LogSamplingOptions) Logger {
+	return sampledLogger{
+		logger: logger,
+		sampler: &logSampler{
+			opts:    opts.withDefaults(),
+			entries: make(map[string]*logSamplerEntry),
+		},
+	}
+}
+
+func (l sampledLogger) Enabled(level LogLevel) bool {
+	return l.logger.Enabled(level)
+}
+
+func (l sampledLogger) Fatal(msg string) {
+	l.logger.Fatal(msg)
+}
+
+func (l sampledLogger) Error(msg string) {
+	if l.sampler.check(l.logger, LogLevelError, msg) {
+		l.logger.Error(msg)
+	}
+}
+
+func (l sampledLogger) Warn(msg string) {
+	if l.sampler.check(l.logger, LogLevelWarn, msg) {
+		l.logger.Warn(msg)
+	}
+}
+
+func (l sampledLogger) Infof(msg string, args ...interface{}) {
+	if l.sampler.check(l.logger, LogLevelInfo, msg) {
+		l.logger.Infof(msg, args...)
+	}
+}
+
+func (l sampledLogger) Info(msg string) {
+	if l.sampler.check(l.logger, LogLevelInfo, msg) {
+		l.logger.Info(msg)
+	}
+}
+
+func (l sampledLogger) Debugf(msg string, args ...interface{}) {
+	if l.sampler.check(l.logger, LogLevelDebug, msg) {
+		l.logger.Debugf(msg, args...)
+	}
+}
+
+func (l sampledLogger) Debug(msg string) {
+	if l.sampler.check(l.logger, LogLevelDebug, msg) {
+		l.logger.Debug(msg)
+	}
+}
+
+func (l sampledLogger) Fields() LogFields {
+	return l.logger.Fields()
+}
+
+func (l sampledLogger) WithFields(fields ...LogField) Logger {
+	return sampledLogger{
+		logger:  l.logger.WithFields(fields...),
+		sampler: l.sampler,
+	}
+}
+
+// check returns whether a message should be logged.
+func (s *logSampler) check(logger Logger, level LogLevel, msg string) bool {
+	if !logger.Enabled(level) {
+		return false
+	}
+
+	key := s.key(logger, level, msg)
+	now := s.opts.TimeNow()
+
+	s.Lock()
+	summaries := s.sweep(now)
+
+	entry, ok := s.entries[key]
+	if !ok {
+		entry = &logSamplerEntry{windowStart: now, logger: logger, level: level, msg: msg}
+		s.entries[key] = entry
+	}
+	entry.count++
+
+	log := entry.count <= s.opts.First ||
+		(s.opts.Thereafter > 0 && (entry.count-s.opts.First)%s.opts.Thereafter == 0)
+	if !log {
+		entry.suppressed++
+	}
+	s.Unlock()
+
+	for _, e := range summaries {
+		e.logSummary()
+	}
+	return log
+}
+
+// sweep removes entries for windows that have ended, and returns the entries
+// that suppressed messages so their summaries can be logged.
+// It runs at most once per window, and must be called with the lock held.
+func (s *logSampler) sweep(now time.Time) []*logSamplerEntry {
+	if now.Sub(s.lastSweep) < s.opts.Window {
+		return nil
+	}
+	s.lastSweep = now
+
+	var summaries []*logSamplerEntry
+	for k, e := range s.entries {
+		if now.Sub(e.windowStart) < s.opts.Window {
+			continue
+		}
+		delete(s.entries, k)
+		if e.suppressed > 0 {
+			summaries = append(summaries, e)
+		}
+	}
+	return summaries
+}
+
+func (s *logSampler) key(logger Logger, level LogLevel, msg string) string {
+	if len(s.opts.KeyFields) == 0 {
+		return fmt.Sprint(int(level), ":", msg)
+	}
+
+	var sb strings.Builder
+	fmt.Fprint(&sb, int(level), ":", msg)
+	fields := logger.Fields()
+	for _, k := range s.opts.KeyFields {
+		for _, f := range fields {
+			if f.Key == k {
+				fmt.Fprintf(&sb, "\x00%v=%v", k, f.Value)
+			}
+		}
+	}
+	return sb.String()
+}
+
+func (e *logSamplerEntry) logSummary() {
+	logger := e.logger.WithFields(
+		LogField{"suppressed", e.suppressed},
+		LogField{"suppressedMessage", e.msg},
+	)
+	const msg = "Suppressed similar log messages."
+	switch e.level {
+	case LogLevelError:
+		logger.Error(msg)
+	case LogLevelWarn:
+		logger.Warn(msg)
+	case LogLevelInfo:
+		logger.Info(msg)
+	default:
+		logger.Debug(msg)
+	}
+}
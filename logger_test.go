@@ -23,11 +23,16 @@ package tchannel_test
 import (
 	"bytes"
 	"errors"
+	"strings"
 	"testing"
+	"time"
 
 	. "github.com/uber/tchannel-go"
 
+	"github.com/uber/tchannel-go/testutils"
+
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func field(k string, v interface{}) LogField {
@@ -156,3 +161,79 @@ func TestLevelLogger(t *testing.T) {
 		assert.Equal(t, expectedLines[level], bytes.Count(buf.Bytes(), []byte{'\n'}))
 	}
 }
+
+func TestSampledLogger(t *testing.T) {
+	var buf bytes.Buffer
+	clock := testutils.NewStubClock(time.Unix(1000, 0))
+	logger := NewSampledLogger(NewLogger(&buf), LogSamplingOptions{
+		Window:     time.Second,
+		First:      2,
+		Thereafter: 3,
+		TimeNow:    clock.Now,
+	})
+
+	// Loggers created using WithFields share the sampling state.
+	for i := 0; i < 10; i++ {
+		logger.WithFields(field("connID", i)).Warn("connection failed")
+	}
+	logger.Error("connection failed")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 5, "Unexpected logs: %v", buf.String())
+	for i, connID := range []string{"0", "1", "4", "7"} {
+		assert.Contains(t, lines[i], "[W] connection failed tags: [{connID "+connID+"}]", "Unexpected sampled log")
+	}
+	assert.Contains(t, lines[4], "[E] connection failed", "Different levels should be sampled separately")
+
+	// Summaries are logged once the window ends.
+	buf.Reset()
+	clock.Elapse(time.Second)
+	logger.Info("next window")
+	lines = strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2, "Unexpected logs: %v", buf.String())
+	assert.Contains(t, lines[0], "[W] Suppressed similar log messages. tags: [{connID 0} {suppressed 6} {suppressedMessage connection failed}]",
+		"Unexpected summary")
+	assert.Contains(t, lines[1], "[I] next window", "Unexpected log")
+
+	buf.Reset()
+	logger.Warn("connection failed")
+	assert.Contains(t, buf.String(), "[W] connection failed", "Messages should be logged in a new window")
+}
+
+func TestSampledLoggerKeyFields(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewSampledLogger(NewLogger(&buf), LogSamplingOptions{
+		KeyFields: []string{"remoteHostPort"},
+	})
+
+	for i := 0; i < 3; i++ {
+		logger.WithFields(field("remoteHostPort", "1.1.1.1:1"), field("connID", i)).Error("failed")
+		logger.WithFields(field("remoteHostPort", "2.2.2.2:2"), field("connID", i)).Error("failed")
+	}
+	assert.Equal(t, 2, strings.Count(buf.String(), "[E] failed"), "Expected one log per remoteHostPort")
+}
+
+func TestSampledLoggerDisabledLevel(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewSampledLogger(NewLevelLogger(NewLogger(&buf), LogLevelInfo), LogSamplingOptions{})
+
+	logger.Debug("debug")
+	logger.Debugf("debug %v", 1)
+	assert.Empty(t, buf.String(), "Disabled levels should not be logged")
+	assert.False(t, logger.Enabled(LogLevelDebug), "Enabled should use the underlying logger")
+}
+
+func TestChannelLogSampling(t *testing.T) {
+	var buf bytes.Buffer
+	ch, err := NewChannel("svc", &ChannelOptions{
+		Logger:      NewLogger(&buf),
+		LogSampling: &LogSamplingOptions{Window: time.Minute},
+	})
+	require.NoError(t, err, "NewChannel failed")
+	defer ch.Close()
+
+	for i := 0; i < 5; i++ {
+		ch.Logger().Error("failed")
+	}
+	assert.Equal(t, 1, strings.Count(buf.String(), "[E] failed"), "Expected similar messages to be suppressed")
+}
@@ -0,0 +1,218 @@
+// Copyright (c) 2015 Uber Technologies, Inc.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+//go:build go1.21
+// +build go1.21
+
+package logging
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"runtime"
+	"time"
+
+	"github.com/uber/tchannel-go"
+)
+
+// SlogLevelFatal is the slog level used for Fatal logs, since slog has no fatal level.
+const SlogLevelFatal = slog.LevelError + 4
+
+type slogLogger struct {
+	handler slog.Handler
+	fields  tchannel.LogFields
+}
+
+// NewSlogLogger returns a tchannel.Logger that logs to the given slog.Handler.
+// LogFields are added using slog.Any, which keeps the type of the value.
+// Fatal logs at SlogLevelFatal, then exits with os.Exit(1).
+func NewSlogLogger(handler slog.Handler, fields ...tchannel.LogField) tchannel.Logger {
+	if len(fields) > 0 {
+		handler = handler.WithAttrs(toSlogAttrs(fields))
+	}
+	return slogLogger{handler, fields}
+}
+
+func (l slogLogger) Enabled(level tchannel.LogLevel) bool {
+	return l.handler.Enabled(context.Background(), toSlogLevel(level))
+}
+
+func (l slogLogger) Fatal(msg string) {
+	l.log(SlogLevelFatal, msg)
+	os.Exit(1)
+}
+
+func (l slogLogger) Error(msg string) { l.log(slog.LevelError, msg) }
+func (l slogLogger) Warn(msg string)  { l.log(slog.LevelWarn, msg) }
+func (l slogLogger) Info(msg string)  { l.log(slog.LevelInfo, msg) }
+func (l slogLogger) Debug(msg string) { l.log(slog.LevelDebug, msg) }
+
+func (l slogLogger) Infof(msg string, args ...interface{}) {
+	l.log(slog.LevelInfo, msg, args...)
+}
+
+func (l slogLogger) Debugf(msg string, args ...interface{}) {
+	l.log(slog.LevelDebug, msg, args...)
+}
+
+func (l slogLogger) log(level slog.Level, msg string, args ...interface{}) {
+	ctx := context.Background()
+	if !l.handler.Enabled(ctx, level) {
+		return
+	}
+
+	if len(args) > 0 {
+		msg = fmt.Sprintf(msg, args...)
+	}
+
+	// Skip runtime.Callers, log, and the exported slogLogger method.
+	var pcs [1]uintptr
+	runtime.Callers(3, pcs[:])
+	r := slog.NewRecord(time.Now(), level, msg, pcs[0])
+	l.handler.Handle(ctx, r)
+}
+
+func (l slogLogger) Fields() tchannel.LogFields {
+	return l.fields
+}
+
+func (l slogLogger) WithFields(fields ...tchannel.LogField) tchannel.Logger {
+	newFields := make(tchannel.LogFields, 0, len(l.fields)+len(fields))
+	newFields = append(newFields, l.fields...)
+	newFields = append(newFields, fields...)
+	return slogLogger{l.handler.WithAttrs(toSlogAttrs(fields)), newFields}
+}
+
+func toSlogAttrs(fields []tchannel.LogField) []slog.Attr {
+	attrs := make([]slog.Attr, len(fields))
+	for i, f := range fields {
+		attrs[i] = slog.Any(f.Key, f.Value)
+	}
+	return attrs
+}
+
+func toSlogLevel(level tchannel.LogLevel) slog.Level {
+	switch level {
+	case tchannel.LogLevelAll, tchannel.LogLevelDebug:
+		return slog.LevelDebug
+	case tchannel.LogLevelInfo:
+		return slog.LevelInfo
+	case tchannel.LogLevelWarn:
+		return slog.LevelWarn
+	case tchannel.LogLevelError:
+		return slog.LevelError
+	default:
+		return SlogLevelFatal
+	}
+}
+
+func fromSlogLevel(level slog.Level) tchannel.LogLevel {
+	switch {
+	case level < slog.LevelInfo:
+		return tchannel.LogLevelDebug
+	case level < slog.LevelWarn:
+		return tchannel.LogLevelInfo
+	case level < slog.LevelError:
+		return tchannel.LogLevelWarn
+	default:
+		return tchannel.LogLevelError
+	}
+}
+
+type slogHandler struct {
+	logger tchannel.Logger
+
+	// prefix is prepended to attribute keys, and is set using WithGroup.
+	prefix string
+}
+
+// NewSlogHandler returns a slog.Handler that logs to the given tchannel.Logger.
+// Attributes are converted to LogFields, with groups flattened into dotted keys.
+// Levels at or above slog.LevelError are logged as errors, and never exit.
+func NewSlogHandler(logger tchannel.Logger) slog.Handler {
+	return slogHandler{logger: logger}
+}
+
+func (h slogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.logger.Enabled(fromSlogLevel(level))
+}
+
+func (h slogHandler) Handle(_ context.Context, r slog.Record) error {
+	logger := h.logger
+	if r.NumAttrs() > 0 {
+		fields := make(tchannel.LogFields, 0, r.NumAttrs())
+		r.Attrs(func(a slog.Attr) bool {
+			fields = appendSlogAttr(fields, h.prefix, a)
+			return true
+		})
+		logger = logger.WithFields(fields...)
+	}
+
+	switch fromSlogLevel(r.Level) {
+	case tchannel.LogLevelDebug:
+		logger.Debug(r.Message)
+	case tchannel.LogLevelInfo:
+		logger.Info(r.Message)
+	case tchannel.LogLevelWarn:
+		logger.Warn(r.Message)
+	default:
+		logger.Error(r.Message)
+	}
+	return nil
+}
+
+func (h slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	fields := make(tchannel.LogFields, 0, len(attrs))
+	for _, a := range attrs {
+		fields = appendSlogAttr(fields, h.prefix, a)
+	}
+	return slogHandler{h.logger.WithFields(fields...), h.prefix}
+}
+
+func (h slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return slogHandler{h.logger, h.prefix + name + "."}
+}
+
+// appendSlogAttr appends the attribute as a LogField, flattening groups into
+// keys separated by ".". Empty attributes are ignored, as slog handlers should.
+func appendSlogAttr(fields tchannel.LogFields, prefix string, a slog.Attr) tchannel.LogFields {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return fields
+	}
+
+	if a.Value.Kind() != slog.KindGroup {
+		return append(fields, tchannel.LogField{Key: prefix + a.Key, Value: a.Value.Any()})
+	}
+
+	groupPrefix := prefix
+	if a.Key != "" {
+		groupPrefix = prefix + a.Key + "."
+	}
+	for _, ga := range a.Value.Group() {
+		fields = appendSlogAttr(fields, groupPrefix, ga)
+	}
+	return fields
+}
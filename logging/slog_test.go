@@ -0,0 +1,118 @@
+// Copyright (c) 2015 Uber Technologies, Inc.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+//go:build go1.21
+// +build go1.21
+
+package logging
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/uber/tchannel-go"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingHandler records the attributes of every log record it handles.
+type recordingHandler struct {
+	level   slog.Level
+	attrs   []slog.Attr
+	records *[]slog.Record
+}
+
+func (h recordingHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level
+}
+
+func (h recordingHandler) Handle(_ context.Context, r slog.Record) error {
+	r = r.Clone()
+	r.AddAttrs(h.attrs...)
+	*h.records = append(*h.records, r)
+	return nil
+}
+
+func (h recordingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	h.attrs = append(append([]slog.Attr(nil), h.attrs...), attrs...)
+	return h
+}
+
+func (h recordingHandler) WithGroup(name string) slog.Handler {
+	panic("unexpected WithGroup")
+}
+
+func TestSlogLogger(t *testing.T) {
+	var records []slog.Record
+	handler := recordingHandler{level: slog.LevelInfo, records: &records}
+	logger := NewSlogLogger(handler, tchannel.LogField{Key: "service", Value: "svc"})
+
+	assert.False(t, logger.Enabled(tchannel.LogLevelDebug), "Debug should be disabled")
+	assert.True(t, logger.Enabled(tchannel.LogLevelInfo), "Info should be enabled")
+	assert.True(t, logger.Enabled(tchannel.LogLevelFatal), "Fatal should be enabled")
+
+	logger = logger.WithFields(
+		tchannel.LogField{Key: "connID", Value: uint32(5)},
+		tchannel.LogField{Key: "timeout", Value: time.Second},
+	)
+	assert.Len(t, logger.Fields(), 3, "Unexpected fields")
+
+	logger.Debugf("dropped %v", 1)
+	logger.Infof("hello %v", "world")
+	logger.Error("failed")
+
+	require.Len(t, records, 2, "Unexpected number of logs")
+	assert.Equal(t, "hello world", records[0].Message, "Unexpected message")
+	assert.Equal(t, slog.LevelInfo, records[0].Level, "Unexpected level")
+	assert.Equal(t, slog.LevelError, records[1].Level, "Unexpected level")
+	assert.NotZero(t, records[0].PC, "Expected caller to be recorded")
+
+	kinds := make(map[string]slog.Kind)
+	records[1].Attrs(func(a slog.Attr) bool {
+		kinds[a.Key] = a.Value.Kind()
+		return true
+	})
+	assert.Equal(t, map[string]slog.Kind{
+		"service": slog.KindString,
+		"connID":  slog.KindUint64,
+		"timeout": slog.KindDuration,
+	}, kinds, "Fields should map to typed attributes")
+}
+
+func TestSlogHandler(t *testing.T) {
+	buf := &bytes.Buffer{}
+	tlogger := tchannel.NewLevelLogger(tchannel.NewLogger(buf), tchannel.LogLevelInfo)
+	logger := slog.New(NewSlogHandler(tlogger)).With("app", "test")
+
+	assert.False(t, logger.Enabled(context.Background(), slog.LevelDebug), "Debug should be disabled")
+
+	logger.Debug("debug")
+	logger.WithGroup("req").Info("hello", "id", "abc", slog.Group("peer", "host", "h1"))
+	logger.Log(context.Background(), slog.LevelError+4, "failed", "count", 3)
+
+	out := buf.String()
+	assert.Contains(t, out, "[I] hello tags: [{app test} {req.id abc} {req.peer.host h1}]", "Unexpected info log")
+	assert.Contains(t, out, "[E] failed tags: [{app test} {count 3}]", "Unexpected error log")
+	assert.NotContains(t, out, "debug", "Debug log should be dropped")
+}
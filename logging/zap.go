@@ -0,0 +1,212 @@
+// Copyright (c) 2015 Uber Technologies, Inc.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package logging
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/uber/tchannel-go"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type zapLogger struct {
+	logger *zap.Logger
+	fields tchannel.LogFields
+}
+
+// NewZapLogger returns a tchannel.Logger that logs to the given zap.Logger.
+// LogFields are added using zap.Any, and Fatal logs at zap's fatal level,
+// which exits the process.
+func NewZapLogger(logger *zap.Logger, fields ...tchannel.LogField) tchannel.Logger {
+	// Skip the zapLogger frame so callers are reported correctly.
+	return zapLogger{
+		logger: logger.WithOptions(zap.AddCallerSkip(1)).With(toZapFields(fields)...),
+		fields: fields,
+	}
+}
+
+func (l zapLogger) Enabled(level tchannel.LogLevel) bool {
+	return l.logger.Core().Enabled(toZapLevel(level))
+}
+
+func (l zapLogger) Fatal(msg string) { l.logger.Fatal(msg) }
+func (l zapLogger) Error(msg string) { l.logger.Error(msg) }
+func (l zapLogger) Warn(msg string)  { l.logger.Warn(msg) }
+func (l zapLogger) Info(msg string)  { l.logger.Info(msg) }
+func (l zapLogger) Debug(msg string) { l.logger.Debug(msg) }
+
+func (l zapLogger) Infof(msg string, args ...interface{}) {
+	if ce := l.logger.Check(zapcore.InfoLevel, ""); ce != nil {
+		ce.Message = fmt.Sprintf(msg, args...)
+		ce.Write()
+	}
+}
+
+func (l zapLogger) Debugf(msg string, args ...interface{}) {
+	if ce := l.logger.Check(zapcore.DebugLevel, ""); ce != nil {
+		ce.Message = fmt.Sprintf(msg, args...)
+		ce.Write()
+	}
+}
+
+func (l zapLogger) Fields() tchannel.LogFields {
+	return l.fields
+}
+
+func (l zapLogger) WithFields(fields ...tchannel.LogField) tchannel.Logger {
+	newFields := make(tchannel.LogFields, 0, len(l.fields)+len(fields))
+	newFields = append(newFields, l.fields...)
+	newFields = append(newFields, fields...)
+	return zapLogger{
+		logger: l.logger.With(toZapFields(fields)...),
+		fields: newFields,
+	}
+}
+
+func toZapFields(fields []tchannel.LogField) []zap.Field {
+	zapFields := make([]zap.Field, len(fields))
+	for i, f := range fields {
+		zapFields[i] = zap.Any(f.Key, f.Value)
+	}
+	return zapFields
+}
+
+func toZapLevel(level tchannel.LogLevel) zapcore.Level {
+	switch level {
+	case tchannel.LogLevelAll, tchannel.LogLevelDebug:
+		return zapcore.DebugLevel
+	case tchannel.LogLevelInfo:
+		return zapcore.InfoLevel
+	case tchannel.LogLevelWarn:
+		return zapcore.WarnLevel
+	case tchannel.LogLevelError:
+		return zapcore.ErrorLevel
+	default:
+		return zapcore.FatalLevel
+	}
+}
+
+func fromZapLevel(level zapcore.Level) tchannel.LogLevel {
+	switch {
+	case level <= zapcore.DebugLevel:
+		return tchannel.LogLevelDebug
+	case level == zapcore.InfoLevel:
+		return tchannel.LogLevelInfo
+	case level == zapcore.WarnLevel:
+		return tchannel.LogLevelWarn
+	default:
+		return tchannel.LogLevelError
+	}
+}
+
+type zapCore struct {
+	logger tchannel.Logger
+}
+
+// NewZapCore returns a zapcore.Core that logs to the given tchannel.Logger.
+// Fields are converted to LogFields, with nested objects flattened into
+// dotted keys. Levels above error are logged as errors; zap still panics or
+// exits for those levels after the entry is written.
+func NewZapCore(logger tchannel.Logger) zapcore.Core {
+	return zapCore{logger}
+}
+
+func (c zapCore) Enabled(level zapcore.Level) bool {
+	return c.logger.Enabled(fromZapLevel(level))
+}
+
+func (c zapCore) With(fields []zapcore.Field) zapcore.Core {
+	return zapCore{c.logger.WithFields(fromZapFields(fields)...)}
+}
+
+func (c zapCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c zapCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	logger := c.logger
+	if ent.LoggerName != "" {
+		logger = logger.WithFields(tchannel.LogField{Key: "logger", Value: ent.LoggerName})
+	}
+	if len(fields) > 0 {
+		logger = logger.WithFields(fromZapFields(fields)...)
+	}
+
+	switch fromZapLevel(ent.Level) {
+	case tchannel.LogLevelDebug:
+		logger.Debug(ent.Message)
+	case tchannel.LogLevelInfo:
+		logger.Info(ent.Message)
+	case tchannel.LogLevelWarn:
+		logger.Warn(ent.Message)
+	default:
+		logger.Error(ent.Message)
+	}
+	return nil
+}
+
+func (c zapCore) Sync() error {
+	return nil
+}
+
+func fromZapFields(fields []zapcore.Field) tchannel.LogFields {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+
+	// Use the order of the fields rather than the encoder's map order.
+	logFields := make(tchannel.LogFields, 0, len(fields))
+	for _, f := range fields {
+		v, ok := enc.Fields[f.Key]
+		if !ok {
+			continue
+		}
+		delete(enc.Fields, f.Key)
+		logFields = appendFlattened(logFields, f.Key, v)
+	}
+	return logFields
+}
+
+// appendFlattened appends v as LogFields, flattening nested maps into keys
+// separated by ".".
+func appendFlattened(fields tchannel.LogFields, key string, v interface{}) tchannel.LogFields {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return append(fields, tchannel.LogField{Key: key, Value: v})
+	}
+
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fields = appendFlattened(fields, key+"."+k, m[k])
+	}
+	return fields
+}
@@ -0,0 +1,85 @@
+// Copyright (c) 2015 Uber Technologies, Inc.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package logging
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/uber/tchannel-go"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestZapLogger(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	logger := NewZapLogger(zap.New(core), tchannel.LogField{Key: "service", Value: "svc"})
+
+	assert.False(t, logger.Enabled(tchannel.LogLevelDebug), "Debug should be disabled")
+	assert.True(t, logger.Enabled(tchannel.LogLevelInfo), "Info should be enabled")
+	assert.True(t, logger.Enabled(tchannel.LogLevelError), "Error should be enabled")
+
+	logger = logger.WithFields(
+		tchannel.LogField{Key: "connID", Value: uint32(5)},
+		tchannel.LogField{Key: "timeout", Value: time.Second},
+	)
+	assert.Equal(t, tchannel.LogFields{
+		{Key: "service", Value: "svc"},
+		{Key: "connID", Value: uint32(5)},
+		{Key: "timeout", Value: time.Second},
+	}, logger.Fields(), "Unexpected fields")
+
+	logger.Debug("dropped")
+	logger.Infof("hello %v", "world")
+	logger.Warn("warning")
+
+	entries := logs.AllUntimed()
+	require.Len(t, entries, 2, "Unexpected number of logs")
+	assert.Equal(t, "hello world", entries[0].Message, "Unexpected message")
+	assert.Equal(t, zapcore.InfoLevel, entries[0].Level, "Unexpected level")
+	assert.Equal(t, zapcore.WarnLevel, entries[1].Level, "Unexpected level")
+	assert.Equal(t, map[string]interface{}{
+		"service": "svc",
+		"connID":  uint32(5),
+		"timeout": time.Second,
+	}, entries[1].ContextMap(), "Fields should keep their types")
+}
+
+func TestZapCore(t *testing.T) {
+	buf := &bytes.Buffer{}
+	tlogger := tchannel.NewLevelLogger(tchannel.NewLogger(buf), tchannel.LogLevelInfo)
+	logger := zap.New(NewZapCore(tlogger)).With(zap.String("app", "test"))
+
+	assert.Nil(t, logger.Check(zapcore.DebugLevel, "debug"), "Debug should be disabled")
+
+	logger.Info("hello", zap.Int("count", 3), zap.Namespace("req"), zap.String("id", "abc"))
+	logger.Error("failed")
+
+	out := buf.String()
+	assert.Contains(t, out, "[I] hello tags: [{app test} {count 3} {req.id abc}]", "Unexpected info log")
+	assert.Contains(t, out, "[E] failed tags: [{app test}]", "Unexpected error log")
+	assert.NotContains(t, out, "debug", "Debug log should be dropped")
+}
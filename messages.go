@@ -336,3 +336,9 @@ func callReqSpan(f *Frame) Span {
 	s.read(rdr)
 	return s
 }
+
+// setCallReqSpan overwrites the tracing fields of a callReq frame.
+func setCallReqSpan(f *Frame, s Span) {
+	wbuf := typed.NewWriteBuffer(f.Payload[_spanIndex : _spanIndex+_spanLength])
+	s.write(wbuf)
+}
@@ -28,6 +28,7 @@ import (
 
 	"github.com/opentracing/opentracing-go"
 	"github.com/opentracing/opentracing-go/ext"
+	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/net/context"
 )
 
@@ -117,7 +118,11 @@ func (c *Connection) beginCall(ctx context.Context, serviceName, methodName stri
 	response.requestState = callOptions.RequestState
 	response.mex = mex
 	response.log = c.log.WithFields(LogField{"Out-Response", requestID})
-	response.span = c.startOutboundSpan(ctx, serviceName, methodName, call, now)
+	if c.otelTracer != nil {
+		response.otelSpan = c.startOutboundOTelSpan(ctx, serviceName, methodName, call, now)
+	} else {
+		response.span = c.startOutboundSpan(ctx, serviceName, methodName, call, now)
+	}
 	response.messageForFragment = func(initial bool) message {
 		if initial {
 			return &response.callRes
@@ -128,6 +133,9 @@ func (c *Connection) beginCall(ctx context.Context, serviceName, methodName stri
 	response.contents = newFragmentingReader(response.log, response)
 	response.statsReporter = call.statsReporter
 	response.commonStatsTags = call.commonStatsTags
+	response.callStats = c.callStats
+	response.callStatsKey = callStatsKey{serviceName, methodName, outbound}
+	response.call = call
 
 	call.response = response
 
@@ -222,14 +230,18 @@ type OutboundCallResponse struct {
 	reqResReader
 
 	callRes callRes
+	call    *OutboundCall
 
 	requestState *RequestState
 	// startedAt is the time at which the outbound call was started.
 	startedAt       time.Time
 	timeNow         func() time.Time
 	span            opentracing.Span
+	otelSpan        trace.Span
 	statsReporter   StatsReporter
 	commonStatsTags map[string]string
+	callStats       *callStats
+	callStatsKey    callStatsKey
 }
 
 // ApplicationError returns true if the call resulted in an application level error
@@ -333,6 +345,9 @@ func (response *OutboundCallResponse) doneReading(unexpected error) {
 		}
 		span.FinishWithOptions(opentracing.FinishOptions{FinishTime: now})
 	}
+	if span := response.otelSpan; span != nil {
+		finishOTelSpan(span, unexpected, !isSuccess && lastAttempt, now)
+	}
 
 	latency := now.Sub(response.startedAt)
 	response.statsReporter.RecordTimer("outbound.calls.per-attempt.latency", response.commonStatsTags, latency)
@@ -358,6 +373,9 @@ func (response *OutboundCallResponse) doneReading(unexpected error) {
 	} else {
 		response.statsReporter.IncCounter("outbound.calls.success", response.commonStatsTags, 1)
 	}
+	response.callStats.record(response.callStatsKey, latency,
+		unexpected == nil && response.ApplicationError(), unexpected != nil, GetSystemErrorCode(unexpected))
+	response.logAccess(now, latency, unexpected)
 
 	response.mex.shutdown()
 }
@@ -68,6 +68,12 @@ type PeerList struct {
 	peerHeap        *peerHeap
 	scoreCalculator ScoreCalculator
 	lastSelected    uint64
+	healthChecker   *peerHealthChecker
+	minConnections  int
+
+	// healthMut serializes starting and stopping health checks, and is held
+	// while waiting for a stopped health checker to exit.
+	healthMut sync.Mutex
 }
 
 func newPeerList(root *RootPeerList) *PeerList {
@@ -116,6 +122,9 @@ func (l *PeerList) Add(hostPort string) *Peer {
 
 	l.peersByHostPort[hostPort] = ps
 	l.peerHeap.addPeer(ps)
+	if l.minConnections > 0 {
+		p.setMinConnections(l, l.minConnections)
+	}
 	return p
 }
 
@@ -169,15 +178,13 @@ func (l *PeerList) Remove(hostPort string) error {
 	}
 
 	p.delSC()
+	p.setMinConnections(l, 0)
 	delete(l.peersByHostPort, hostPort)
 	l.peerHeap.removePeer(p)
 
 	return nil
 }
 func (l *PeerList) choosePeer(prevSelected map[string]struct{}, avoidHost bool) *Peer {
-	var psPopList []*peerScore
-	var ps *peerScore
-
 	canChoosePeer := func(hostPort string) bool {
 		if _, ok := prevSelected[hostPort]; ok {
 			return false
@@ -190,11 +197,36 @@ func (l *PeerList) choosePeer(prevSelected map[string]struct{}, avoidHost bool)
 		return true
 	}
 
+	// Avoid peers that are unhealthy or backing off after failed connection attempts.
+	ps := l.selectPeer(func(ps *peerScore) bool {
+		return !ps.unhealthy && !ps.inDialBackoff() && canChoosePeer(ps.HostPort())
+	})
+
+	// If every candidate is avoided, fall back to them rather than failing the call.
+	if ps == nil {
+		ps = l.selectPeer(func(ps *peerScore) bool {
+			return canChoosePeer(ps.HostPort())
+		})
+	}
+
+	if ps == nil {
+		return nil
+	}
+	ps.chosenCount.Inc()
+	return ps.Peer
+}
+
+// selectPeer returns the peer with the best score that can be chosen, or nil
+// if no peers can be chosen. Note that a Write lock must be held to call this function.
+func (l *PeerList) selectPeer(canChoose func(*peerScore) bool) *peerScore {
+	var psPopList []*peerScore
+	var ps *peerScore
+
 	size := l.peerHeap.Len()
 	for i := 0; i < size; i++ {
 		popped := l.peerHeap.popPeer()
 
-		if canChoosePeer(popped.HostPort()) {
+		if canChoose(popped) {
 			ps = popped
 			break
 		}
@@ -205,13 +237,10 @@ func (l *PeerList) choosePeer(prevSelected map[string]struct{}, avoidHost bool)
 		heap.Push(l.peerHeap, p)
 	}
 
-	if ps == nil {
-		return nil
+	if ps != nil {
+		l.peerHeap.pushPeer(ps)
 	}
-
-	l.peerHeap.pushPeer(ps)
-	ps.chosenCount.Inc()
-	return ps.Peer
+	return ps
 }
 
 // GetOrAdd returns a peer for the given hostPort, creating one if it doesn't yet exist.
@@ -304,6 +333,12 @@ type peerScore struct {
 	// order is the tiebreaker for when score is equal. It is set when a peer
 	// is pushed to the heap based on peerHeap.order with jitter.
 	order uint64
+	// unhealthy is set when the peer fails an active health check, and is
+	// cleared once the peer passes enough consecutive health checks.
+	unhealthy bool
+	// healthSuccesses is the number of consecutive health checks passed
+	// while the peer is unhealthy.
+	healthSuccesses int
 }
 
 func newPeerScore(p *Peer, score uint64) *peerScore {
@@ -332,6 +367,26 @@ type Peer struct {
 	outboundConnections []*Connection
 	chosenCount         atomic.Uint64
 
+	// opts are set by the root peer list, and nextConn is used for
+	// round-robin connection selection.
+	opts             peerOptions
+	connsPerPeerOnce sync.Once
+	nextConn         atomic.Uint64
+
+	// dialMut protects the state used to back off after failed dials.
+	dialMut      sync.RWMutex
+	dialFailures int
+	nextDialTime time.Time
+	lastDialErr  error
+
+	// minConnsByList is the minimum number of outbound connections required by
+	// each peer list, and minConnsStop/Wake control the goroutine that creates them.
+	// The nil key is used for ConnectionOptions.ConnectionsPerPeer.
+	minConnsMut    sync.Mutex
+	minConnsByList map[*PeerList]int
+	minConnsStop   chan struct{}
+	minConnsWake   chan struct{}
+
 	// onUpdate is a test-only hook.
 	onUpdate func(*Peer)
 }
@@ -348,6 +403,7 @@ func newPeer(channel Connectable, hostPort string, onStatusChanged func(*Peer),
 		hostPort:            hostPort,
 		onStatusChanged:     onStatusChanged,
 		onClosedConnRemoved: onClosedConnRemoved,
+		opts:                peerOptions{timeNow: time.Now},
 	}
 }
 
@@ -373,25 +429,16 @@ func (p *Peer) getActiveConnLocked() (*Connection, bool) {
 		return nil, false
 	}
 
-	// We cycle through the connection list, starting at a random point
-	// to avoid always choosing the same connection.
-	var startOffset int
-	if allConns > 1 {
-		startOffset = peerRng.Intn(allConns)
-	}
-	for i := 0; i < allConns; i++ {
-		connIndex := (i + startOffset) % allConns
-		if conn := p.getConn(connIndex); conn.IsActive() {
-			return conn, true
-		}
+	conn := p.selectConnLocked(allConns)
+	if conn == nil {
+		return nil, false
 	}
-
-	return nil, false
+	conn.chosenCount.Inc()
+	return conn, true
 }
 
-// getActiveConn will randomly select an active connection.
+// getActiveConn will select an active connection using the ConnectionSelection strategy.
 // TODO(prashant): Should we clear inactive connections?
-// TODO(prashant): Do we want some sort of scoring for connections?
 func (p *Peer) getActiveConn() (*Connection, bool) {
 	p.RLock()
 	conn, ok := p.getActiveConnLocked()
@@ -403,6 +450,7 @@ func (p *Peer) getActiveConn() (*Connection, bool) {
 // GetConnection returns an active connection to this peer. If no active connections
 // are found, it will create a new outbound connection and return it.
 func (p *Peer) GetConnection(ctx context.Context) (*Connection, error) {
+	p.ensureConnectionsPerPeer()
 	if activeConn, ok := p.getActiveConn(); ok {
 		return activeConn, nil
 	}
@@ -417,12 +465,13 @@ func (p *Peer) GetConnection(ctx context.Context) (*Connection, error) {
 	}
 
 	// No active connections, make a new outgoing connection.
-	return p.Connect(ctx)
+	return p.connectWithBackoff(ctx)
 }
 
 // getConnectionRelay gets a connection, and uses the given timeout to lazily
 // create a context if a new connection is required.
 func (p *Peer) getConnectionRelay(callTimeout, relayMaxConnTimeout time.Duration) (*Connection, error) {
+	p.ensureConnectionsPerPeer()
 	if conn, ok := p.getActiveConn(); ok {
 		return conn, nil
 	}
@@ -449,7 +498,7 @@ func (p *Peer) getConnectionRelay(callTimeout, relayMaxConnTimeout time.Duration
 	ctx, cancel := NewContextBuilder(timeout).HideListeningOnOutbound().Build()
 	defer cancel()
 
-	return p.Connect(ctx)
+	return p.connectWithBackoff(ctx)
 }
 
 // addSC adds a reference to a peer from a subchannel (e.g. peer list).
@@ -536,12 +585,17 @@ func (p *Peer) connectionCloseStateChange(changed *Connection) {
 		p.onClosedConnRemoved(p)
 		// Inform third parties that a peer lost a connection.
 		p.onStatusChanged(p)
+		p.wakeMinConnections()
 	}
 }
 
-// Connect adds a new outbound connection to the peer.
+// Connect adds a new outbound connection to the peer. Failed connection
+// attempts cause GetConnection to back off before connecting again, but
+// Connect always attempts a new connection.
 func (p *Peer) Connect(ctx context.Context) (*Connection, error) {
-	return p.channel.Connect(ctx, p.hostPort)
+	conn, err := p.channel.Connect(ctx, p.hostPort)
+	p.recordDial(ctx, err)
+	return conn, err
 }
 
 // BeginCall starts a new call to this specific peer, returning an OutboundCall that can
@@ -593,6 +647,21 @@ func (p *Peer) NumPendingOutbound() int {
 	return count
 }
 
+// Degraded returns whether all of the peer's connections are degraded due to
+// failed health checks. It returns false if the peer has no connections.
+func (p *Peer) Degraded() bool {
+	p.RLock()
+	defer p.RUnlock()
+
+	numConns := len(p.inboundConnections) + len(p.outboundConnections)
+	for i := 0; i < numConns; i++ {
+		if !p.getConn(i).degraded.Load() {
+			return false
+		}
+	}
+	return numConns > 0
+}
+
 func (p *Peer) runWithConnections(f func(*Connection)) {
 	p.RLock()
 	for _, c := range p.inboundConnections {
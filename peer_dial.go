@@ -0,0 +1,111 @@
+// Copyright (c) 2015 Uber Technologies, Inc.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package tchannel
+
+import (
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+const (
+	_dialBackoffInitial = 50 * time.Millisecond
+	_dialBackoffMax     = 10 * time.Second
+)
+
+// ErrPeerDialBackoff is returned when a new connection is required to a peer,
+// but the peer is backing off after consecutive failed connection attempts.
+// No request is sent, so it is retried by all retry options other than RetryNever.
+var ErrPeerDialBackoff = NewSystemError(ErrCodeDeclined, "peer is backing off after failed connection attempts")
+
+// connectWithBackoff creates a new connection to the peer, unless the peer is
+// backing off after failed connection attempts.
+func (p *Peer) connectWithBackoff(ctx context.Context) (*Connection, error) {
+	if p.inDialBackoff() {
+		return nil, ErrPeerDialBackoff
+	}
+	return p.Connect(ctx)
+}
+
+// inDialBackoff returns whether new connection attempts should be avoided
+// due to consecutive failed connection attempts.
+func (p *Peer) inDialBackoff() bool {
+	p.dialMut.RLock()
+	nextDialTime := p.nextDialTime
+	p.dialMut.RUnlock()
+
+	return !nextDialTime.IsZero() && p.opts.timeNow().Before(nextDialTime)
+}
+
+// recordDial updates the dial failure state using the result of a connection
+// attempt made using ctx.
+func (p *Peer) recordDial(ctx context.Context, err error) {
+	// Ignore errors caused by the caller or the channel closing, rather than the peer.
+	// Timeouts are only counted if the connect timeout fired before the caller's
+	// context ended.
+	if err == errInvalidStateForOp || GetSystemErrorCode(err) == ErrCodeCancelled {
+		return
+	}
+	if err != nil && callerDone(ctx) {
+		return
+	}
+
+	p.dialMut.Lock()
+	defer p.dialMut.Unlock()
+
+	if err == nil {
+		p.dialFailures = 0
+		p.nextDialTime = time.Time{}
+		p.lastDialErr = nil
+		return
+	}
+
+	p.dialFailures++
+	p.lastDialErr = err
+	p.nextDialTime = p.opts.timeNow().Add(dialBackoff(p.dialFailures))
+}
+
+// callerDone returns whether ctx has ended. Dials that time out using the
+// context's deadline may return before the context's Err is set, so the
+// deadline is also checked.
+func callerDone(ctx context.Context) bool {
+	if ctx.Err() != nil {
+		return true
+	}
+	deadline, ok := ctx.Deadline()
+	return ok && !time.Now().Before(deadline)
+}
+
+// dialBackoff returns the time to wait after the given number of consecutive
+// failed dials. The backoff doubles after every failure up to a maximum, and a
+// random jitter of up to half the backoff is removed to avoid synchronized retries.
+func dialBackoff(failures int) time.Duration {
+	backoff := _dialBackoffInitial
+	for i := 1; i < failures && backoff < _dialBackoffMax; i++ {
+		backoff *= 2
+	}
+	if backoff > _dialBackoffMax {
+		backoff = _dialBackoffMax
+	}
+
+	half := int64(backoff / 2)
+	return time.Duration(half + peerRng.Int63n(half+1))
+}
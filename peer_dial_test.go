@@ -0,0 +1,165 @@
+// Copyright (c) 2015 Uber Technologies, Inc.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package tchannel_test
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	. "github.com/uber/tchannel-go"
+
+	"github.com/uber/tchannel-go/testutils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var introspectEmptyPeers = &IntrospectionOptions{IncludeEmptyPeers: true}
+
+func TestPeerDialBackoff(t *testing.T) {
+	clock := testutils.NewStubClock(time.Unix(1000, 0))
+	ch := testutils.NewClient(t, testutils.NewOpts().SetTimeNow(clock.Now))
+	defer ch.Close()
+
+	peer := ch.Peers().Add(testutils.GetClosedHostPort(t))
+	getConn := func() error {
+		ctx, cancel := NewContext(time.Second)
+		defer cancel()
+		_, err := peer.GetConnection(ctx)
+		return err
+	}
+
+	err := getConn()
+	require.Error(t, err, "GetConnection to a closed port should fail")
+	assert.NotEqual(t, ErrPeerDialBackoff, err, "First failure should come from the dial")
+
+	state := ch.IntrospectState(introspectEmptyPeers).RootPeers[peer.HostPort()]
+	assert.Equal(t, 1, state.DialFailures, "Unexpected dial failures")
+	assert.NotEmpty(t, state.LastDialError, "Missing last dial error")
+	firstBackoff := state.NextDialTime.Sub(clock.Now())
+	assert.True(t, firstBackoff > 0 && firstBackoff <= 50*time.Millisecond,
+		"Unexpected first backoff %v", firstBackoff)
+
+	// While backing off, no connection attempts are made.
+	assert.Equal(t, ErrPeerDialBackoff, getConn(), "Expected backoff error")
+	state = ch.IntrospectState(introspectEmptyPeers).RootPeers[peer.HostPort()]
+	assert.Equal(t, 1, state.DialFailures, "Backoff should not count as a dial failure")
+
+	// Once the backoff elapses, the next failure increases the backoff.
+	clock.Elapse(firstBackoff)
+	require.Error(t, getConn(), "GetConnection to a closed port should fail")
+	state = ch.IntrospectState(introspectEmptyPeers).RootPeers[peer.HostPort()]
+	assert.Equal(t, 2, state.DialFailures, "Unexpected dial failures")
+	secondBackoff := state.NextDialTime.Sub(clock.Now())
+	assert.True(t, secondBackoff >= 50*time.Millisecond && secondBackoff <= 100*time.Millisecond,
+		"Unexpected second backoff %v", secondBackoff)
+}
+
+func TestPeerDialBackoffReset(t *testing.T) {
+	clock := testutils.NewStubClock(time.Unix(1000, 0))
+	client := testutils.NewClient(t, testutils.NewOpts().SetTimeNow(clock.Now))
+	defer client.Close()
+
+	hostPort := testutils.GetClosedHostPort(t)
+	peer := client.Peers().Add(hostPort)
+
+	ctx, cancel := NewContext(time.Second)
+	defer cancel()
+
+	_, err := peer.GetConnection(ctx)
+	require.Error(t, err, "GetConnection to a closed port should fail")
+
+	// Start a server on the failing host:port.
+	ln, err := net.Listen("tcp", hostPort)
+	require.NoError(t, err, "Listen failed")
+	server := testutils.NewClient(t, nil)
+	defer server.Close()
+	require.NoError(t, server.Serve(ln), "Serve failed")
+
+	// Connect ignores the backoff, and a successful connection resets the failures.
+	_, err = peer.Connect(ctx)
+	require.NoError(t, err, "Connect failed")
+	state := client.IntrospectState(introspectEmptyPeers).RootPeers[hostPort]
+	assert.Equal(t, 0, state.DialFailures, "Unexpected dial failures")
+	assert.True(t, state.NextDialTime.IsZero(), "Unexpected next dial time")
+	assert.Empty(t, state.LastDialError, "Unexpected last dial error")
+}
+
+func TestPeerDialBackoffChoosePeer(t *testing.T) {
+	testutils.WithTestServer(t, nil, func(t testing.TB, ts *testutils.TestServer) {
+		clock := testutils.NewStubClock(time.Unix(1000, 0))
+		client := ts.NewClient(testutils.NewOpts().SetTimeNow(clock.Now))
+
+		ctx, cancel := NewContext(time.Second)
+		defer cancel()
+
+		closedHostPort := testutils.GetClosedHostPort(t)
+		closedPeer := client.Peers().Add(closedHostPort)
+		_, err := closedPeer.Connect(ctx)
+		require.Error(t, err, "Connect to a closed port should fail")
+		client.Peers().Add(ts.HostPort())
+
+		for i := 0; i < 10; i++ {
+			peer, err := client.Peers().Get(nil)
+			require.NoError(t, err, "Get failed")
+			assert.Equal(t, ts.HostPort(), peer.HostPort(), "Peer in backoff should be avoided")
+		}
+
+		// Once every peer is in backoff, they are still chosen.
+		client.Peers().Remove(ts.HostPort())
+		peer, err := client.Peers().Get(nil)
+		require.NoError(t, err, "Get failed")
+		assert.Equal(t, closedHostPort, peer.HostPort(), "Peer in backoff should be used as a fallback")
+	})
+}
+
+func TestPeerDialTimeout(t *testing.T) {
+	// The listener never accepts connections, so the handshake times out.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err, "Listen failed")
+	defer ln.Close()
+
+	opts := testutils.NewOpts().AddLogFilter("Failed during connection handshake.", 2)
+	client := testutils.NewClient(t, opts)
+	defer client.Close()
+
+	peer := client.Peers().Add(ln.Addr().String())
+	dialFailures := func() int {
+		return client.IntrospectState(introspectEmptyPeers).RootPeers[peer.HostPort()].DialFailures
+	}
+
+	// A timeout caused by the caller's context is not the peer's fault.
+	ctx, cancel := NewContext(testutils.Timeout(20 * time.Millisecond))
+	defer cancel()
+	_, err = peer.Connect(ctx)
+	require.Error(t, err, "Connect should time out")
+	assert.Equal(t, 0, dialFailures(), "Caller timeout should not count as a dial failure")
+
+	// A timeout from the connect timeout is counted.
+	ctx, cancel = NewContextBuilder(time.Second).
+		SetConnectTimeout(testutils.Timeout(20 * time.Millisecond)).
+		Build()
+	defer cancel()
+	_, err = peer.Connect(ctx)
+	require.Error(t, err, "Connect should time out")
+	assert.Equal(t, 1, dialFailures(), "Connect timeout should count as a dial failure")
+}
@@ -0,0 +1,244 @@
+// Copyright (c) 2015 Uber Technologies, Inc.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package tchannel
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+const (
+	_defaultPeerHealthCheckTimeout = time.Second
+	_defaultPeerSuccessesToReadmit = 3
+)
+
+// PeerHealthCheckFunc checks whether a peer is healthy and should receive
+// traffic. It returns a non-nil error if the peer is unhealthy.
+type PeerHealthCheckFunc func(ctx context.Context, peer *Peer) error
+
+// PeerHealthCheckOptions are the parameters to configure active health checks
+// for the peers in a PeerList. Unlike HealthCheckOptions, these are intended
+// to check application level health, such as whether a peer has finished
+// warming up, so a peer with a healthy connection may still fail them.
+// thrift.HealthCheck returns a check that uses the Meta::health endpoint.
+type PeerHealthCheckOptions struct {
+	// Check is the function used to check the health of each peer.
+	Check PeerHealthCheckFunc
+
+	// The period between health checks. If this is zero, or Check is nil,
+	// health checks are disabled.
+	Interval time.Duration
+
+	// The timeout to use for a health check.
+	// If no value is specified, it defaults to time.Second.
+	Timeout time.Duration
+
+	// SuccessesToReadmit is the number of consecutive successful health checks
+	// required before an unhealthy peer is selected again.
+	// If no value is specified, it defaults to 3.
+	SuccessesToReadmit int
+}
+
+func (o PeerHealthCheckOptions) enabled() bool {
+	return o.Interval > 0 && o.Check != nil
+}
+
+func (o PeerHealthCheckOptions) withDefaults() PeerHealthCheckOptions {
+	if o.Timeout == 0 {
+		o.Timeout = _defaultPeerHealthCheckTimeout
+	}
+	if o.SuccessesToReadmit == 0 {
+		o.SuccessesToReadmit = _defaultPeerSuccessesToReadmit
+	}
+	return o
+}
+
+// peerHealthChecker periodically checks the health of all peers in a PeerList.
+type peerHealthChecker struct {
+	list   *PeerList
+	opts   PeerHealthCheckOptions
+	log    Logger
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// SetHealthChecks starts active health checks for the peers in the list,
+// replacing any existing health checks. While health checks are enabled, peers
+// that fail a health check are not selected unless there are no other peers to
+// choose from. Health checks run until StopHealthChecks is called, or the
+// channel is closed.
+func (l *PeerList) SetHealthChecks(opts PeerHealthCheckOptions) {
+	l.healthMut.Lock()
+	defer l.healthMut.Unlock()
+
+	l.stopHealthChecks()
+	if !opts.enabled() {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	hc := &peerHealthChecker{
+		list:   l,
+		opts:   opts.withDefaults(),
+		log:    l.parent.channel.Logger(),
+		ctx:    ctx,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+
+	l.Lock()
+	l.healthChecker = hc
+	l.Unlock()
+
+	go hc.run()
+}
+
+// StopHealthChecks stops any active health checks for the peers in the list,
+// and allows all peers to be selected again.
+func (l *PeerList) StopHealthChecks() {
+	l.healthMut.Lock()
+	defer l.healthMut.Unlock()
+
+	l.stopHealthChecks()
+}
+
+// stopHealthChecks must be called with healthMut held, so the health checker
+// cannot be replaced concurrently.
+func (l *PeerList) stopHealthChecks() {
+	l.Lock()
+	hc := l.healthChecker
+	l.healthChecker = nil
+	l.Unlock()
+
+	if hc == nil {
+		return
+	}
+	hc.cancel()
+	<-hc.done
+
+	l.Lock()
+	for _, ps := range l.peersByHostPort {
+		ps.unhealthy = false
+		ps.healthSuccesses = 0
+	}
+	l.Unlock()
+}
+
+func (hc *peerHealthChecker) run() {
+	defer close(hc.done)
+
+	// Stop health checks if the channel is closed, since all checks will fail.
+	var closed <-chan struct{}
+	if ch, ok := hc.list.parent.channel.(interface {
+		ClosedChan() <-chan struct{}
+	}); ok {
+		closed = ch.ClosedChan()
+	}
+
+	ticker := time.NewTicker(hc.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			hc.checkPeers()
+		case <-hc.ctx.Done():
+			return
+		case <-closed:
+			return
+		}
+	}
+}
+
+// checkPeers concurrently checks the health of all peers in the list, and
+// then updates which peers can be selected.
+func (hc *peerHealthChecker) checkPeers() {
+	peers := hc.list.Copy()
+
+	var (
+		wg      sync.WaitGroup
+		mut     sync.Mutex
+		results = make(map[string]error, len(peers))
+	)
+	for hostPort, peer := range peers {
+		wg.Add(1)
+		go func(hostPort string, peer *Peer) {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(hc.ctx, hc.opts.Timeout)
+			err := hc.opts.Check(ctx, peer)
+			cancel()
+
+			mut.Lock()
+			results[hostPort] = err
+			mut.Unlock()
+		}(hostPort, peer)
+	}
+	wg.Wait()
+
+	// Don't update peers using checks that failed due to being stopped.
+	if hc.ctx.Err() != nil {
+		return
+	}
+
+	hc.list.Lock()
+	defer hc.list.Unlock()
+
+	for hostPort, err := range results {
+		ps, ok := hc.list.peersByHostPort[hostPort]
+		if !ok {
+			continue
+		}
+		hc.updatePeer(ps, err)
+	}
+}
+
+// updatePeer updates the health of a peer using the result of a health check.
+// Note that a Write lock on the list must be held to call this function.
+func (hc *peerHealthChecker) updatePeer(ps *peerScore, err error) {
+	if err != nil {
+		if !ps.unhealthy {
+			hc.log.WithFields(
+				LogField{"hostPort", ps.HostPort()},
+				ErrField(err),
+			).Warn("Peer failed health check, avoiding peer.")
+		}
+		ps.unhealthy = true
+		ps.healthSuccesses = 0
+		return
+	}
+
+	if !ps.unhealthy {
+		return
+	}
+	ps.healthSuccesses++
+	if ps.healthSuccesses >= hc.opts.SuccessesToReadmit {
+		hc.log.WithFields(
+			LogField{"hostPort", ps.HostPort()},
+			LogField{"successes", ps.healthSuccesses},
+		).Info("Peer passed health checks, selecting peer again.")
+		ps.unhealthy = false
+		ps.healthSuccesses = 0
+	}
+}
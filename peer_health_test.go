@@ -0,0 +1,240 @@
+// Copyright (c) 2015 Uber Technologies, Inc.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package tchannel_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/uber/tchannel-go"
+
+	"github.com/uber/tchannel-go/testutils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+// fakePeerHealth is a PeerHealthCheckFunc with configurable unhealthy peers.
+type fakePeerHealth struct {
+	sync.Mutex
+
+	unhealthy map[string]bool
+	checks    map[string]int
+}
+
+func newFakePeerHealth() *fakePeerHealth {
+	return &fakePeerHealth{
+		unhealthy: make(map[string]bool),
+		checks:    make(map[string]int),
+	}
+}
+
+func (h *fakePeerHealth) check(ctx context.Context, peer *Peer) error {
+	h.Lock()
+	defer h.Unlock()
+
+	h.checks[peer.HostPort()]++
+	if h.unhealthy[peer.HostPort()] {
+		return errors.New("unhealthy")
+	}
+	return nil
+}
+
+func (h *fakePeerHealth) setUnhealthy(hostPort string, unhealthy bool) {
+	h.Lock()
+	h.unhealthy[hostPort] = unhealthy
+	h.Unlock()
+}
+
+// waitForChecks waits until n more health checks have started for hostPort,
+// which ensures the results of the previous round of checks have been applied.
+func (h *fakePeerHealth) waitForChecks(t *testing.T, hostPort string, n int) {
+	h.Lock()
+	want := h.checks[hostPort] + n
+	h.Unlock()
+
+	require.True(t, testutils.WaitFor(time.Second, func() bool {
+		h.Lock()
+		defer h.Unlock()
+		return h.checks[hostPort] >= want
+	}), "Timed out waiting for health checks of %v", hostPort)
+}
+
+func selectedPeers(t *testing.T, pl *PeerList, n int) map[string]int {
+	selected := make(map[string]int)
+	for i := 0; i < n; i++ {
+		peer, err := pl.Get(nil)
+		require.NoError(t, err, "Get failed")
+		selected[peer.HostPort()]++
+	}
+	return selected
+}
+
+func TestPeerHealthChecks(t *testing.T) {
+	const (
+		peer1 = "1.1.1.1:1"
+		peer2 = "2.2.2.2:2"
+	)
+
+	opts := testutils.NewOpts().AddLogFilter("Peer failed health check", 2)
+	ch := testutils.NewClient(t, opts)
+	defer ch.Close()
+
+	pl := ch.Peers()
+	pl.Add(peer1)
+	pl.Add(peer2)
+
+	health := newFakePeerHealth()
+	health.setUnhealthy(peer1, true)
+	pl.SetHealthChecks(PeerHealthCheckOptions{
+		Check:              health.check,
+		Interval:           time.Millisecond,
+		SuccessesToReadmit: 3,
+	})
+	defer pl.StopHealthChecks()
+
+	health.waitForChecks(t, peer1, 2)
+	assert.Equal(t, map[string]int{peer2: 20}, selectedPeers(t, pl, 20), "Unhealthy peer should not be selected")
+
+	// If all peers are unhealthy, they are still selected.
+	health.setUnhealthy(peer2, true)
+	health.waitForChecks(t, peer2, 2)
+	assert.Len(t, selectedPeers(t, pl, 20), 2, "Unhealthy peers should be selected if all peers are unhealthy")
+
+	// Peers are selected again once they pass enough health checks.
+	health.setUnhealthy(peer2, false)
+	health.waitForChecks(t, peer2, 5)
+	assert.Equal(t, map[string]int{peer2: 20}, selectedPeers(t, pl, 20), "Healthy peer should be selected")
+
+	health.setUnhealthy(peer1, false)
+	health.waitForChecks(t, peer1, 5)
+	assert.Len(t, selectedPeers(t, pl, 20), 2, "Both peers should be selected")
+}
+
+func TestPeerHealthChecksReadmit(t *testing.T) {
+	const (
+		peer1 = "1.1.1.1:1"
+		peer2 = "2.2.2.2:2"
+	)
+
+	opts := testutils.NewOpts().AddLogFilter("Peer failed health check", 1)
+	ch := testutils.NewClient(t, opts)
+	defer ch.Close()
+
+	pl := ch.Peers()
+	pl.Add(peer1)
+	pl.Add(peer2)
+
+	health := newFakePeerHealth()
+	health.setUnhealthy(peer1, true)
+	pl.SetHealthChecks(PeerHealthCheckOptions{
+		Check:    health.check,
+		Interval: time.Millisecond,
+	})
+
+	health.waitForChecks(t, peer1, 2)
+	assert.Equal(t, map[string]int{peer2: 20}, selectedPeers(t, pl, 20), "Unhealthy peer should not be selected")
+
+	// Stopping health checks allows all peers to be selected.
+	pl.StopHealthChecks()
+	assert.Len(t, selectedPeers(t, pl, 20), 2, "Both peers should be selected after stopping health checks")
+
+	health.Lock()
+	checks := health.checks[peer1]
+	health.Unlock()
+	time.Sleep(testutils.Timeout(10 * time.Millisecond))
+	health.Lock()
+	assert.Equal(t, checks, health.checks[peer1], "Health checks should not run after stopping")
+	health.Unlock()
+}
+
+func TestPeerHealthChecksDisabled(t *testing.T) {
+	ch := testutils.NewClient(t, nil)
+	defer ch.Close()
+
+	health := newFakePeerHealth()
+	ch.Peers().Add("1.1.1.1:1")
+	ch.Peers().SetHealthChecks(PeerHealthCheckOptions{Check: health.check})
+	ch.Peers().SetHealthChecks(PeerHealthCheckOptions{Interval: time.Millisecond})
+
+	time.Sleep(testutils.Timeout(10 * time.Millisecond))
+	health.Lock()
+	assert.Empty(t, health.checks, "Health checks should be disabled without an interval and check")
+	health.Unlock()
+}
+
+func TestPeerHealthChecksStopOnClose(t *testing.T) {
+	ch := testutils.NewClient(t, nil)
+
+	health := newFakePeerHealth()
+	ch.Peers().Add("1.1.1.1:1")
+	ch.Peers().SetHealthChecks(PeerHealthCheckOptions{
+		Check:    health.check,
+		Interval: time.Millisecond,
+	})
+	health.waitForChecks(t, "1.1.1.1:1", 1)
+
+	// The health check goroutine exits when the channel is closed, so tests
+	// verifying goroutines do not find leaked health checkers.
+	ch.Close()
+	ch.Peers().StopHealthChecks()
+}
+
+func TestPeerHealthChecksConcurrentSet(t *testing.T) {
+	const hostPort = "1.1.1.1:1"
+
+	ch := testutils.NewClient(t, nil)
+	defer ch.Close()
+
+	health := newFakePeerHealth()
+	ch.Peers().Add(hostPort)
+
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			ch.Peers().SetHealthChecks(PeerHealthCheckOptions{
+				Check:    health.check,
+				Interval: time.Millisecond,
+			})
+		}()
+	}
+	close(start)
+	wg.Wait()
+	health.waitForChecks(t, hostPort, 1)
+
+	// Once stopped, a leaked health checker goroutine would keep checking the peer.
+	ch.Peers().StopHealthChecks()
+	health.Lock()
+	checks := health.checks[hostPort]
+	health.Unlock()
+
+	time.Sleep(testutils.Timeout(20 * time.Millisecond))
+	health.Lock()
+	assert.Equal(t, checks, health.checks[hostPort], "Health checker goroutine leaked after StopHealthChecks")
+	health.Unlock()
+}
@@ -0,0 +1,205 @@
+// Copyright (c) 2015 Uber Technologies, Inc.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package tchannel
+
+import (
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+const (
+	_minConnsInitialBackoff = 100 * time.Millisecond
+	_minConnsMaxBackoff     = 10 * time.Second
+)
+
+// SetMinConnections sets the minimum number of outbound connections to keep
+// open to each peer in the list. Connections are created in the background
+// when a peer is added, and are re-created with backoff after they close, so
+// calls do not have to wait for a connection to be established. If a peer is
+// in multiple lists, the largest minimum is used. Setting n to 0 stops
+// creating connections, but does not close any existing connections.
+//
+// To keep connections to the peers of a subchannel, use:
+//
+//	ch.GetSubChannel("service", Isolated).Peers().SetMinConnections(n)
+func (l *PeerList) SetMinConnections(n int) {
+	l.Lock()
+	defer l.Unlock()
+
+	l.minConnections = n
+	for _, ps := range l.peersByHostPort {
+		ps.setMinConnections(l, n)
+	}
+}
+
+// setMinConnections sets the minimum number of outbound connections required
+// by the given peer list, starting or stopping the background goroutine that
+// maintains connections as required.
+func (p *Peer) setMinConnections(l *PeerList, n int) {
+	p.minConnsMut.Lock()
+	defer p.minConnsMut.Unlock()
+
+	if n > 0 {
+		if p.minConnsByList == nil {
+			p.minConnsByList = make(map[*PeerList]int)
+		}
+		p.minConnsByList[l] = n
+	} else {
+		delete(p.minConnsByList, l)
+	}
+
+	switch min := p.minConnectionsLocked(); {
+	case min > 0 && p.minConnsStop == nil:
+		p.minConnsStop = make(chan struct{})
+		p.minConnsWake = make(chan struct{}, 1)
+		go p.maintainMinConnections(p.minConnsStop, p.minConnsWake)
+	case min == 0 && p.minConnsStop != nil:
+		close(p.minConnsStop)
+		p.minConnsStop = nil
+		p.minConnsWake = nil
+	default:
+		p.wakeMinConnectionsLocked()
+	}
+}
+
+// stopMinConnections clears the minimum connections required by all peer
+// lists, and stops the goroutine that maintains connections. It's called when
+// the peer is removed from the root peer list, since new connections to the
+// host:port are added to a new Peer, so this peer would never reach its
+// minimum.
+func (p *Peer) stopMinConnections() {
+	p.minConnsMut.Lock()
+	defer p.minConnsMut.Unlock()
+
+	p.minConnsByList = nil
+	if p.minConnsStop != nil {
+		close(p.minConnsStop)
+		p.minConnsStop = nil
+		p.minConnsWake = nil
+	}
+}
+
+// minConnections returns the minimum number of outbound connections to keep
+// open to the peer.
+func (p *Peer) minConnections() int {
+	p.minConnsMut.Lock()
+	defer p.minConnsMut.Unlock()
+	return p.minConnectionsLocked()
+}
+
+func (p *Peer) minConnectionsLocked() int {
+	var min int
+	for _, n := range p.minConnsByList {
+		if n > min {
+			min = n
+		}
+	}
+	return min
+}
+
+// wakeMinConnections notifies the goroutine maintaining connections that the
+// peer's connections have changed.
+func (p *Peer) wakeMinConnections() {
+	p.minConnsMut.Lock()
+	p.wakeMinConnectionsLocked()
+	p.minConnsMut.Unlock()
+}
+
+func (p *Peer) wakeMinConnectionsLocked() {
+	if p.minConnsWake == nil {
+		return
+	}
+	select {
+	case p.minConnsWake <- struct{}{}:
+	default:
+	}
+}
+
+// numActiveOutbound returns the number of active outbound connections.
+func (p *Peer) numActiveOutbound() int {
+	p.RLock()
+	defer p.RUnlock()
+
+	var count int
+	for _, c := range p.outboundConnections {
+		if c.IsActive() {
+			count++
+		}
+	}
+	return count
+}
+
+// maintainMinConnections creates outbound connections until the peer has the
+// minimum number of active outbound connections, and then waits for changes.
+func (p *Peer) maintainMinConnections(stop, wake <-chan struct{}) {
+	var closed <-chan struct{}
+	if ch, ok := p.channel.(interface {
+		ClosedChan() <-chan struct{}
+	}); ok {
+		closed = ch.ClosedChan()
+	}
+
+	backoff := _minConnsInitialBackoff
+	for {
+		if p.numActiveOutbound() >= p.minConnections() {
+			backoff = _minConnsInitialBackoff
+			select {
+			case <-wake:
+				continue
+			case <-stop:
+				return
+			case <-closed:
+				return
+			}
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), DefaultConnectTimeout)
+		_, err := p.Connect(ctx)
+		cancel()
+		if err == nil {
+			backoff = _minConnsInitialBackoff
+			continue
+		}
+
+		// The channel is closing and will not allow new connections.
+		if err == errInvalidStateForOp {
+			return
+		}
+
+		p.channel.Logger().WithFields(
+			LogField{"hostPort", p.hostPort},
+			LogField{"backoff", backoff},
+			ErrField(err),
+		).Info("Failed to create minimum connections to peer, will retry.")
+
+		select {
+		case <-time.After(backoff):
+		case <-stop:
+			return
+		case <-closed:
+			return
+		}
+		if backoff *= 2; backoff > _minConnsMaxBackoff {
+			backoff = _minConnsMaxBackoff
+		}
+	}
+}
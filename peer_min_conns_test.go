@@ -0,0 +1,120 @@
+// Copyright (c) 2015 Uber Technologies, Inc.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package tchannel_test
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	. "github.com/uber/tchannel-go"
+
+	"github.com/uber/tchannel-go/testutils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+func waitForOutbound(t testing.TB, peer *Peer, want int) {
+	require.True(t, testutils.WaitFor(time.Second, func() bool {
+		_, outbound := peer.NumConnections()
+		return outbound == want
+	}), "Timed out waiting for %v outbound connections", want)
+}
+
+func TestPeerMinConnections(t *testing.T) {
+	testutils.WithTestServer(t, nil, func(t testing.TB, ts *testutils.TestServer) {
+		client := ts.NewClient(nil)
+
+		client.Peers().SetMinConnections(2)
+		peer := client.Peers().Add(ts.HostPort())
+		waitForOutbound(t, peer, 2)
+
+		// Closed connections are replaced.
+		ctx, cancel := NewContext(time.Second)
+		defer cancel()
+		conn, err := peer.GetConnection(ctx)
+		require.NoError(t, err, "GetConnection failed")
+		require.NoError(t, conn.Close(), "Close failed")
+		require.True(t, testutils.WaitFor(time.Second, func() bool {
+			return !conn.IsActive()
+		}), "Connection did not close")
+		waitForOutbound(t, peer, 2)
+
+		// Increasing the minimum creates connections for existing peers.
+		client.Peers().SetMinConnections(3)
+		waitForOutbound(t, peer, 3)
+
+		// Once disabled, closed connections are not replaced.
+		client.Peers().SetMinConnections(0)
+		conn, err = peer.GetConnection(ctx)
+		require.NoError(t, err, "GetConnection failed")
+		require.NoError(t, conn.Close(), "Close failed")
+		waitForOutbound(t, peer, 2)
+		time.Sleep(testutils.Timeout(10 * time.Millisecond))
+		_, outbound := peer.NumConnections()
+		assert.Equal(t, 2, outbound, "Connections should not be created once disabled")
+	})
+}
+
+func TestPeerMinConnectionsRemovePeer(t *testing.T) {
+	testutils.WithTestServer(t, nil, func(t testing.TB, ts *testutils.TestServer) {
+		client := ts.NewClient(nil)
+
+		client.Peers().SetMinConnections(1)
+		peer := client.Peers().Add(ts.HostPort())
+		waitForOutbound(t, peer, 1)
+
+		require.NoError(t, client.Peers().Remove(ts.HostPort()), "Remove failed")
+
+		conn, err := peer.GetConnection(context.Background())
+		require.NoError(t, err, "GetConnection failed")
+		require.NoError(t, conn.Close(), "Close failed")
+		waitForOutbound(t, peer, 0)
+		time.Sleep(testutils.Timeout(10 * time.Millisecond))
+		_, outbound := peer.NumConnections()
+		assert.Equal(t, 0, outbound, "Connections should not be created after the peer is removed")
+	})
+}
+
+func TestPeerMinConnectionsRetry(t *testing.T) {
+	// Reserve a host:port, and only start listening after the first dial fails.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err, "Listen failed")
+	hostPort := ln.Addr().String()
+	require.NoError(t, ln.Close(), "Close failed")
+
+	client := testutils.NewClient(t, nil)
+	defer client.Close()
+
+	client.Peers().SetMinConnections(1)
+	peer := client.Peers().Add(hostPort)
+
+	time.Sleep(testutils.Timeout(10 * time.Millisecond))
+	_, outbound := peer.NumConnections()
+	assert.Equal(t, 0, outbound, "No connections expected before the peer is listening")
+
+	server := testutils.NewClient(t, testutils.NewOpts().SetServiceName("server"))
+	defer server.Close()
+	require.NoError(t, server.ListenAndServe(hostPort), "ListenAndServe failed")
+	waitForOutbound(t, peer, 1)
+}
@@ -45,6 +45,10 @@ func newZeroCalculator() zeroCalculator {
 	return zeroCalculator{}
 }
 
+// _degradedScore is added to the score of peers where all connections are
+// degraded by health checks, so they are only preferred over unconnected peers.
+const _degradedScore = math.MaxUint32
+
 type leastPendingCalculator struct{}
 
 func (leastPendingCalculator) GetScore(p *Peer) uint64 {
@@ -53,12 +57,17 @@ func (leastPendingCalculator) GetScore(p *Peer) uint64 {
 		return math.MaxUint64
 	}
 
-	return uint64(p.NumPendingOutbound())
+	numPendingOutbound := uint64(p.NumPendingOutbound())
+	if p.Degraded() {
+		return _degradedScore + numPendingOutbound
+	}
+
+	return numPendingOutbound
 }
 
 // newLeastPendingCalculator returns a strategy prefers any connected peer.
 // Within connected peers, least pending calls is used. Peers with less pending outbound calls
-// get a smaller score.
+// get a smaller score. Peers with only degraded connections are preferred after other connected peers.
 func newLeastPendingCalculator() leastPendingCalculator {
 	return leastPendingCalculator{}
 }
@@ -72,6 +81,9 @@ func (preferIncomingCalculator) GetScore(p *Peer) uint64 {
 	}
 
 	numPendingOutbound := uint64(p.NumPendingOutbound())
+	if p.Degraded() {
+		return _degradedScore + numPendingOutbound
+	}
 	if inbound == 0 {
 		return math.MaxInt32 + numPendingOutbound
 	}
@@ -81,7 +93,8 @@ func (preferIncomingCalculator) GetScore(p *Peer) uint64 {
 
 // newPreferIncomingCalculator returns a strategy that prefers peers with incoming connections.
 // The scoring tiers are:
-// Peers with incoming connections, peers with any connections, unconnected peers.
+// Peers with incoming connections, peers with any connections, peers with only
+// degraded connections, unconnected peers.
 // Within each tier, least pending calls is used. Peers with less pending outbound calls
 // get a smaller score.
 func newPreferIncomingCalculator() preferIncomingCalculator {
@@ -153,6 +153,12 @@ func TestHRWScorerIntegration(t *testing.T) {
 	err = s1Up.ListenAndServe(s1.PeerInfo().HostPort)
 	require.NoError(t, err, "Failed to bring up a new channel as s1")
 
+	// s1 is avoided until its dial backoff from the failed connections elapses.
+	require.True(t, testutils.WaitFor(time.Second, func() bool {
+		peer, err := client.Peers().Get(nil)
+		return err == nil && peer.HostPort() == s1.PeerInfo().HostPort
+	}), "s1 was not chosen after its dial backoff")
+
 	for i := 0; i < 10; i++ {
 		require.NoError(t, callEcho(), "Failed to call echo after s1 restarted")
 	}
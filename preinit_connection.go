@@ -33,9 +33,11 @@ import (
 )
 
 func (ch *Channel) outboundHandshake(ctx context.Context, c net.Conn, outboundHP string, events connectionEvents) (_ *Connection, err error) {
+	start := ch.timeNow()
 	defer setInitDeadline(ctx, c)()
 	defer func() {
 		err = ch.initError(c, outbound, 1, err)
+		ch.reportHandshake(outbound, outboundHP, start, err)
 	}()
 
 	msg := &initReq{initMessage: ch.getInitMessage(ctx, 1)}
@@ -73,9 +75,16 @@ func (ch *Channel) outboundHandshake(ctx context.Context, c net.Conn, outboundHP
 func (ch *Channel) inboundHandshake(ctx context.Context, c net.Conn, events connectionEvents) (_ *Connection, err error) {
 	id := uint32(math.MaxUint32)
 
+	// The remote host:port is only known once the init request is parsed.
+	// The socket address is not used, since the remote port is usually
+	// ephemeral, and would create a new metric for every connection.
+	var remoteHostPort string
+
+	start := ch.timeNow()
 	defer setInitDeadline(ctx, c)()
 	defer func() {
 		err = ch.initError(c, inbound, id, err)
+		ch.reportHandshake(inbound, remoteHostPort, start, err)
 	}()
 
 	req := &initReq{}
@@ -92,6 +101,7 @@ func (ch *Channel) inboundHandshake(ctx context.Context, c net.Conn, events conn
 	if err != nil {
 		return nil, NewWrappedSystemError(ErrCodeProtocol, err)
 	}
+	remoteHostPort = remotePeer.statsHostPort()
 
 	res := &initRes{initMessage: ch.getInitMessage(ctx, id)}
 	if err := ch.writeMessage(c, res); err != nil {
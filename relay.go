@@ -240,6 +240,9 @@ type Relayer struct {
 	relayConn *relay.Conn
 	logger    Logger
 	pending   atomic.Uint32
+
+	// tracing enables a tracing span for every relayed call.
+	tracing bool
 }
 
 // NewRelayer constructs a Relayer.
@@ -259,7 +262,8 @@ func NewRelayer(ch *Channel, conn *Connection) *Relayer {
 			IsOutbound:        conn.connDirection == outbound,
 			Context:           conn.baseContext,
 		},
-		logger: conn.log,
+		logger:  conn.log,
+		tracing: ch.relayTracing,
 	}
 	r.timeouts = newRelayTimerPool(r.timeoutRelayItem, ch.relayTimerVerify)
 	return r
@@ -428,12 +432,14 @@ func (r *Relayer) handleCallReq(f *lazyCallReq) (shouldRelease bool, _ error) {
 		return _relayNoRelease, nil
 	}
 
+	tracedCall := r.startRelaySpan(f)
+
 	call, err := r.relayHost.Start(f, r.relayConn)
 	if err != nil {
 		// If we have a RateLimitDropError we record the statistic, but
 		// we *don't* send an error frame back to the client.
 		if _, silentlyDrop := err.(relay.RateLimitDropError); silentlyDrop {
-			if call != nil {
+			if call = tracedCall.wrap(call, "relay-dropped"); call != nil {
 				call.Failed("relay-dropped")
 				call.End()
 			}
@@ -442,8 +448,9 @@ func (r *Relayer) handleCallReq(f *lazyCallReq) (shouldRelease bool, _ error) {
 		if _, ok := err.(SystemError); !ok {
 			err = NewSystemError(ErrCodeDeclined, err.Error())
 		}
-		if call != nil {
-			call.Failed(GetSystemErrorCode(err).relayMetricsKey())
+		failure := GetSystemErrorCode(err).relayMetricsKey()
+		if call = tracedCall.wrap(call, failure); call != nil {
+			call.Failed(failure)
 			call.End()
 		}
 		r.conn.SendSystemError(f.Header.ID, f.Span(), err)
@@ -455,6 +462,8 @@ func (r *Relayer) handleCallReq(f *lazyCallReq) (shouldRelease bool, _ error) {
 		return _relayShouldRelease, nil
 	}
 
+	call = tracedCall.wrap(call, "" /* failure */)
+
 	// Check that the current connection is in a valid state to handle a new call.
 	if canHandle, state := r.canHandleNewCall(); !canHandle {
 		call.Failed("relay-client-conn-inactive")
@@ -467,6 +476,7 @@ func (r *Relayer) handleCallReq(f *lazyCallReq) (shouldRelease bool, _ error) {
 	// Get a remote connection and check whether it can handle this call.
 	remoteConn, ok, err := r.getDestination(f, call)
 	if err == nil && ok {
+		tracedCall.destinationSelected(remoteConn.RemotePeerInfo().HostPort)
 		if canHandle, state := remoteConn.relay.canHandleNewCall(); !canHandle {
 			err = NewWrappedSystemError(ErrCodeNetwork, errConnNotActive{"selected remote", state})
 			call.Failed("relay-remote-inactive")
@@ -490,6 +500,7 @@ func (r *Relayer) handleCallReq(f *lazyCallReq) (shouldRelease bool, _ error) {
 		f.SetTTL(r.maxTimeout)
 	}
 	span := f.Span()
+	tracedCall.rewriteFrameSpan(f)
 
 	var mutatedChecksum Checksum
 	if len(f.arg2Appends) > 0 {
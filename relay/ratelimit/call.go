@@ -0,0 +1,79 @@
+// Copyright (c) 2015 Uber Technologies, Inc.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ratelimit
+
+import (
+	"github.com/uber/tchannel-go"
+	"github.com/uber/tchannel-go/relay"
+)
+
+// rejectedCall is the tchannel.RelayCall returned for calls that exceed their
+// quota. The relay marks it as failed with the reason "relay-busy" or
+// "relay-dropped", which is reported to the relay channel's StatsReporter as:
+//
+//	relay.calls.failed (tagged "reason")  counter
+//
+// using the same tags as relay/routing, so rejected calls show up alongside
+// other failed relay calls.
+type rejectedCall struct {
+	reporter tchannel.StatsReporter
+	tags     map[string]string
+}
+
+var _ tchannel.RelayCall = (*rejectedCall)(nil)
+
+func (h *RelayHost) newRejectedCall(cf relay.CallFrame) *rejectedCall {
+	tags := make(map[string]string, len(h.statsTags)+3)
+	for k, v := range h.statsTags {
+		tags[k] = v
+	}
+	tags["calling-service"] = string(cf.Caller())
+	tags["target-service"] = string(cf.Service())
+	tags["target-endpoint"] = string(cf.Method())
+
+	return &rejectedCall{
+		reporter: h.stats,
+		tags:     tags,
+	}
+}
+
+func (c *rejectedCall) Destination() (*tchannel.Peer, bool) {
+	return nil, false
+}
+
+func (c *rejectedCall) SentBytes(uint16) {}
+
+func (c *rejectedCall) ReceivedBytes(uint16) {}
+
+func (c *rejectedCall) CallResponse(relay.RespFrame) {}
+
+func (c *rejectedCall) Succeeded() {}
+
+func (c *rejectedCall) Failed(reason string) {
+	tags := make(map[string]string, len(c.tags)+1)
+	for k, v := range c.tags {
+		tags[k] = v
+	}
+	tags["reason"] = reason
+	c.reporter.IncCounter("relay.calls.failed", tags, 1)
+}
+
+func (c *rejectedCall) End() {}
@@ -0,0 +1,309 @@
+// Copyright (c) 2015 Uber Technologies, Inc.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package ratelimit provides a tchannel.RelayHost wrapper that enforces
+// token-bucket quotas on relayed calls.
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/uber/tchannel-go"
+	"github.com/uber/tchannel-go/relay"
+)
+
+// Action is what the relay does with calls that exceed their quota.
+type Action int
+
+const (
+	// Busy rejects calls with an ErrCodeBusy error.
+	Busy Action = iota
+
+	// Drop silently drops calls, so callers see a timeout.
+	Drop
+)
+
+// AnyCaller is used as the caller when setting a quota for a service that is
+// shared by all callers.
+const AnyCaller = ""
+
+// Quota is a token-bucket quota.
+type Quota struct {
+	// RPS is the rate that tokens are added to the bucket, in calls per second.
+	// If zero, calls are not limited.
+	RPS float64
+
+	// Burst is the maximum number of tokens in the bucket. Defaults to the
+	// RPS rounded up, with a minimum of 1.
+	Burst int
+}
+
+func (q Quota) burst() float64 {
+	if q.Burst > 0 {
+		return float64(q.Burst)
+	}
+	return math.Max(1, math.Ceil(q.RPS))
+}
+
+// Options are used to configure a RelayHost.
+type Options struct {
+	// DefaultQuota applies to each (caller, service) pair that has no quota
+	// for the caller and service, or for the service. By default, calls are
+	// not limited.
+	DefaultQuota Quota
+
+	// ConnQuota applies to the calls received on each connection, regardless
+	// of the caller and service, so a single client can't use up a quota that
+	// is shared with other clients. By default, connections are not limited.
+	ConnQuota Quota
+
+	// Action is what the relay does with calls that exceed their quota.
+	// Defaults to Busy.
+	Action Action
+
+	// TimeNow is a variable for overriding time.Now in unit tests.
+	TimeNow func() time.Time
+}
+
+type quotaKey struct {
+	caller  string
+	service string
+
+	// conn is only set for the buckets used by ConnQuota, and is the remote
+	// address of the connection.
+	conn string
+}
+
+// _bucketSweepInterval is how often buckets that have refilled are removed.
+const _bucketSweepInterval = time.Minute
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+
+	// full is when the bucket refills, after which it's equivalent to a new
+	// bucket and can be removed.
+	full time.Time
+}
+
+// refill adds the tokens for the time since the bucket was last used.
+func (b *bucket) refill(q Quota, now time.Time) {
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = math.Min(q.burst(), b.tokens+elapsed.Seconds()*q.RPS)
+		b.last = now
+	}
+}
+
+// take removes a token from the bucket.
+func (b *bucket) take(q Quota, now time.Time) {
+	b.tokens--
+	b.full = now.Add(time.Duration((q.burst() - b.tokens) / q.RPS * float64(time.Second)))
+}
+
+// RelayHost wraps a tchannel.RelayHost and enforces quotas per (caller, service),
+// and optionally per connection. Quotas can be changed at runtime using
+// SetQuota, SetDefaultQuota and SetConnQuota.
+//
+// Calls that exceed their quota are rejected before the wrapped RelayHost's
+// Start is called, so no peer is selected for them. The relay reports them as
+// failed calls, see rejectedCall.
+type RelayHost struct {
+	host      tchannel.RelayHost
+	action    Action
+	timeNow   func() time.Time
+	stats     tchannel.StatsReporter
+	statsTags map[string]string
+
+	sync.Mutex
+	defaultQuota Quota
+	connQuota    Quota
+	quotas       map[quotaKey]Quota
+	buckets      map[quotaKey]*bucket
+	lastSweep    time.Time
+}
+
+var _ tchannel.RelayHost = (*RelayHost)(nil)
+
+// NewRelayHost returns a RelayHost that enforces quotas on calls before
+// passing them to host.
+func NewRelayHost(host tchannel.RelayHost, opts Options) *RelayHost {
+	timeNow := opts.TimeNow
+	if timeNow == nil {
+		timeNow = time.Now
+	}
+	return &RelayHost{
+		host:         host,
+		action:       opts.Action,
+		timeNow:      timeNow,
+		stats:        tchannel.NullStatsReporter,
+		defaultQuota: opts.DefaultQuota,
+		connQuota:    opts.ConnQuota,
+		quotas:       make(map[quotaKey]Quota),
+		buckets:      make(map[quotaKey]*bucket),
+	}
+}
+
+// SetQuota sets the quota for calls from caller to service. If caller is
+// AnyCaller, the quota is shared by all callers of the service that do not
+// have their own quota. Setting a quota resets its bucket to full.
+func (h *RelayHost) SetQuota(caller, service string, q Quota) {
+	key := quotaKey{caller: caller, service: service}
+
+	h.Lock()
+	defer h.Unlock()
+	h.quotas[key] = q
+	h.resetBuckets(func(k quotaKey) bool { return k.service == service })
+}
+
+// RemoveQuota removes the quota set using SetQuota for calls from caller to service.
+func (h *RelayHost) RemoveQuota(caller, service string) {
+	key := quotaKey{caller: caller, service: service}
+
+	h.Lock()
+	defer h.Unlock()
+	delete(h.quotas, key)
+	h.resetBuckets(func(k quotaKey) bool { return k.service == service })
+}
+
+// SetDefaultQuota sets the quota for (caller, service) pairs without a
+// quota set using SetQuota.
+func (h *RelayHost) SetDefaultQuota(q Quota) {
+	h.Lock()
+	defer h.Unlock()
+	h.defaultQuota = q
+	h.resetBuckets(func(k quotaKey) bool { return k.conn == "" })
+}
+
+// SetConnQuota sets the quota for calls received on each connection.
+func (h *RelayHost) SetConnQuota(q Quota) {
+	h.Lock()
+	defer h.Unlock()
+	h.connQuota = q
+	h.resetBuckets(func(k quotaKey) bool { return k.conn != "" })
+}
+
+// resetBuckets must be called with the lock held.
+func (h *RelayHost) resetBuckets(match func(quotaKey) bool) {
+	for k := range h.buckets {
+		if match(k) {
+			delete(h.buckets, k)
+		}
+	}
+}
+
+// SetChannel passes the channel to the wrapped RelayHost.
+func (h *RelayHost) SetChannel(ch *tchannel.Channel) {
+	h.stats = ch.StatsReporter()
+	h.statsTags = ch.StatsTags()
+	h.host.SetChannel(ch)
+}
+
+// Start checks the quota for the call, then starts the call using the wrapped RelayHost.
+func (h *RelayHost) Start(cf relay.CallFrame, conn *relay.Conn) (tchannel.RelayCall, error) {
+	caller, service := string(cf.Caller()), string(cf.Service())
+	var remoteAddr string
+	if conn != nil {
+		remoteAddr = conn.RemoteAddr
+	}
+	if h.allow(caller, service, remoteAddr) {
+		return h.host.Start(cf, conn)
+	}
+
+	call := h.newRejectedCall(cf)
+	if h.action == Drop {
+		return call, relay.RateLimitDropError{}
+	}
+	return call, tchannel.NewSystemError(tchannel.ErrCodeBusy, "rate limit exceeded for %v calling %v", caller, service)
+}
+
+// allow takes a token from the buckets for the call, and returns whether the
+// call is within its quotas. remoteAddr is the remote address of the
+// connection that the call was received on.
+func (h *RelayHost) allow(caller, service, remoteAddr string) bool {
+	now := h.timeNow()
+
+	h.Lock()
+	defer h.Unlock()
+
+	h.maybeSweep(now)
+
+	key := quotaKey{caller: caller, service: service}
+	q, ok := h.quotas[key]
+	if !ok {
+		if q, ok = h.quotas[quotaKey{service: service}]; ok {
+			key = quotaKey{service: service}
+		} else {
+			q = h.defaultQuota
+		}
+	}
+	b := h.getBucket(key, q, now)
+
+	var connBucket *bucket
+	if remoteAddr != "" {
+		connBucket = h.getBucket(quotaKey{conn: remoteAddr}, h.connQuota, now)
+	}
+
+	// Only take tokens once the call is within all of its quotas, so a
+	// rejected call doesn't use up any of them.
+	if (b != nil && b.tokens < 1) || (connBucket != nil && connBucket.tokens < 1) {
+		return false
+	}
+	if b != nil {
+		b.take(q, now)
+	}
+	if connBucket != nil {
+		connBucket.take(h.connQuota, now)
+	}
+	return true
+}
+
+// getBucket returns the refilled bucket for key, or nil if calls are not
+// limited by q. It must be called with the lock held.
+func (h *RelayHost) getBucket(key quotaKey, q Quota, now time.Time) *bucket {
+	if q.RPS <= 0 {
+		return nil
+	}
+
+	b, ok := h.buckets[key]
+	if !ok {
+		b = &bucket{tokens: q.burst(), last: now}
+		h.buckets[key] = b
+	}
+	b.refill(q, now)
+	return b
+}
+
+// maybeSweep removes buckets that have refilled, at most once every
+// _bucketSweepInterval, so buckets for callers that stop calling are not
+// kept forever. It must be called with the lock held.
+func (h *RelayHost) maybeSweep(now time.Time) {
+	if now.Sub(h.lastSweep) < _bucketSweepInterval {
+		return
+	}
+	h.lastSweep = now
+
+	for k, b := range h.buckets {
+		if !now.Before(b.full) {
+			delete(h.buckets, k)
+		}
+	}
+}
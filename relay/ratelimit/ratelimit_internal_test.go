@@ -0,0 +1,55 @@
+// Copyright (c) 2015 Uber Technologies, Inc.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ratelimit
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/uber/tchannel-go/testutils"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBucketsExpire(t *testing.T) {
+	clock := testutils.NewStubClock(time.Unix(1000, 0))
+	h := NewRelayHost(nil, Options{
+		DefaultQuota: Quota{RPS: 1, Burst: 2},
+		TimeNow:      clock.Now,
+	})
+
+	for i := 0; i < 100; i++ {
+		assert.True(t, h.allow(fmt.Sprintf("caller-%v", i), "svc", ""), "First call should be allowed")
+	}
+
+	clock.Elapse(_bucketSweepInterval - time.Second)
+	assert.True(t, h.allow("active", "svc", ""), "First call should be allowed")
+	assert.True(t, h.allow("active", "svc", ""), "Second call should be allowed")
+	assert.Len(t, h.buckets, 101, "Expected a bucket per caller")
+
+	// After the sweep interval, buckets that have refilled are removed, and
+	// buckets that are still refilling are kept.
+	clock.Elapse(time.Second)
+	assert.True(t, h.allow("active", "svc", ""), "Call should be allowed after a token is added")
+	assert.Len(t, h.buckets, 1, "Refilled buckets should be removed")
+	assert.Contains(t, h.buckets, quotaKey{caller: "active", service: "svc"}, "Refilling bucket should be kept")
+}
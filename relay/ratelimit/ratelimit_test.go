@@ -0,0 +1,224 @@
+// Copyright (c) 2015 Uber Technologies, Inc.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ratelimit_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/uber/tchannel-go"
+	"github.com/uber/tchannel-go/raw"
+	"github.com/uber/tchannel-go/relay/ratelimit"
+	"github.com/uber/tchannel-go/relay/relaytest"
+	"github.com/uber/tchannel-go/testutils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type counterKey struct {
+	name   string
+	reason string
+}
+
+// countingStatsReporter counts counters by name and "reason" tag.
+type countingStatsReporter struct {
+	sync.Mutex
+	counters map[counterKey]int64
+}
+
+func (r *countingStatsReporter) IncCounter(name string, tags map[string]string, value int64) {
+	r.Lock()
+	defer r.Unlock()
+	r.counters[counterKey{name, tags["reason"]}] += value
+}
+
+func (r *countingStatsReporter) UpdateGauge(name string, tags map[string]string, value int64) {}
+
+func (r *countingStatsReporter) RecordTimer(name string, tags map[string]string, d time.Duration) {}
+
+func (r *countingStatsReporter) get(name, reason string) int64 {
+	r.Lock()
+	defer r.Unlock()
+	return r.counters[counterKey{name, reason}]
+}
+
+type relayTest struct {
+	t      testing.TB
+	server *tchannel.Channel
+	relay  *tchannel.Channel
+	stub   *relaytest.StubRelayHost
+	host   *ratelimit.RelayHost
+	clock  *testutils.StubClock
+	stats  *countingStatsReporter
+}
+
+func newRelayTest(t testing.TB, opts ratelimit.Options) *relayTest {
+	clock := testutils.NewStubClock(time.Unix(1000, 0))
+	opts.TimeNow = clock.Now
+
+	stub := relaytest.NewStubRelayHost()
+	host := ratelimit.NewRelayHost(stub, opts)
+
+	server := testutils.NewServer(t, nil)
+	testutils.RegisterEcho(server, nil)
+
+	stats := &countingStatsReporter{counters: make(map[counterKey]int64)}
+	relayCh := testutils.NewServer(t, testutils.NewOpts().
+		SetServiceName("relay").
+		SetStatsReporter(stats).
+		SetRelayHost(host))
+	stub.Add(server.ServiceName(), server.PeerInfo().HostPort)
+
+	return &relayTest{t, server, relayCh, stub, host, clock, stats}
+}
+
+func (rt *relayTest) close() {
+	rt.relay.Close()
+	rt.server.Close()
+}
+
+func (rt *relayTest) call(client *tchannel.Channel, timeout time.Duration) error {
+	ctx, cancel := tchannel.NewContext(timeout)
+	defer cancel()
+	_, _, _, err := raw.Call(ctx, client, rt.relay.PeerInfo().HostPort, rt.server.ServiceName(), "echo", nil, nil)
+	return err
+}
+
+func newClient(t testing.TB, name string) *tchannel.Channel {
+	return testutils.NewClient(t, testutils.NewOpts().SetServiceName(name))
+}
+
+func TestRelayHostBusy(t *testing.T) {
+	rt := newRelayTest(t, ratelimit.Options{})
+	defer rt.close()
+
+	client := newClient(t, "caller")
+	defer client.Close()
+
+	rt.host.SetQuota("caller", rt.server.ServiceName(), ratelimit.Quota{RPS: 1, Burst: 2})
+
+	require.NoError(t, rt.call(client, time.Second), "First call should be within the burst")
+	require.NoError(t, rt.call(client, time.Second), "Second call should be within the burst")
+
+	err := rt.call(client, time.Second)
+	require.Error(t, err, "Third call should exceed the quota")
+	assert.Equal(t, tchannel.ErrCodeBusy, tchannel.GetSystemErrorCode(err), "Unexpected error: %v", err)
+
+	// Tokens are added at the quota's rate.
+	rt.clock.Elapse(time.Second)
+	require.NoError(t, rt.call(client, time.Second), "Call should succeed after a token is added")
+
+	// The wrapped RelayHost does not start calls that exceed their quota.
+	calls := relaytest.NewMockStats()
+	for i := 0; i < 3; i++ {
+		calls.Add("caller", rt.server.ServiceName(), "echo").Succeeded().End()
+	}
+	rt.stub.Stats().AssertEqual(t, calls)
+	assert.EqualValues(t, 1, rt.stats.get("relay.calls.failed", "relay-busy"), "Unexpected rejected calls")
+}
+
+func TestRelayHostDrop(t *testing.T) {
+	rt := newRelayTest(t, ratelimit.Options{
+		Action:       ratelimit.Drop,
+		DefaultQuota: ratelimit.Quota{RPS: 1},
+	})
+	defer rt.close()
+
+	client := newClient(t, "caller")
+	defer client.Close()
+
+	require.NoError(t, rt.call(client, time.Second), "First call should be within the quota")
+	err := rt.call(client, testutils.Timeout(50*time.Millisecond))
+	assert.Equal(t, tchannel.ErrTimeout, err, "Dropped call should time out")
+
+	calls := relaytest.NewMockStats()
+	calls.Add("caller", rt.server.ServiceName(), "echo").Succeeded().End()
+	rt.stub.Stats().AssertEqual(t, calls)
+	assert.EqualValues(t, 1, rt.stats.get("relay.calls.failed", "relay-dropped"), "Unexpected dropped calls")
+}
+
+func TestRelayHostConnQuota(t *testing.T) {
+	rt := newRelayTest(t, ratelimit.Options{
+		ConnQuota: ratelimit.Quota{RPS: 1, Burst: 2},
+	})
+	defer rt.close()
+
+	// Both clients use the same service name, so only the connection differs.
+	c1 := newClient(t, "caller")
+	defer c1.Close()
+	c2 := newClient(t, "caller")
+	defer c2.Close()
+
+	for i := 0; i < 2; i++ {
+		require.NoError(t, rt.call(c1, time.Second), "Call %v should be within c1's connection quota", i)
+	}
+	require.Error(t, rt.call(c1, time.Second), "c1 should exceed its connection quota")
+	require.NoError(t, rt.call(c2, time.Second), "c2 should not be limited by c1's connection")
+
+	// A call that exceeds the connection quota doesn't use up the service quota.
+	rt.host.SetQuota(ratelimit.AnyCaller, rt.server.ServiceName(), ratelimit.Quota{RPS: 1, Burst: 1})
+	require.Error(t, rt.call(c1, time.Second), "c1 should still exceed its connection quota")
+	require.NoError(t, rt.call(c2, time.Second), "Service quota should not be used by rejected calls")
+
+	// Connection quotas can be changed at runtime.
+	rt.host.SetConnQuota(ratelimit.Quota{})
+	rt.host.RemoveQuota(ratelimit.AnyCaller, rt.server.ServiceName())
+	for i := 0; i < 5; i++ {
+		require.NoError(t, rt.call(c1, time.Second), "Calls should not be limited after removing the connection quota")
+	}
+	assert.EqualValues(t, 2, rt.stats.get("relay.calls.failed", "relay-busy"), "Unexpected rejected calls")
+}
+
+func TestRelayHostQuotas(t *testing.T) {
+	rt := newRelayTest(t, ratelimit.Options{})
+	defer rt.close()
+
+	c1 := newClient(t, "c1")
+	defer c1.Close()
+	c2 := newClient(t, "c2")
+	defer c2.Close()
+
+	// Without quotas, calls are not limited.
+	for i := 0; i < 5; i++ {
+		require.NoError(t, rt.call(c1, time.Second), "Calls should not be limited without a quota")
+	}
+
+	// A service quota is shared by all callers without their own quota.
+	rt.host.SetQuota(ratelimit.AnyCaller, rt.server.ServiceName(), ratelimit.Quota{RPS: 1})
+	require.NoError(t, rt.call(c1, time.Second), "First call to the service should succeed")
+	require.Error(t, rt.call(c2, time.Second), "Service quota should be shared by callers")
+
+	// A caller's quota takes precedence over the service quota.
+	rt.host.SetQuota("c2", rt.server.ServiceName(), ratelimit.Quota{RPS: 1, Burst: 3})
+	for i := 0; i < 3; i++ {
+		require.NoError(t, rt.call(c2, time.Second), "Call %v should be within c2's quota", i)
+	}
+	require.Error(t, rt.call(c2, time.Second), "c2 should exceed its quota")
+
+	// Removing quotas at runtime stops limiting calls.
+	rt.host.RemoveQuota("c2", rt.server.ServiceName())
+	rt.host.RemoveQuota(ratelimit.AnyCaller, rt.server.ServiceName())
+	for i := 0; i < 5; i++ {
+		require.NoError(t, rt.call(c2, time.Second), "Calls should not be limited after removing quotas")
+	}
+}
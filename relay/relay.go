@@ -22,6 +22,9 @@
 //
 // These interfaces are currently unstable, and aren't covered by the API
 // backwards-compatibility guarantee.
+//
+// See the relay/routing package for a RelayHost that routes calls using a
+// static routing table.
 package relay
 
 import (
@@ -105,6 +108,8 @@ type Conn struct {
 // the actual TChannel protocol.
 // The relayer will record that it has dropped the packet, but *won't* notify
 // the client.
+//
+// See the relay/ratelimit package for a RelayHost wrapper that enforces quotas.
 type RateLimitDropError struct{}
 
 func (e RateLimitDropError) Error() string {
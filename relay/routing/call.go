@@ -0,0 +1,97 @@
+// Copyright (c) 2015 Uber Technologies, Inc.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package routing
+
+import (
+	"time"
+
+	"github.com/uber/tchannel-go"
+	"github.com/uber/tchannel-go/relay"
+
+	"go.uber.org/atomic"
+)
+
+// relayCall is the tchannel.RelayCall returned by RelayHost. It reports the
+// outcome of each relayed call to the relay channel's StatsReporter:
+//
+//	relay.calls.success                   counter
+//	relay.calls.failed (tagged "reason")  counter, e.g. reason "timeout"
+//	relay.calls.latency                   timer
+//	relay.bytes.sent, relay.bytes.recvd   counters
+type relayCall struct {
+	reporter tchannel.StatsReporter
+	tags     map[string]string
+	timeNow  func() time.Time
+	started  time.Time
+	peer     *tchannel.Peer
+
+	// finished is set by the first call to Succeeded or Failed.
+	finished   atomic.Bool
+	sentBytes  atomic.Uint64
+	recvdBytes atomic.Uint64
+}
+
+var _ tchannel.RelayCall = (*relayCall)(nil)
+
+func (c *relayCall) Destination() (*tchannel.Peer, bool) {
+	return c.peer, c.peer != nil
+}
+
+func (c *relayCall) SentBytes(n uint16) {
+	c.sentBytes.Add(uint64(n))
+}
+
+func (c *relayCall) ReceivedBytes(n uint16) {
+	c.recvdBytes.Add(uint64(n))
+}
+
+func (c *relayCall) CallResponse(relay.RespFrame) {}
+
+func (c *relayCall) Succeeded() {
+	if c.finished.CAS(false, true) {
+		c.reporter.IncCounter("relay.calls.success", c.tags, 1)
+	}
+}
+
+func (c *relayCall) Failed(reason string) {
+	if c.finished.CAS(false, true) {
+		c.reporter.IncCounter("relay.calls.failed", withTag(c.tags, "reason", reason), 1)
+	}
+}
+
+func (c *relayCall) End() {
+	c.reporter.RecordTimer("relay.calls.latency", c.tags, c.timeNow().Sub(c.started))
+	if n := c.sentBytes.Load(); n > 0 {
+		c.reporter.IncCounter("relay.bytes.sent", c.tags, int64(n))
+	}
+	if n := c.recvdBytes.Load(); n > 0 {
+		c.reporter.IncCounter("relay.bytes.recvd", c.tags, int64(n))
+	}
+}
+
+func withTag(tags map[string]string, key, value string) map[string]string {
+	newTags := make(map[string]string, len(tags)+1)
+	for k, v := range tags {
+		newTags[k] = v
+	}
+	newTags[key] = value
+	return newTags
+}
@@ -0,0 +1,326 @@
+// Copyright (c) 2015 Uber Technologies, Inc.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package routing provides a tchannel.RelayHost that relays calls using a
+// static routing table, which can be loaded from a file and reloaded when
+// the file changes.
+//
+// A relay using a routing table file can be created with:
+//
+//	host, err := routing.NewRelayHost(routing.Options{File: "routes.yaml"})
+//	if err != nil {
+//		// handle error
+//	}
+//	defer host.Close()
+//
+//	ch, err := tchannel.NewChannel("relay", &tchannel.ChannelOptions{
+//		RelayHost: host,
+//	})
+package routing
+
+import (
+	"bytes"
+	"io/ioutil"
+	"sync"
+	"time"
+
+	"github.com/uber/tchannel-go"
+	"github.com/uber/tchannel-go/relay"
+)
+
+const defaultReloadInterval = 10 * time.Second
+
+// Options are used to configure a RelayHost.
+type Options struct {
+	// Table is the initial routing table. It's ignored if File is set.
+	Table Table
+
+	// File is the path of a routing table file. See Table for the file format.
+	File string
+
+	// ReloadInterval is how often File is read to check for changes. If the
+	// contents have changed, the routing table is reloaded. Defaults to 10 seconds;
+	// a negative value disables reloading.
+	ReloadInterval time.Duration
+
+	// PeerStrategy returns the peer selection strategy for the peers of the
+	// given routing name. By default, the channel's default peer selection
+	// is used.
+	PeerStrategy func(name string) tchannel.ScoreCalculator
+
+	// TimeNow is a variable for overriding time.Now in unit tests.
+	TimeNow func() time.Time
+}
+
+// RelayHost is a tchannel.RelayHost that routes calls using a Table.
+//
+// Calls are routed using the routing delegate if the caller set one,
+// otherwise the routing key if the caller set one, otherwise the service
+// name. Calls to a name that is not in the routing table are declined.
+//
+// Each name in the routing table has its own peer list, which is an
+// isolated subchannel of the relay channel.
+type RelayHost struct {
+	opts    Options
+	timeNow func() time.Time
+
+	sync.RWMutex
+	ch         *tchannel.Channel
+	table      Table
+	configured map[string]struct{}
+
+	// fileData is the last contents of File seen by watch.
+	fileData []byte
+
+	stopOnce sync.Once
+	stopCh   chan struct{}
+}
+
+var _ tchannel.RelayHost = (*RelayHost)(nil)
+
+// NewRelayHost returns a RelayHost using the given options. If opts.File is
+// set, the routing table is loaded from the file, and an error is returned
+// if it can't be loaded.
+func NewRelayHost(opts Options) (*RelayHost, error) {
+	table := opts.Table
+	var fileData []byte
+	if opts.File != "" {
+		var err error
+		if fileData, err = ioutil.ReadFile(opts.File); err != nil {
+			return nil, err
+		}
+		if table, err = ParseTable(fileData); err != nil {
+			return nil, err
+		}
+	} else if err := table.validate(); err != nil {
+		return nil, err
+	}
+
+	if opts.ReloadInterval == 0 {
+		opts.ReloadInterval = defaultReloadInterval
+	}
+	timeNow := opts.TimeNow
+	if timeNow == nil {
+		timeNow = time.Now
+	}
+
+	return &RelayHost{
+		opts:       opts,
+		timeNow:    timeNow,
+		table:      table,
+		configured: make(map[string]struct{}),
+		fileData:   fileData,
+		stopCh:     make(chan struct{}),
+	}, nil
+}
+
+// SetChannel is called by the relay channel after creation. It adds the
+// routing table's peers to the channel, and starts watching the routing
+// table file for changes.
+func (h *RelayHost) SetChannel(ch *tchannel.Channel) {
+	h.Lock()
+	h.ch = ch
+	h.applyTable(Table{}, h.table)
+	h.Unlock()
+
+	if h.opts.File != "" && h.opts.ReloadInterval > 0 {
+		go h.watch()
+	}
+}
+
+// Table returns the current routing table.
+func (h *RelayHost) Table() Table {
+	h.RLock()
+	defer h.RUnlock()
+	return h.table
+}
+
+// SetTable replaces the routing table. Peers that are no longer in the
+// table for a name are removed from that name's peer list.
+func (h *RelayHost) SetTable(table Table) error {
+	if err := table.validate(); err != nil {
+		return err
+	}
+
+	h.Lock()
+	defer h.Unlock()
+	h.applyTable(h.table, table)
+	h.table = table
+	return nil
+}
+
+// Reload loads the routing table from the file in Options and replaces the
+// current routing table. If the file can't be loaded, the current routing
+// table is kept.
+func (h *RelayHost) Reload() error {
+	table, err := LoadTable(h.opts.File)
+	if err != nil {
+		return err
+	}
+	return h.SetTable(table)
+}
+
+// Close stops watching the routing table file. It doesn't close the channel.
+func (h *RelayHost) Close() {
+	h.stopOnce.Do(func() { close(h.stopCh) })
+}
+
+// applyTable updates the channel's peer lists from the previous table to the
+// next table. It must be called with the lock held.
+//
+// A channel's subchannels can't be removed, so the subchannel for a name
+// that is removed from the table is kept with an empty peer list. If the name
+// is added back, the subchannel is reused and PeerStrategy is applied again.
+func (h *RelayHost) applyTable(prev, next Table) {
+	if h.ch == nil {
+		return
+	}
+
+	for name, hostPorts := range next.Services {
+		peers := h.peers(name)
+		keep := make(map[string]struct{}, len(hostPorts))
+		for _, hostPort := range hostPorts {
+			keep[hostPort] = struct{}{}
+			peers.GetOrAdd(hostPort)
+		}
+		for _, hostPort := range prev.Services[name] {
+			if _, ok := keep[hostPort]; !ok {
+				peers.Remove(hostPort)
+			}
+		}
+	}
+	for name, hostPorts := range prev.Services {
+		if _, ok := next.Services[name]; ok {
+			continue
+		}
+		peers := h.peers(name)
+		for _, hostPort := range hostPorts {
+			peers.Remove(hostPort)
+		}
+		delete(h.configured, name)
+	}
+}
+
+// peers returns the peer list for the given routing name, setting the peer
+// selection strategy the first time it's used. It must be called with the
+// lock held.
+func (h *RelayHost) peers(name string) *tchannel.PeerList {
+	peers := h.ch.GetSubChannel(name, tchannel.Isolated).Peers()
+	if _, ok := h.configured[name]; !ok {
+		h.configured[name] = struct{}{}
+		if h.opts.PeerStrategy != nil {
+			peers.SetStrategy(h.opts.PeerStrategy(name))
+		}
+	}
+	return peers
+}
+
+// Start selects a peer for the call using the routing table.
+func (h *RelayHost) Start(cf relay.CallFrame, _ *relay.Conn) (tchannel.RelayCall, error) {
+	name := routingName(cf)
+	call := h.newCall(cf)
+
+	h.RLock()
+	_, ok := h.table.Services[name]
+	h.RUnlock()
+	if !ok {
+		return call, tchannel.NewSystemError(tchannel.ErrCodeDeclined, "no route for %q", name)
+	}
+
+	// The subchannel is created by applyTable, but it's always isolated so
+	// calls are never routed using the relay channel's peers.
+	peer, err := h.ch.GetSubChannel(name, tchannel.Isolated).Peers().Get(nil)
+	if err != nil {
+		return call, tchannel.NewWrappedSystemError(tchannel.ErrCodeDeclined, err)
+	}
+	call.peer = peer
+	return call, nil
+}
+
+func (h *RelayHost) newCall(cf relay.CallFrame) *relayCall {
+	tags := make(map[string]string)
+	for k, v := range h.ch.StatsTags() {
+		tags[k] = v
+	}
+	tags["calling-service"] = string(cf.Caller())
+	tags["target-service"] = string(cf.Service())
+	tags["target-endpoint"] = string(cf.Method())
+
+	return &relayCall{
+		reporter: h.ch.StatsReporter(),
+		tags:     tags,
+		timeNow:  h.timeNow,
+		started:  h.timeNow(),
+	}
+}
+
+// routingName returns the name in the routing table that the call is routed by.
+func routingName(cf relay.CallFrame) string {
+	if rd := cf.RoutingDelegate(); len(rd) > 0 {
+		return string(rd)
+	}
+	if rk := cf.RoutingKey(); len(rk) > 0 {
+		return string(rk)
+	}
+	return string(cf.Service())
+}
+
+// watch reloads the routing table when the file changes, until the
+// RelayHost or the channel is closed.
+func (h *RelayHost) watch() {
+	ticker := time.NewTicker(h.opts.ReloadInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-h.stopCh:
+			return
+		case <-ticker.C:
+		}
+		if h.ch.Closed() {
+			return
+		}
+
+		logger := h.ch.Logger().WithFields(tchannel.LogField{Key: "file", Value: h.opts.File})
+		data, err := ioutil.ReadFile(h.opts.File)
+		if err != nil {
+			// Only log a failure to read the file once, rather than on every tick.
+			if h.fileData != nil {
+				logger.WithFields(tchannel.ErrField(err)).Warn("Failed to read relay routing table.")
+				h.fileData = nil
+			}
+			continue
+		}
+		if bytes.Equal(data, h.fileData) {
+			continue
+		}
+		h.fileData = data
+
+		table, err := ParseTable(data)
+		if err == nil {
+			err = h.SetTable(table)
+		}
+		if err != nil {
+			logger.WithFields(tchannel.ErrField(err)).Warn("Failed to reload relay routing table.")
+			continue
+		}
+		logger.Info("Reloaded relay routing table.")
+	}
+}
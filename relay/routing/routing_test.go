@@ -0,0 +1,334 @@
+// Copyright (c) 2015 Uber Technologies, Inc.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package routing_test
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/uber/tchannel-go"
+	"github.com/uber/tchannel-go/raw"
+	"github.com/uber/tchannel-go/relay/routing"
+	"github.com/uber/tchannel-go/testutils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+// countingStatsReporter counts counters by name and tags.
+type countingStatsReporter struct {
+	sync.Mutex
+	counters map[string]int64
+}
+
+func newCountingStatsReporter() *countingStatsReporter {
+	return &countingStatsReporter{counters: make(map[string]int64)}
+}
+
+func (r *countingStatsReporter) IncCounter(name string, tags map[string]string, value int64) {
+	r.Lock()
+	defer r.Unlock()
+	if reason, ok := tags["reason"]; ok {
+		name += ":" + reason
+	}
+	r.counters[name] += value
+}
+
+func (r *countingStatsReporter) UpdateGauge(name string, tags map[string]string, value int64) {}
+
+func (r *countingStatsReporter) RecordTimer(name string, tags map[string]string, d time.Duration) {
+	r.IncCounter(name, nil, 1)
+}
+
+func (r *countingStatsReporter) get(name string) int64 {
+	r.Lock()
+	defer r.Unlock()
+	return r.counters[name]
+}
+
+// newServer returns a server for "svc" that responds to "echo" with its name.
+func newServer(t testing.TB, name string) *tchannel.Channel {
+	server := testutils.NewServer(t, testutils.NewOpts().SetServiceName("svc"))
+	testutils.RegisterFunc(server, "echo", func(ctx context.Context, args *raw.Args) (*raw.Res, error) {
+		return &raw.Res{Arg3: []byte(name)}, nil
+	})
+	return server
+}
+
+func newRelay(t testing.TB, host *routing.RelayHost, stats tchannel.StatsReporter) *tchannel.Channel {
+	opts := testutils.NewOpts().SetServiceName("relay").SetRelayHost(host)
+	if stats != nil {
+		opts.SetStatsReporter(stats)
+	}
+	return testutils.NewServer(t, opts)
+}
+
+func call(client, relay *tchannel.Channel, cb *tchannel.ContextBuilder, service string) (string, error) {
+	ctx, cancel := cb.Build()
+	defer cancel()
+	_, arg3, _, err := raw.Call(ctx, client, relay.PeerInfo().HostPort, service, "echo", nil, nil)
+	return string(arg3), err
+}
+
+func newContext() *tchannel.ContextBuilder {
+	return tchannel.NewContextBuilder(testutils.Timeout(time.Second))
+}
+
+func writeTable(t testing.TB, path string, contents string) {
+	// Write to a temporary file and rename it, so a reload never sees a
+	// partially written table.
+	tmp := path + ".tmp"
+	require.NoError(t, os.WriteFile(tmp, []byte(contents), 0644), "Failed to write routing table")
+	require.NoError(t, os.Rename(tmp, path), "Failed to rename routing table")
+}
+
+func TestParseTable(t *testing.T) {
+	tests := []struct {
+		msg     string
+		data    string
+		want    routing.Table
+		wantErr string
+	}{
+		{
+			msg:  "yaml",
+			data: "services:\n  svc: [\"127.0.0.1:1\", \"127.0.0.1:2\"]\n",
+			want: routing.Table{Services: map[string][]string{"svc": {"127.0.0.1:1", "127.0.0.1:2"}}},
+		},
+		{
+			msg:  "json",
+			data: `{"services": {"svc": ["127.0.0.1:1"]}}`,
+			want: routing.Table{Services: map[string][]string{"svc": {"127.0.0.1:1"}}},
+		},
+		{
+			msg:     "unknown field",
+			data:    "routes: {}\n",
+			wantErr: "failed to parse routing table",
+		},
+		{
+			msg:     "invalid host:port",
+			data:    "services:\n  svc: [\"127.0.0.1\"]\n",
+			wantErr: "invalid host:port",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.msg, func(t *testing.T) {
+			table, err := routing.ParseTable([]byte(tt.data))
+			if tt.wantErr != "" {
+				require.Error(t, err, "Expected parse to fail")
+				assert.Contains(t, err.Error(), tt.wantErr, "Unexpected error")
+				return
+			}
+			require.NoError(t, err, "Parse failed")
+			assert.Equal(t, tt.want, table, "Unexpected table")
+		})
+	}
+}
+
+func TestRelayHostRouting(t *testing.T) {
+	s1 := newServer(t, "s1")
+	defer s1.Close()
+	s2 := newServer(t, "s2")
+	defer s2.Close()
+
+	stats := newCountingStatsReporter()
+	host, err := routing.NewRelayHost(routing.Options{
+		Table: routing.Table{Services: map[string][]string{
+			"svc":        {s1.PeerInfo().HostPort},
+			"svc-canary": {s2.PeerInfo().HostPort},
+			"router":     {s2.PeerInfo().HostPort},
+			"empty":      nil,
+		}},
+	})
+	require.NoError(t, err, "NewRelayHost failed")
+	relay := newRelay(t, host, stats)
+	defer relay.Close()
+
+	client := testutils.NewClient(t, nil)
+	defer client.Close()
+
+	tests := []struct {
+		msg      string
+		cb       *tchannel.ContextBuilder
+		service  string
+		want     string
+		wantCode tchannel.SystemErrCode
+	}{
+		{msg: "service", cb: newContext(), service: "svc", want: "s1"},
+		{msg: "routing key", cb: newContext().SetRoutingKey("svc-canary"), service: "svc", want: "s2"},
+		{msg: "routing delegate", cb: newContext().SetRoutingKey("svc-canary").SetRoutingDelegate("router"), service: "svc", want: "s2"},
+		{msg: "unknown service", cb: newContext(), service: "unknown", wantCode: tchannel.ErrCodeDeclined},
+		{msg: "unknown routing key", cb: newContext().SetRoutingKey("unknown"), service: "svc", wantCode: tchannel.ErrCodeDeclined},
+		{msg: "no peers", cb: newContext(), service: "empty", wantCode: tchannel.ErrCodeDeclined},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.msg, func(t *testing.T) {
+			got, err := call(client, relay, tt.cb, tt.service)
+			if tt.wantCode != 0 {
+				require.Error(t, err, "Expected call to fail")
+				assert.Equal(t, tt.wantCode, tchannel.GetSystemErrorCode(err), "Unexpected error: %v", err)
+				return
+			}
+			require.NoError(t, err, "Call failed")
+			assert.Equal(t, tt.want, got, "Call routed to unexpected server")
+		})
+	}
+
+	assert.EqualValues(t, 3, stats.get("relay.calls.success"), "Unexpected successful calls")
+	assert.EqualValues(t, 3, stats.get("relay.calls.failed:relay-declined"), "Unexpected declined calls")
+	assert.EqualValues(t, 6, stats.get("relay.calls.latency"), "Unexpected latency timers")
+	assert.True(t, stats.get("relay.bytes.sent") > 0, "Expected sent bytes")
+	assert.True(t, stats.get("relay.bytes.recvd") > 0, "Expected received bytes")
+}
+
+func TestRelayHostSetTable(t *testing.T) {
+	s1 := newServer(t, "s1")
+	defer s1.Close()
+	s2 := newServer(t, "s2")
+	defer s2.Close()
+
+	host, err := routing.NewRelayHost(routing.Options{
+		Table: routing.Table{Services: map[string][]string{"svc": {s1.PeerInfo().HostPort}}},
+	})
+	require.NoError(t, err, "NewRelayHost failed")
+	relay := newRelay(t, host, nil)
+	defer relay.Close()
+
+	client := testutils.NewClient(t, nil)
+	defer client.Close()
+
+	got, err := call(client, relay, newContext(), "svc")
+	require.NoError(t, err, "Call failed")
+	assert.Equal(t, "s1", got, "Call routed to unexpected server")
+
+	// s1 is removed from the peer list, so all calls go to s2.
+	require.NoError(t, host.SetTable(routing.Table{Services: map[string][]string{"svc": {s2.PeerInfo().HostPort}}}))
+	for i := 0; i < 5; i++ {
+		got, err := call(client, relay, newContext(), "svc")
+		require.NoError(t, err, "Call failed")
+		assert.Equal(t, "s2", got, "Call routed to unexpected server")
+	}
+
+	// Removing the service from the table declines calls to it.
+	require.NoError(t, host.SetTable(routing.Table{}))
+	_, err = call(client, relay, newContext(), "svc")
+	assert.Equal(t, tchannel.ErrCodeDeclined, tchannel.GetSystemErrorCode(err), "Unexpected error: %v", err)
+
+	err = host.SetTable(routing.Table{Services: map[string][]string{"svc": {"invalid"}}})
+	assert.Error(t, err, "SetTable should reject invalid tables")
+	assert.Empty(t, host.Table().Services, "Invalid table should not replace the current table")
+}
+
+func TestRelayHostReload(t *testing.T) {
+	s1 := newServer(t, "s1")
+	defer s1.Close()
+	s2 := newServer(t, "s2")
+	defer s2.Close()
+
+	path := filepath.Join(t.TempDir(), "routes.yaml")
+	writeTable(t, path, "services:\n  svc: ["+s1.PeerInfo().HostPort+"]\n")
+
+	host, err := routing.NewRelayHost(routing.Options{
+		File:           path,
+		ReloadInterval: 10 * time.Millisecond,
+	})
+	require.NoError(t, err, "NewRelayHost failed")
+	defer host.Close()
+
+	relay := newRelay(t, host, nil)
+	defer relay.Close()
+
+	client := testutils.NewClient(t, nil)
+	defer client.Close()
+
+	got, err := call(client, relay, newContext(), "svc")
+	require.NoError(t, err, "Call failed")
+	assert.Equal(t, "s1", got, "Call routed to unexpected server")
+
+	writeTable(t, path, "services:\n  svc: ["+s2.PeerInfo().HostPort+"]\n")
+	assert.True(t, testutils.WaitFor(time.Second, func() bool {
+		got, err := call(client, relay, newContext(), "svc")
+		return err == nil && got == "s2"
+	}), "Routing table was not reloaded")
+}
+
+func TestRelayHostReloadFailure(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "routes.yaml")
+
+	_, err := routing.NewRelayHost(routing.Options{File: path})
+	assert.Error(t, err, "NewRelayHost should fail if the file doesn't exist")
+
+	writeTable(t, path, "services:\n  svc: [\"127.0.0.1:1\"]\n")
+	host, err := routing.NewRelayHost(routing.Options{File: path, ReloadInterval: -1})
+	require.NoError(t, err, "NewRelayHost failed")
+
+	writeTable(t, path, "services: [")
+	assert.Error(t, host.Reload(), "Reload should fail for an invalid file")
+	assert.Equal(t, []string{"127.0.0.1:1"}, host.Table().Services["svc"], "Failed reload should keep the current table")
+}
+
+func TestRelayHostPeerStrategy(t *testing.T) {
+	s1 := newServer(t, "s1")
+	defer s1.Close()
+
+	var (
+		mu    sync.Mutex
+		names []string
+	)
+	host, err := routing.NewRelayHost(routing.Options{
+		Table: routing.Table{Services: map[string][]string{"svc": {s1.PeerInfo().HostPort}}},
+		PeerStrategy: func(name string) tchannel.ScoreCalculator {
+			mu.Lock()
+			names = append(names, name)
+			mu.Unlock()
+			return tchannel.ScoreCalculatorFunc(func(p *tchannel.Peer) uint64 { return 0 })
+		},
+	})
+	require.NoError(t, err, "NewRelayHost failed")
+	relay := newRelay(t, host, nil)
+	defer relay.Close()
+
+	client := testutils.NewClient(t, nil)
+	defer client.Close()
+
+	got, err := call(client, relay, newContext(), "svc")
+	require.NoError(t, err, "Call failed")
+	assert.Equal(t, "s1", got, "Call routed to unexpected server")
+
+	// Updating the table only applies PeerStrategy to names that were removed
+	// and added back.
+	table := host.Table()
+	require.NoError(t, host.SetTable(table), "SetTable failed")
+	require.NoError(t, host.SetTable(routing.Table{}), "SetTable failed")
+	require.NoError(t, host.SetTable(table), "SetTable failed")
+	got, err = call(client, relay, newContext(), "svc")
+	require.NoError(t, err, "Call failed")
+	assert.Equal(t, "s1", got, "Call routed to unexpected server")
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{"svc", "svc"}, names, "PeerStrategy should be called when a name is added")
+}
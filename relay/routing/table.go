@@ -0,0 +1,78 @@
+// Copyright (c) 2015 Uber Technologies, Inc.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package routing
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Table is a static routing table.
+//
+// A routing table file is YAML (or JSON) of the form:
+//
+//	services:
+//	  users: ["10.0.0.1:4040", "10.0.0.2:4040"]
+//	  users-canary: ["10.0.0.3:4040"]
+type Table struct {
+	// Services maps a routing name to the host:ports that calls for that
+	// name are relayed to. A routing name is a service name, a routing key
+	// or a routing delegate.
+	Services map[string][]string `yaml:"services" json:"services"`
+}
+
+// ParseTable parses a YAML or JSON routing table.
+func ParseTable(data []byte) (Table, error) {
+	var t Table
+	if err := yaml.UnmarshalStrict(data, &t); err != nil {
+		return Table{}, fmt.Errorf("failed to parse routing table: %v", err)
+	}
+	if err := t.validate(); err != nil {
+		return Table{}, err
+	}
+	return t, nil
+}
+
+// LoadTable reads and parses the routing table in the given file.
+func LoadTable(path string) (Table, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return Table{}, err
+	}
+	return ParseTable(data)
+}
+
+func (t Table) validate() error {
+	for name, hostPorts := range t.Services {
+		if name == "" {
+			return fmt.Errorf("routing table has an empty service name")
+		}
+		for _, hostPort := range hostPorts {
+			if _, _, err := net.SplitHostPort(hostPort); err != nil {
+				return fmt.Errorf("service %q has invalid host:port %q: %v", name, hostPort, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,218 @@
+// Copyright (c) 2015 Uber Technologies, Inc.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package tchannel
+
+import (
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/atomic"
+)
+
+// relayTracedCall wraps a RelayCall to record a tracing span for the relayed
+// call, using the channel's tracer. The span is a child of the caller's span,
+// and the relayed frame's tracing fields are rewritten to the relay span, so
+// the destination's span is a child of the relay span.
+type relayTracedCall struct {
+	RelayCall
+
+	otSpan    opentracing.Span
+	otelSpan  trace.Span
+	frameSpan *Span
+	timeNow   func() time.Time
+
+	startTime      time.Time
+	requestFrames  atomic.Uint32
+	responseFrames atomic.Uint32
+	requestBytes   atomic.Uint64
+	responseBytes  atomic.Uint64
+	failure        atomic.String
+}
+
+// startRelaySpan starts a span for the call, using the frame's tracing fields
+// as the parent. It returns nil if relay tracing is disabled, or the tracer
+// does not support Zipkin-style IDs.
+func (r *Relayer) startRelaySpan(f *lazyCallReq) *relayTracedCall {
+	if !r.tracing {
+		return nil
+	}
+
+	tc := &relayTracedCall{
+		timeNow:   r.conn.timeNow,
+		startTime: r.conn.timeNow(),
+	}
+	parent := f.Span()
+	method := string(f.Method())
+
+	if tracer := r.conn.otelTracer; tracer != nil {
+		parentSC := parent.otelSpanContext()
+		ctx := trace.ContextWithRemoteSpanContext(r.conn.baseContext, parentSC)
+		_, tc.otelSpan = tracer.Start(ctx, method,
+			trace.WithSpanKind(trace.SpanKindInternal),
+			trace.WithTimestamp(tc.startTime),
+			trace.WithAttributes(
+				attribute.String("rpc.system", "tchannel"),
+				attribute.String("rpc.service", string(f.Service())),
+				attribute.String("rpc.method", method),
+				attribute.String("peer.service", string(f.Service())),
+				attribute.String("relay.caller", string(f.Caller())),
+				attribute.String("as", string(f.as)),
+			),
+		)
+		if sc := tc.otelSpan.SpanContext(); sc.IsValid() {
+			frameSpan := spanFromOTel(sc, parentSC)
+			tc.frameSpan = &frameSpan
+		}
+		return tc
+	}
+
+	tracer := r.conn.Tracer()
+	parentCtx, err := tracer.Extract(zipkinSpanFormat, &parent)
+	if err != nil || parentCtx == nil {
+		// Without Zipkin-style IDs, the relay span can't be linked to the frame's span.
+		return nil
+	}
+	tc.otSpan = tracer.StartSpan(method,
+		opentracing.ChildOf(parentCtx),
+		opentracing.StartTime(tc.startTime),
+	)
+	tc.otSpan.SetTag("component", "tchannel-relay")
+	tc.otSpan.SetTag("as", string(f.as))
+	tc.otSpan.SetTag("relay.caller", string(f.Caller()))
+	ext.PeerService.Set(tc.otSpan, string(f.Service()))
+
+	var injectable injectableSpan
+	if err := injectable.initFromOpenTracing(tc.otSpan); err == nil {
+		frameSpan := Span(injectable)
+		tc.frameSpan = &frameSpan
+	}
+	return tc
+}
+
+// rewriteFrameSpan rewrites the frame's tracing fields to the relay span,
+// so the destination's span is a child of the relay span.
+func (tc *relayTracedCall) rewriteFrameSpan(f *lazyCallReq) {
+	if tc == nil || tc.frameSpan == nil {
+		return
+	}
+	setCallReqSpan(f.Frame, *tc.frameSpan)
+}
+
+// wrap returns call wrapped to record the relay span. If call is nil,
+// the span is ended immediately with the failure reason.
+func (tc *relayTracedCall) wrap(call RelayCall, failure string) RelayCall {
+	if tc == nil {
+		return call
+	}
+	if call == nil {
+		tc.Failed(failure)
+		tc.End()
+		return nil
+	}
+	tc.RelayCall = call
+	return tc
+}
+
+// destinationSelected records the destination, and the time taken to select it.
+func (tc *relayTracedCall) destinationSelected(hostPort string) {
+	if tc == nil {
+		return
+	}
+
+	now := tc.timeNow()
+	selectionTime := now.Sub(tc.startTime)
+	if tc.otelSpan != nil {
+		tc.otelSpan.AddEvent("peer-selected", trace.WithTimestamp(now))
+		tc.otelSpan.SetAttributes(
+			attribute.String("relay.destination", hostPort),
+			attribute.Int64("relay.peer_selection_time_us", selectionTime.Microseconds()),
+		)
+	} else {
+		tc.otSpan.LogKV("event", "peer-selected")
+		tc.otSpan.SetTag("relay.destination", hostPort)
+		tc.otSpan.SetTag("relay.peer_selection_time_us", selectionTime.Microseconds())
+	}
+}
+
+// SentBytes implements RelayCall.
+func (tc *relayTracedCall) SentBytes(size uint16) {
+	tc.requestFrames.Inc()
+	tc.requestBytes.Add(uint64(size))
+	if tc.RelayCall != nil {
+		tc.RelayCall.SentBytes(size)
+	}
+}
+
+// ReceivedBytes implements RelayCall.
+func (tc *relayTracedCall) ReceivedBytes(size uint16) {
+	tc.responseFrames.Inc()
+	tc.responseBytes.Add(uint64(size))
+	if tc.RelayCall != nil {
+		tc.RelayCall.ReceivedBytes(size)
+	}
+}
+
+// Failed implements RelayCall.
+func (tc *relayTracedCall) Failed(reason string) {
+	tc.failure.Store(reason)
+	if tc.RelayCall != nil {
+		tc.RelayCall.Failed(reason)
+	}
+}
+
+// End implements RelayCall, and finishes the relay span.
+func (tc *relayTracedCall) End() {
+	if tc.RelayCall != nil {
+		tc.RelayCall.End()
+	}
+
+	now := tc.timeNow()
+	failure := tc.failure.Load()
+	if span := tc.otelSpan; span != nil {
+		span.SetAttributes(
+			attribute.Int64("relay.request_frames", int64(tc.requestFrames.Load())),
+			attribute.Int64("relay.response_frames", int64(tc.responseFrames.Load())),
+			attribute.Int64("relay.request_bytes", int64(tc.requestBytes.Load())),
+			attribute.Int64("relay.response_bytes", int64(tc.responseBytes.Load())),
+		)
+		if failure != "" {
+			span.SetAttributes(attribute.String("relay.failure", failure))
+			span.SetStatus(codes.Error, failure)
+		}
+		span.End(trace.WithTimestamp(now))
+		return
+	}
+
+	span := tc.otSpan
+	span.SetTag("relay.request_frames", tc.requestFrames.Load())
+	span.SetTag("relay.response_frames", tc.responseFrames.Load())
+	span.SetTag("relay.request_bytes", tc.requestBytes.Load())
+	span.SetTag("relay.response_bytes", tc.responseBytes.Load())
+	if failure != "" {
+		span.SetTag("relay.failure", failure)
+		ext.Error.Set(span, true)
+	}
+	span.FinishWithOptions(opentracing.FinishOptions{FinishTime: now})
+}
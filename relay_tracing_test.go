@@ -0,0 +1,163 @@
+// Copyright (c) 2015 Uber Technologies, Inc.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package tchannel_test
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/uber/tchannel-go"
+	"github.com/uber/tchannel-go/json"
+	"github.com/uber/tchannel-go/raw"
+	"github.com/uber/tchannel-go/testutils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/net/context"
+)
+
+func relayTracingOpts() (*testutils.ChannelOpts, *tracetest.SpanRecorder) {
+	recorder := tracetest.NewSpanRecorder()
+	opts := testutils.NewOpts().SetRelayOnly()
+	opts.TracerProvider = sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	opts.RelayTracing = true
+	return opts, recorder
+}
+
+// waitForRelaySpans waits for the client, relay and server spans after the first skip spans.
+func waitForRelaySpans(t testing.TB, recorder *tracetest.SpanRecorder, skip int) map[trace.SpanKind]sdktrace.ReadOnlySpan {
+	spans := make(map[trace.SpanKind]sdktrace.ReadOnlySpan)
+	for _, s := range waitForEndedSpans(t, recorder, skip, 3) {
+		spans[s.SpanKind()] = s
+	}
+	require.Len(t, spans, 3, "Expected client, relay and server spans")
+	return spans
+}
+
+func TestRelayTracingRaw(t *testing.T) {
+	opts, recorder := relayTracingOpts()
+	testutils.WithTestServer(t, opts, func(t testing.TB, ts *testutils.TestServer) {
+		// Spans from previous runs of the test are recorded by the same recorder.
+		skip := len(recorder.Ended())
+		testutils.RegisterEcho(ts.Server(), nil)
+		client := ts.NewClient(opts)
+
+		ctx, cancel := NewContext(time.Second)
+		defer cancel()
+		_, _, _, err := raw.Call(ctx, client, ts.HostPort(), ts.ServiceName(), "echo", []byte("arg2"), []byte("arg3"))
+		require.NoError(t, err, "Call failed")
+
+		spans := waitForRelaySpans(t, recorder, skip)
+		clientSpan := spans[trace.SpanKindClient]
+		relaySpan := spans[trace.SpanKindInternal]
+		serverSpan := spans[trace.SpanKindServer]
+
+		assert.Equal(t, clientSpan.SpanContext().SpanID(), relaySpan.Parent().SpanID(), "Relay span parent should be client span")
+		assert.Equal(t, relaySpan.SpanContext().SpanID(), serverSpan.Parent().SpanID(), "Server span parent should be relay span")
+		assert.Equal(t, relaySpan.SpanContext().TraceID(), serverSpan.SpanContext().TraceID(), "Relay and server spans should be in the same trace")
+
+		assert.Equal(t, "echo", relaySpan.Name(), "Unexpected relay span name")
+		assert.Equal(t, ts.Server().PeerInfo().HostPort, otelAttr(relaySpan, "relay.destination").AsString(), "Unexpected destination")
+		assert.True(t, otelAttr(relaySpan, "relay.peer_selection_time_us").AsInt64() >= 0, "Missing peer selection time")
+		assert.EqualValues(t, 1, otelAttr(relaySpan, "relay.request_frames").AsInt64(), "Unexpected request frames")
+		assert.EqualValues(t, 1, otelAttr(relaySpan, "relay.response_frames").AsInt64(), "Unexpected response frames")
+		assert.True(t, otelAttr(relaySpan, "relay.request_bytes").AsInt64() > 0, "Missing request bytes")
+		assert.True(t, otelAttr(relaySpan, "relay.response_bytes").AsInt64() > 0, "Missing response bytes")
+		assert.Equal(t, codes.Unset, relaySpan.Status().Code, "Unexpected relay span status")
+	})
+}
+
+func TestRelayTracingJSON(t *testing.T) {
+	opts, recorder := relayTracingOpts()
+	testutils.WithTestServer(t, opts, func(t testing.TB, ts *testutils.TestServer) {
+		skip := len(recorder.Ended())
+		handler := func(ctx json.Context, req map[string]string) (map[string]string, error) {
+			return req, nil
+		}
+		json.Register(ts.Server(), json.Handlers{"call": handler}, nil)
+		client := ts.NewClient(opts)
+
+		ctx, cancel := json.NewContext(time.Second)
+		defer cancel()
+		var res map[string]string
+		peer := client.Peers().GetOrAdd(ts.HostPort())
+		require.NoError(t, json.CallPeer(ctx, peer, ts.ServiceName(), "call", map[string]string{}, &res), "Call failed")
+
+		spans := waitForRelaySpans(t, recorder, skip)
+		clientSpan := spans[trace.SpanKindClient]
+		relaySpan := spans[trace.SpanKindInternal]
+		serverSpan := spans[trace.SpanKindServer]
+
+		// The full trace ID comes from the headers, while the relay span is the parent.
+		assert.Equal(t, clientSpan.SpanContext().TraceID(), serverSpan.SpanContext().TraceID(), "Server span should be in the client's trace")
+		assert.Equal(t, relaySpan.SpanContext().SpanID(), serverSpan.Parent().SpanID(), "Server span parent should be relay span")
+	})
+}
+
+func TestRelayTracingFailure(t *testing.T) {
+	opts, recorder := relayTracingOpts()
+	opts.AddLogFilter("Failed to connect to relay host.", 1)
+	testutils.WithTestServer(t, opts, func(t testing.TB, ts *testutils.TestServer) {
+		skip := len(recorder.Ended())
+		ts.RelayHost().Add("unreachable", testutils.GetClosedHostPort(t))
+		client := ts.NewClient(opts)
+
+		ctx, cancel := NewContext(time.Second)
+		defer cancel()
+		_, _, _, err := raw.Call(ctx, client, ts.HostPort(), "unreachable", "echo", nil, nil)
+		require.Error(t, err, "Call to unreachable service should fail")
+
+		var relaySpan sdktrace.ReadOnlySpan
+		require.True(t, testutils.WaitFor(time.Second, func() bool {
+			for _, s := range recorder.Ended()[skip:] {
+				if s.SpanKind() == trace.SpanKindInternal {
+					relaySpan = s
+				}
+			}
+			return relaySpan != nil
+		}), "Timed out waiting for relay span")
+
+		assert.Equal(t, "relay-connection-failed", otelAttr(relaySpan, "relay.failure").AsString(), "Unexpected failure reason")
+		assert.Equal(t, codes.Error, relaySpan.Status().Code, "Failed relay span should have an error status")
+	})
+}
+
+func TestRelayTracingDisabled(t *testing.T) {
+	opts, recorder := relayTracingOpts()
+	opts.RelayTracing = false
+	testutils.WithTestServer(t, opts, func(t testing.TB, ts *testutils.TestServer) {
+		skip := len(recorder.Ended())
+		testutils.RegisterEcho(ts.Server(), nil)
+		client := ts.NewClient(opts)
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		_, _, _, err := raw.Call(ctx, client, ts.HostPort(), ts.ServiceName(), "echo", nil, nil)
+		require.NoError(t, err, "Call failed")
+
+		clientSpan, serverSpan := waitForOTelSpans(t, recorder, skip)
+		assert.Equal(t, clientSpan.SpanContext().SpanID(), serverSpan.Parent().SpanID(), "Server span parent should be client span")
+	})
+}
@@ -73,6 +73,9 @@ type reqResWriter struct {
 	messageForFragment messageForFragment
 	log                Logger
 	err                error
+
+	// bytesWritten is the total payload size of the frames sent.
+	bytesWritten int64
 }
 
 //go:generate stringer -type=reqResReaderState
@@ -143,6 +146,7 @@ func (w *reqResWriter) flushFragment(fragment *writableFragment) error {
 
 	frame := fragment.frame
 	frame.Header.SetPayloadSize(uint16(fragment.contents.BytesWritten()))
+	payloadSize := int64(frame.Header.PayloadSize())
 
 	if err := w.mex.checkError(); err != nil {
 		return w.failed(err)
@@ -153,6 +157,7 @@ func (w *reqResWriter) flushFragment(fragment *writableFragment) error {
 	case <-w.mex.errCh.c:
 		return w.failed(w.mex.errCh.err)
 	case w.conn.sendCh <- frame:
+		w.bytesWritten += payloadSize
 		return nil
 	}
 }
@@ -189,6 +194,9 @@ type reqResReader struct {
 	previousFragment   *readableFragment
 	log                Logger
 	err                error
+
+	// bytesRead is the total payload size of the frames received.
+	bytesRead int64
 }
 
 // arg1Reader returns an ArgReader to read arg1.
@@ -244,6 +252,7 @@ func (r *reqResReader) recvNextFragment(initial bool) (*readableFragment, error)
 
 		return nil, r.failed(err)
 	}
+	r.bytesRead += int64(frame.Header.PayloadSize())
 
 	// Parse the message and setup the fragment
 	fragment, err := parseInboundFragment(r.mex.framePool, frame, message)
@@ -210,9 +210,26 @@ func (rs *RequestState) RetryCount() int {
 // RunWithRetry will take a function that makes the TChannel call, and will
 // rerun it as specifed in the RetryOptions in the Context.
 func (ch *Channel) RunWithRetry(runCtx context.Context, f RetriableFunc) error {
+	return ch.runWithRetry(runCtx, getRetryOptions(runCtx), f)
+}
+
+// RunWithDefaultRetry is similar to RunWithRetry, but uses the given retry
+// options if the Context does not specify any RetryOptions.
+func (ch *Channel) RunWithDefaultRetry(runCtx context.Context, defaults *RetryOptions, f RetriableFunc) error {
+	if params := getTChannelParams(runCtx); (params != nil && params.retryOptions != nil) || defaults == nil {
+		return ch.RunWithRetry(runCtx, f)
+	}
+
+	opts := *defaults
+	if opts.MaxAttempts == 0 {
+		opts.MaxAttempts = defaultRetryOptions.MaxAttempts
+	}
+	return ch.runWithRetry(runCtx, &opts, f)
+}
+
+func (ch *Channel) runWithRetry(runCtx context.Context, opts *RetryOptions, f RetriableFunc) error {
 	var err error
 
-	opts := getRetryOptions(runCtx)
 	rs := ch.getRequestState(opts)
 	defer requestStatePool.Put(rs)
 
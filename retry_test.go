@@ -164,6 +164,51 @@ func TestRetryTillMaxAttempts(t *testing.T) {
 	}
 }
 
+func TestRunWithDefaultRetry(t *testing.T) {
+	ch := testutils.NewClient(t, nil)
+	defer ch.Close()
+
+	defaults := &RetryOptions{RetryOn: RetryNever}
+	tests := []struct {
+		msg         string
+		retryOpts   *RetryOptions
+		defaults    *RetryOptions
+		wantCounter int
+	}{
+		{
+			msg:         "no defaults",
+			wantCounter: 5,
+		},
+		{
+			msg:         "defaults used if context has no retry options",
+			defaults:    defaults,
+			wantCounter: 1,
+		},
+		{
+			msg:         "defaults with max attempts",
+			defaults:    &RetryOptions{MaxAttempts: 2},
+			wantCounter: 2,
+		},
+		{
+			msg:         "context retry options override defaults",
+			retryOpts:   &RetryOptions{MaxAttempts: 3},
+			defaults:    defaults,
+			wantCounter: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		ctx, cancel := NewContextBuilder(time.Second).SetRetryOptions(tt.retryOpts).Build()
+		defer cancel()
+
+		f, counter := createFuncToRetry(t, ErrServerBusy, ErrServerBusy, ErrServerBusy, ErrServerBusy, ErrServerBusy)
+		err := ch.RunWithDefaultRetry(ctx, tt.defaults, f)
+		assert.Equal(t, ErrServerBusy, err, "%v: unexpected error", tt.msg)
+		assert.Equal(t, tt.wantCounter, *counter, "%v: unexpected number of attempts", tt.msg)
+	}
+	assert.Equal(t, 0, defaults.MaxAttempts, "Defaults should not be modified")
+}
+
 func TestRetrySubContextNoTimeoutPerAttempt(t *testing.T) {
 	e := getTestErrors()
 	ctx, cancel := NewContext(time.Second)
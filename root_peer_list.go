@@ -20,7 +20,10 @@
 
 package tchannel
 
-import "sync"
+import (
+	"sync"
+	"time"
+)
 
 // RootPeerList is the root peer list which is only used to connect to
 // peers and share peers between subchannels.
@@ -30,13 +33,27 @@ type RootPeerList struct {
 	channel             Connectable
 	onPeerStatusChanged func(*Peer)
 	peersByHostPort     map[string]*Peer
+	peerOpts            peerOptions
 }
 
-func newRootPeerList(ch Connectable, onPeerStatusChanged func(*Peer)) *RootPeerList {
+// peerOptions are the options used by every peer in a RootPeerList.
+type peerOptions struct {
+	// connsPerPeer and connSelection are set from the channel's ConnectionOptions.
+	connsPerPeer  int
+	connSelection ConnectionSelection
+
+	timeNow func() time.Time
+}
+
+func newRootPeerList(ch Connectable, onPeerStatusChanged func(*Peer), peerOpts peerOptions) *RootPeerList {
+	if peerOpts.timeNow == nil {
+		peerOpts.timeNow = time.Now
+	}
 	return &RootPeerList{
 		channel:             ch,
 		onPeerStatusChanged: onPeerStatusChanged,
 		peersByHostPort:     make(map[string]*Peer),
+		peerOpts:            peerOpts,
 	}
 }
 
@@ -68,6 +85,7 @@ func (l *RootPeerList) Add(hostPort string) *Peer {
 	// To avoid duplicate connections, only the root list should create new
 	// peers. All other lists should keep refs to the root list's peers.
 	p = newPeer(l.channel, hostPort, l.onPeerStatusChanged, l.onClosedConnRemoved)
+	p.opts = l.peerOpts
 	l.peersByHostPort[hostPort] = p
 	return p
 }
@@ -103,6 +121,7 @@ func (l *RootPeerList) onClosedConnRemoved(peer *Peer) {
 		l.Lock()
 		delete(l.peersByHostPort, hostPort)
 		l.Unlock()
+		p.stopMinConnections()
 		l.channel.Logger().WithFields(
 			LogField{"remoteHostPort", hostPort},
 		).Debug("Removed peer from root peer list.")
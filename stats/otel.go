@@ -0,0 +1,185 @@
+// Copyright (c) 2015 Uber Technologies, Inc.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package stats
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/uber/tchannel-go"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+type otelReporter struct {
+	sync.RWMutex
+
+	meter    metric.Meter
+	counters map[string]metric.Int64Counter
+	gauges   map[string]*otelGauge
+	timers   map[string]metric.Float64Histogram
+}
+
+// otelGauge stores the last value reported for each set of tags, which is
+// observed when the metrics are collected.
+type otelGauge struct {
+	sync.Mutex
+
+	values map[attribute.Distinct]otelGaugeValue
+}
+
+type otelGaugeValue struct {
+	attrs attribute.Set
+	value int64
+}
+
+// NewOTelReporter takes an OpenTelemetry MeterProvider and wraps it so it can
+// be used as a StatsReporter. Counters are reported as Int64Counters, gauges as
+// Int64ObservableGauges, and timers as Float64Histograms in seconds. All tags are
+// reported as attributes. The list of metrics emitted is documented on:
+// https://tchannel.readthedocs.io/en/latest/metrics/
+func NewOTelReporter(provider metric.MeterProvider) tchannel.StatsReporter {
+	return &otelReporter{
+		meter:    provider.Meter("github.com/uber/tchannel-go", metric.WithInstrumentationVersion(tchannel.VersionInfo)),
+		counters: make(map[string]metric.Int64Counter),
+		gauges:   make(map[string]*otelGauge),
+		timers:   make(map[string]metric.Float64Histogram),
+	}
+}
+
+func (r *otelReporter) IncCounter(name string, tags map[string]string, value int64) {
+	if counter := r.getCounter(name); counter != nil {
+		counter.Add(context.Background(), value, metric.WithAttributeSet(otelAttributes(tags)))
+	}
+}
+
+func (r *otelReporter) UpdateGauge(name string, tags map[string]string, value int64) {
+	if gauge := r.getGauge(name); gauge != nil {
+		gauge.update(otelAttributes(tags), value)
+	}
+}
+
+func (r *otelReporter) RecordTimer(name string, tags map[string]string, d time.Duration) {
+	if timer := r.getTimer(name); timer != nil {
+		timer.Record(context.Background(), d.Seconds(), metric.WithAttributeSet(otelAttributes(tags)))
+	}
+}
+
+func otelAttributes(tags map[string]string) attribute.Set {
+	attrs := make([]attribute.KeyValue, 0, len(tags))
+	for k, v := range tags {
+		attrs = append(attrs, attribute.String(k, v))
+	}
+	return attribute.NewSet(attrs...)
+}
+
+func (r *otelReporter) getCounter(name string) metric.Int64Counter {
+	r.RLock()
+	counter, ok := r.counters[name]
+	r.RUnlock()
+	if ok {
+		return counter
+	}
+
+	r.Lock()
+	defer r.Unlock()
+
+	// Always double-check under the write-lock, as the meter may not allow
+	// registering the same instrument multiple times.
+	if counter, ok := r.counters[name]; ok {
+		return counter
+	}
+
+	counter, err := r.meter.Int64Counter(name)
+	if err != nil {
+		otel.Handle(err)
+		counter = nil
+	}
+	r.counters[name] = counter
+	return counter
+}
+
+func (r *otelReporter) getGauge(name string) *otelGauge {
+	r.RLock()
+	gauge, ok := r.gauges[name]
+	r.RUnlock()
+	if ok {
+		return gauge
+	}
+
+	r.Lock()
+	defer r.Unlock()
+
+	if gauge, ok := r.gauges[name]; ok {
+		return gauge
+	}
+
+	gauge = &otelGauge{values: make(map[attribute.Distinct]otelGaugeValue)}
+	if _, err := r.meter.Int64ObservableGauge(name, metric.WithInt64Callback(gauge.observe)); err != nil {
+		otel.Handle(err)
+		gauge = nil
+	}
+	r.gauges[name] = gauge
+	return gauge
+}
+
+func (r *otelReporter) getTimer(name string) metric.Float64Histogram {
+	r.RLock()
+	timer, ok := r.timers[name]
+	r.RUnlock()
+	if ok {
+		return timer
+	}
+
+	r.Lock()
+	defer r.Unlock()
+
+	if timer, ok := r.timers[name]; ok {
+		return timer
+	}
+
+	timer, err := r.meter.Float64Histogram(name, metric.WithUnit("s"))
+	if err != nil {
+		otel.Handle(err)
+		timer = nil
+	}
+	r.timers[name] = timer
+	return timer
+}
+
+func (g *otelGauge) update(attrs attribute.Set, value int64) {
+	g.Lock()
+	g.values[attrs.Equivalent()] = otelGaugeValue{attrs: attrs, value: value}
+	g.Unlock()
+}
+
+func (g *otelGauge) observe(_ context.Context, o metric.Int64Observer) error {
+	g.Lock()
+	defer g.Unlock()
+
+	for _, v := range g.values {
+		o.Observe(v.value, metric.WithAttributeSet(v.attrs))
+	}
+	return nil
+}
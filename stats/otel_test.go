@@ -0,0 +1,88 @@
+// Copyright (c) 2015 Uber Technologies, Inc.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package stats
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func collectOTel(t *testing.T, reader sdkmetric.Reader) map[string]metricdata.Aggregation {
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm), "Collect failed")
+
+	metrics := make(map[string]metricdata.Aggregation)
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			metrics[m.Name] = m.Data
+		}
+	}
+	return metrics
+}
+
+func TestOTelReporter(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	r := NewOTelReporter(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+
+	tags := map[string]string{
+		"service":        "foo",
+		"target-service": "bar",
+	}
+	wantAttrs := attribute.NewSet(
+		attribute.String("service", "foo"),
+		attribute.String("target-service", "bar"),
+	)
+
+	r.IncCounter("outbound.calls.send", tags, 1)
+	r.IncCounter("outbound.calls.send", tags, 2)
+	r.UpdateGauge("gauge", tags, 5)
+	r.UpdateGauge("gauge", tags, 3)
+	r.RecordTimer("outbound.calls.latency", tags, 250*time.Millisecond)
+	r.RecordTimer("outbound.calls.latency", tags, 750*time.Millisecond)
+
+	metrics := collectOTel(t, reader)
+
+	counter, ok := metrics["outbound.calls.send"].(metricdata.Sum[int64])
+	require.True(t, ok, "Unexpected counter data: %v", metrics["outbound.calls.send"])
+	require.Len(t, counter.DataPoints, 1, "Unexpected counter data points")
+	assert.Equal(t, int64(3), counter.DataPoints[0].Value, "Unexpected counter value")
+	assert.Equal(t, wantAttrs, counter.DataPoints[0].Attributes, "Unexpected counter attributes")
+
+	gauge, ok := metrics["gauge"].(metricdata.Gauge[int64])
+	require.True(t, ok, "Unexpected gauge data: %v", metrics["gauge"])
+	require.Len(t, gauge.DataPoints, 1, "Unexpected gauge data points")
+	assert.Equal(t, int64(3), gauge.DataPoints[0].Value, "Gauge should report the last value")
+	assert.Equal(t, wantAttrs, gauge.DataPoints[0].Attributes, "Unexpected gauge attributes")
+
+	timer, ok := metrics["outbound.calls.latency"].(metricdata.Histogram[float64])
+	require.True(t, ok, "Unexpected timer data: %v", metrics["outbound.calls.latency"])
+	require.Len(t, timer.DataPoints, 1, "Unexpected timer data points")
+	assert.Equal(t, uint64(2), timer.DataPoints[0].Count, "Unexpected timer count")
+	assert.InDelta(t, 1.0, timer.DataPoints[0].Sum, 1e-9, "Timer should be recorded in seconds")
+	assert.Equal(t, wantAttrs, timer.DataPoints[0].Attributes, "Unexpected timer attributes")
+}
@@ -0,0 +1,316 @@
+// Copyright (c) 2015 Uber Technologies, Inc.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package stats
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/uber/tchannel-go"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// _prometheusOtherValue replaces label values once a label reaches MaxLabelValues.
+const _prometheusOtherValue = "other"
+
+// DefaultPrometheusLabels are the tags reported as labels by default. They
+// identify the caller, callee and method for inbound and outbound calls.
+var DefaultPrometheusLabels = []string{
+	"service",
+	"target-service",
+	"target-endpoint",
+	"calling-service",
+	"endpoint",
+	"retry-count",
+}
+
+// PrometheusOptions are options for the Prometheus StatsReporter.
+type PrometheusOptions struct {
+	// Registry that metrics are registered with. If nil, a new registry is created.
+	Registry *prometheus.Registry
+
+	// Namespace is prefixed to all metric names, and defaults to "tchannel".
+	Namespace string
+
+	// Labels is the list of tags that are reported as labels. All other tags
+	// are dropped. Defaults to DefaultPrometheusLabels.
+	Labels []string
+
+	// MaxLabelValues limits the number of distinct values for each label.
+	// Once the limit is reached, new values are reported as "other".
+	// If zero, there is no limit.
+	MaxLabelValues int
+
+	// Buckets are the histogram buckets for timers, in seconds.
+	// Defaults to prometheus.DefBuckets.
+	Buckets []float64
+
+	// Logger is used to log metrics that cannot be registered, such as when
+	// the name conflicts with an existing metric in the registry. Those
+	// metrics are not reported. Defaults to tchannel.NullLogger.
+	Logger tchannel.Logger
+}
+
+// PrometheusReporter is a StatsReporter that exposes metrics as Prometheus collectors.
+// Counters are reported with a "_total" suffix, and timers as histograms with a
+// "_seconds" suffix. Metric names and labels have unsupported characters replaced
+// with "_", so "outbound.calls.latency" is reported as "tchannel_outbound_calls_latency_seconds".
+type PrometheusReporter struct {
+	sync.RWMutex
+
+	opts       PrometheusOptions
+	labelNames []string
+
+	labelMut    sync.RWMutex
+	labelValues []map[string]struct{}
+
+	counters map[string]*prometheus.CounterVec
+	gauges   map[string]*prometheus.GaugeVec
+	timers   map[string]*prometheus.HistogramVec
+}
+
+var _ tchannel.StatsReporter = (*PrometheusReporter)(nil)
+
+// NewPrometheusReporter returns a StatsReporter that reports metrics to a Prometheus registry.
+func NewPrometheusReporter(opts PrometheusOptions) *PrometheusReporter {
+	if opts.Registry == nil {
+		opts.Registry = prometheus.NewRegistry()
+	}
+	if opts.Namespace == "" {
+		opts.Namespace = "tchannel"
+	}
+	if opts.Labels == nil {
+		opts.Labels = DefaultPrometheusLabels
+	}
+	if opts.Buckets == nil {
+		opts.Buckets = prometheus.DefBuckets
+	}
+	if opts.Logger == nil {
+		opts.Logger = tchannel.NullLogger
+	}
+
+	r := &PrometheusReporter{
+		opts:        opts,
+		labelNames:  make([]string, len(opts.Labels)),
+		labelValues: make([]map[string]struct{}, len(opts.Labels)),
+		counters:    make(map[string]*prometheus.CounterVec),
+		gauges:      make(map[string]*prometheus.GaugeVec),
+		timers:      make(map[string]*prometheus.HistogramVec),
+	}
+	for i, label := range opts.Labels {
+		r.labelNames[i] = prometheusName(label)
+		r.labelValues[i] = make(map[string]struct{})
+	}
+	return r
+}
+
+// Registry returns the registry that metrics are registered with.
+func (r *PrometheusReporter) Registry() *prometheus.Registry {
+	return r.opts.Registry
+}
+
+// Handler returns an HTTP handler that serves the metrics in the registry.
+func (r *PrometheusReporter) Handler() http.Handler {
+	return promhttp.HandlerFor(r.opts.Registry, promhttp.HandlerOpts{})
+}
+
+// IncCounter implements tchannel.StatsReporter.
+func (r *PrometheusReporter) IncCounter(name string, tags map[string]string, value int64) {
+	if counter := r.getCounter(name); counter != nil {
+		counter.WithLabelValues(r.labels(tags)...).Add(float64(value))
+	}
+}
+
+// UpdateGauge implements tchannel.StatsReporter.
+func (r *PrometheusReporter) UpdateGauge(name string, tags map[string]string, value int64) {
+	if gauge := r.getGauge(name); gauge != nil {
+		gauge.WithLabelValues(r.labels(tags)...).Set(float64(value))
+	}
+}
+
+// RecordTimer implements tchannel.StatsReporter.
+func (r *PrometheusReporter) RecordTimer(name string, tags map[string]string, d time.Duration) {
+	if timer := r.getTimer(name); timer != nil {
+		timer.WithLabelValues(r.labels(tags)...).Observe(d.Seconds())
+	}
+}
+
+// labels returns the label values for the tags, applying MaxLabelValues.
+func (r *PrometheusReporter) labels(tags map[string]string) []string {
+	values := make([]string, len(r.opts.Labels))
+	for i, label := range r.opts.Labels {
+		values[i] = tags[label]
+	}
+	if r.opts.MaxLabelValues <= 0 {
+		return values
+	}
+
+	// Most values have been seen before, so check them under the read lock,
+	// and only take the write lock to add new values.
+	var add []int
+	r.labelMut.RLock()
+	for i, v := range values {
+		seen := r.labelValues[i]
+		if _, ok := seen[v]; ok {
+			continue
+		}
+		if len(seen) >= r.opts.MaxLabelValues {
+			values[i] = _prometheusOtherValue
+			continue
+		}
+		add = append(add, i)
+	}
+	r.labelMut.RUnlock()
+	if len(add) == 0 {
+		return values
+	}
+
+	r.labelMut.Lock()
+	defer r.labelMut.Unlock()
+
+	for _, i := range add {
+		seen := r.labelValues[i]
+		if _, ok := seen[values[i]]; ok {
+			continue
+		}
+		if len(seen) >= r.opts.MaxLabelValues {
+			values[i] = _prometheusOtherValue
+			continue
+		}
+		seen[values[i]] = struct{}{}
+	}
+	return values
+}
+
+// register registers the collector, returning false if it could not be
+// registered, such as when the name conflicts with an existing metric.
+// Failures are logged, since StatsReporter methods cannot return errors.
+func (r *PrometheusReporter) register(name string, c prometheus.Collector) bool {
+	if err := r.opts.Registry.Register(c); err != nil {
+		r.opts.Logger.WithFields(
+			tchannel.LogField{Key: "metric", Value: name},
+			tchannel.ErrField(err),
+		).Warn("Failed to register Prometheus metric, it will not be reported.")
+		return false
+	}
+	return true
+}
+
+func (r *PrometheusReporter) getCounter(name string) *prometheus.CounterVec {
+	r.RLock()
+	counter, ok := r.counters[name]
+	r.RUnlock()
+	if ok {
+		return counter
+	}
+
+	r.Lock()
+	defer r.Unlock()
+
+	// Always double-check under the write-lock, as collectors can only
+	// be registered once.
+	if counter, ok := r.counters[name]; ok {
+		return counter
+	}
+
+	counter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: r.opts.Namespace,
+		Name:      prometheusName(name) + "_total",
+		Help:      "TChannel counter " + name,
+	}, r.labelNames)
+	if !r.register(name, counter) {
+		counter = nil
+	}
+	r.counters[name] = counter
+	return counter
+}
+
+func (r *PrometheusReporter) getGauge(name string) *prometheus.GaugeVec {
+	r.RLock()
+	gauge, ok := r.gauges[name]
+	r.RUnlock()
+	if ok {
+		return gauge
+	}
+
+	r.Lock()
+	defer r.Unlock()
+
+	if gauge, ok := r.gauges[name]; ok {
+		return gauge
+	}
+
+	gauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: r.opts.Namespace,
+		Name:      prometheusName(name),
+		Help:      "TChannel gauge " + name,
+	}, r.labelNames)
+	if !r.register(name, gauge) {
+		gauge = nil
+	}
+	r.gauges[name] = gauge
+	return gauge
+}
+
+func (r *PrometheusReporter) getTimer(name string) *prometheus.HistogramVec {
+	r.RLock()
+	timer, ok := r.timers[name]
+	r.RUnlock()
+	if ok {
+		return timer
+	}
+
+	r.Lock()
+	defer r.Unlock()
+
+	if timer, ok := r.timers[name]; ok {
+		return timer
+	}
+
+	timer = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: r.opts.Namespace,
+		Name:      prometheusName(name) + "_seconds",
+		Help:      "TChannel timer " + name,
+		Buckets:   r.opts.Buckets,
+	}, r.labelNames)
+	if !r.register(name, timer) {
+		timer = nil
+	}
+	r.timers[name] = timer
+	return timer
+}
+
+// prometheusName replaces characters that are not valid in Prometheus
+// metric and label names with "_".
+func prometheusName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+}
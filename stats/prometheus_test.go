@@ -0,0 +1,157 @@
+// Copyright (c) 2015 Uber Technologies, Inc.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package stats
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/uber/tchannel-go"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrometheusReporter(t *testing.T) {
+	r := NewPrometheusReporter(PrometheusOptions{
+		Buckets: []float64{0.1, 1},
+	})
+
+	outboundTags := map[string]string{
+		"app":             "svc-app",
+		"service":         "svc",
+		"target-service":  "target",
+		"target-endpoint": "method",
+	}
+	r.IncCounter("outbound.calls.send", outboundTags, 1)
+	r.IncCounter("outbound.calls.send", outboundTags, 2)
+	r.UpdateGauge("outbound.pending", outboundTags, 5)
+	r.RecordTimer("outbound.calls.latency", outboundTags, 50*time.Millisecond)
+	r.RecordTimer("outbound.calls.latency", outboundTags, 500*time.Millisecond)
+
+	const want = `
+# HELP tchannel_outbound_calls_latency_seconds TChannel timer outbound.calls.latency
+# TYPE tchannel_outbound_calls_latency_seconds histogram
+tchannel_outbound_calls_latency_seconds_bucket{calling_service="",endpoint="",retry_count="",service="svc",target_endpoint="method",target_service="target",le="0.1"} 1
+tchannel_outbound_calls_latency_seconds_bucket{calling_service="",endpoint="",retry_count="",service="svc",target_endpoint="method",target_service="target",le="1"} 2
+tchannel_outbound_calls_latency_seconds_bucket{calling_service="",endpoint="",retry_count="",service="svc",target_endpoint="method",target_service="target",le="+Inf"} 2
+tchannel_outbound_calls_latency_seconds_sum{calling_service="",endpoint="",retry_count="",service="svc",target_endpoint="method",target_service="target"} 0.55
+tchannel_outbound_calls_latency_seconds_count{calling_service="",endpoint="",retry_count="",service="svc",target_endpoint="method",target_service="target"} 2
+# HELP tchannel_outbound_calls_send_total TChannel counter outbound.calls.send
+# TYPE tchannel_outbound_calls_send_total counter
+tchannel_outbound_calls_send_total{calling_service="",endpoint="",retry_count="",service="svc",target_endpoint="method",target_service="target"} 3
+# HELP tchannel_outbound_pending TChannel gauge outbound.pending
+# TYPE tchannel_outbound_pending gauge
+tchannel_outbound_pending{calling_service="",endpoint="",retry_count="",service="svc",target_endpoint="method",target_service="target"} 5
+`
+	assert.NoError(t, testutil.GatherAndCompare(r.Registry(), strings.NewReader(want)), "Unexpected metrics")
+
+	// The handler serves the same metrics.
+	rec := httptest.NewRecorder()
+	r.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body, err := io.ReadAll(rec.Body)
+	require.NoError(t, err, "Failed to read response")
+	assert.Contains(t, string(body), `tchannel_outbound_calls_send_total{calling_service="",endpoint="",retry_count="",service="svc",target_endpoint="method",target_service="target"} 3`)
+}
+
+func TestPrometheusReporterMaxLabelValues(t *testing.T) {
+	r := NewPrometheusReporter(PrometheusOptions{
+		Namespace:      "test",
+		Labels:         []string{"endpoint"},
+		MaxLabelValues: 2,
+	})
+
+	for _, endpoint := range []string{"a", "b", "c", "d", "a"} {
+		r.IncCounter("inbound.calls.recvd", map[string]string{"endpoint": endpoint}, 1)
+	}
+
+	const want = `
+# HELP test_inbound_calls_recvd_total TChannel counter inbound.calls.recvd
+# TYPE test_inbound_calls_recvd_total counter
+test_inbound_calls_recvd_total{endpoint="a"} 2
+test_inbound_calls_recvd_total{endpoint="b"} 1
+test_inbound_calls_recvd_total{endpoint="other"} 2
+`
+	assert.NoError(t, testutil.GatherAndCompare(r.Registry(), strings.NewReader(want)), "Unexpected metrics")
+}
+
+func TestPrometheusReporterMaxLabelValuesConcurrent(t *testing.T) {
+	r := NewPrometheusReporter(PrometheusOptions{
+		Labels:         []string{"endpoint"},
+		MaxLabelValues: 5,
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				r.IncCounter("inbound.calls.recvd", map[string]string{"endpoint": fmt.Sprint((i + j) % 10)}, 1)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	families, err := r.Registry().Gather()
+	require.NoError(t, err, "Gather failed")
+	require.Len(t, families, 1, "Expected a single metric")
+	var total float64
+	for _, m := range families[0].GetMetric() {
+		total += m.GetCounter().GetValue()
+	}
+	assert.Len(t, families[0].GetMetric(), 6, "Expected 5 label values and other")
+	assert.EqualValues(t, 1000, total, "Unexpected total calls")
+}
+
+func TestPrometheusReporterRegisterError(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	conflict := prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "tchannel_outbound_calls_send_total",
+		Help: "Conflicting counter",
+	})
+	require.NoError(t, registry.Register(conflict), "Register failed")
+
+	var logs bytes.Buffer
+	r := NewPrometheusReporter(PrometheusOptions{
+		Registry: registry,
+		Logger:   tchannel.NewLogger(&logs),
+	})
+	r.IncCounter("outbound.calls.send", nil, 1)
+	r.IncCounter("outbound.calls.send", nil, 1)
+
+	assert.Equal(t, 1, strings.Count(logs.String(), "Failed to register Prometheus metric"),
+		"Expected a single log for the registration failure: %v", logs.String())
+	assert.Contains(t, logs.String(), "outbound.calls.send", "Log should include the metric name")
+}
+
+func TestPrometheusName(t *testing.T) {
+	assert.Equal(t, "outbound_calls_per_attempt_latency", prometheusName("outbound.calls.per-attempt.latency"))
+	assert.Equal(t, "target_service", prometheusName("target-service"))
+}
@@ -41,6 +41,9 @@ type statsValue struct {
 
 	// timers is the list of timer values if this metrics is a timer.
 	timers []time.Duration
+
+	// gauge is the last value if this metric is a gauge.
+	gauge int64
 }
 
 type recordingStatsReporter struct {
@@ -105,12 +108,16 @@ func (r *recordingStatsReporter) getStat(name string, tags map[string]string) *s
 
 func (r *recordingStatsReporter) IncCounter(name string, tags map[string]string, value int64) {
 	statVal := r.getStat(name, tags)
+	r.Lock()
 	statVal.count += value
+	r.Unlock()
 }
 
 func (r *recordingStatsReporter) RecordTimer(name string, tags map[string]string, d time.Duration) {
 	statVal := r.getStat(name, tags)
+	r.Lock()
 	statVal.timers = append(statVal.timers, d)
+	r.Unlock()
 }
 
 func (r *recordingStatsReporter) Reset() {
@@ -145,4 +152,9 @@ func (r *recordingStatsReporter) Validate(t *testing.T) {
 	}
 }
 
-func (r *recordingStatsReporter) UpdateGauge(name string, tags map[string]string, value int64) {}
+func (r *recordingStatsReporter) UpdateGauge(name string, tags map[string]string, value int64) {
+	statVal := r.getStat(name, tags)
+	r.Lock()
+	statVal.gauge = value
+	r.Unlock()
+}
@@ -25,6 +25,7 @@ import (
 	"sync"
 
 	"github.com/opentracing/opentracing-go"
+	"go.uber.org/atomic"
 	"golang.org/x/net/context"
 )
 
@@ -51,12 +52,17 @@ type SubChannel struct {
 	handler            Handler
 	logger             Logger
 	statsReporter      StatsReporter
+	accessLog          *accessLogger
 }
 
 // Map of subchannel and the corresponding service
 type subChannelMap struct {
 	sync.RWMutex
 	subchannels map[string]*SubChannel
+
+	// hasAccessLog is set once any subchannel has its own access log, to
+	// avoid looking up the subchannel for every call otherwise.
+	hasAccessLog atomic.Bool
 }
 
 func newSubChannel(serviceName string, ch *Channel) *SubChannel {
@@ -96,6 +102,11 @@ func (c *SubChannel) Peers() *PeerList {
 	return c.peers
 }
 
+// State returns the state of the top-level channel for this subchannel.
+func (c *SubChannel) State() ChannelState {
+	return c.topChannel.State()
+}
+
 // Isolated returns whether this subchannel is an isolated subchannel.
 func (c *SubChannel) Isolated() bool {
 	c.RLock()
@@ -125,6 +125,12 @@ func (o *ChannelOpts) SetStatsReporter(statsReporter tchannel.StatsReporter) *Ch
 	return o
 }
 
+// SetCallStats sets CallStats in ChannelOptions.
+func (o *ChannelOpts) SetCallStats(callStats tchannel.CallStatsOptions) *ChannelOpts {
+	o.CallStats = callStats
+	return o
+}
+
 // SetFramePool sets FramePool in DefaultConnectionOptions.
 func (o *ChannelOpts) SetFramePool(framePool tchannel.FramePool) *ChannelOpts {
 	o.DefaultConnectionOptions.FramePool = framePool
@@ -137,6 +143,12 @@ func (o *ChannelOpts) SetHealthChecks(healthChecks tchannel.HealthCheckOptions)
 	return o
 }
 
+// SetConnectionMetrics sets Metrics in DefaultConnectionOptions.
+func (o *ChannelOpts) SetConnectionMetrics(metrics tchannel.ConnectionMetricsOptions) *ChannelOpts {
+	o.DefaultConnectionOptions.Metrics = metrics
+	return o
+}
+
 // SetSendBufferSize sets the SendBufferSize in DefaultConnectionOptions.
 func (o *ChannelOpts) SetSendBufferSize(bufSize int) *ChannelOpts {
 	o.DefaultConnectionOptions.SendBufferSize = bufSize
@@ -155,6 +167,13 @@ func (o *ChannelOpts) SetTosPriority(tosPriority tos.ToS) *ChannelOpts {
 	return o
 }
 
+// SetConnectionsPerPeer sets ConnectionsPerPeer and ConnectionSelection in DefaultConnectionOptions.
+func (o *ChannelOpts) SetConnectionsPerPeer(n int, selection tchannel.ConnectionSelection) *ChannelOpts {
+	o.DefaultConnectionOptions.ConnectionsPerPeer = n
+	o.DefaultConnectionOptions.ConnectionSelection = selection
+	return o
+}
+
 // SetChecksumType sets the ChecksumType in DefaultConnectionOptions.
 func (o *ChannelOpts) SetChecksumType(checksumType tchannel.ChecksumType) *ChannelOpts {
 	o.DefaultConnectionOptions.ChecksumType = checksumType
@@ -451,6 +451,7 @@ func comparableState(ch *tchannel.Channel, opts *tchannel.IntrospectionOptions)
 	s := ch.IntrospectState(opts)
 	s.SubChannels = nil
 	s.Peers = nil
+	s.CallStats = nil
 
 	// Tests start with ChannelClient or ChannelListening, but end with ChannelClosed.
 	s.ChannelState = ""
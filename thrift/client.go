@@ -21,6 +21,8 @@
 package thrift
 
 import (
+	"time"
+
 	"github.com/uber/tchannel-go"
 	"github.com/uber/tchannel-go/internal/argreader"
 
@@ -40,6 +42,49 @@ type client struct {
 type ClientOptions struct {
 	// HostPort specifies a specific server to hit.
 	HostPort string
+
+	// Protocol is the Thrift protocol used to encode calls. Defaults to
+	// BinaryProtocol. CompactProtocol should only be used if all servers
+	// for the service support it, as it is signalled using CompactFormat.
+	Protocol Protocol
+}
+
+// MethodOptions are options for calls to a specific method. They are set by
+// the generated client using annotations on the method in the Thrift IDL.
+type MethodOptions struct {
+	// Timeout is the timeout for the call. If the Context has an earlier
+	// deadline, then that is used instead.
+	Timeout time.Duration
+
+	// RetryOn is the types of errors to retry on. It is only used if the
+	// Context does not specify any retry options.
+	RetryOn tchannel.RetryOn
+
+	// ShardKey is the shard key for the call. It is only used if the
+	// Context does not specify a shard key.
+	ShardKey string
+}
+
+type methodOptionsKey struct{}
+
+// WithMethodOptions returns a Context to make a call using the given method options.
+// The returned CancelFunc should be called once the call is complete.
+func WithMethodOptions(ctx Context, opts MethodOptions) (Context, context.CancelFunc) {
+	var (
+		optsCtx context.Context = ctx
+		cancel  context.CancelFunc
+	)
+	if opts.Timeout > 0 {
+		optsCtx, cancel = context.WithTimeout(optsCtx, opts.Timeout)
+	} else {
+		optsCtx, cancel = context.WithCancel(optsCtx)
+	}
+	return Wrap(context.WithValue(optsCtx, methodOptionsKey{}, opts)), cancel
+}
+
+func getMethodOptions(ctx context.Context) MethodOptions {
+	opts, _ := ctx.Value(methodOptionsKey{}).(MethodOptions)
+	return opts
 }
 
 // NewClient returns a Client that makes calls over the given tchannel to the given Hyperbahn service.
@@ -62,7 +107,7 @@ func (c *client) startCall(ctx context.Context, method string, callOptions *tcha
 	return c.sc.BeginCall(ctx, method, callOptions)
 }
 
-func writeArgs(call *tchannel.OutboundCall, headers map[string]string, req thrift.TStruct) error {
+func writeArgs(call *tchannel.OutboundCall, protocol Protocol, headers map[string]string, req thrift.TStruct) error {
 	writer, err := call.Arg2Writer()
 	if err != nil {
 		return err
@@ -80,7 +125,7 @@ func writeArgs(call *tchannel.OutboundCall, headers map[string]string, req thrif
 		return err
 	}
 
-	if err := WriteStruct(writer, req); err != nil {
+	if err := writeStruct(protocol, writer, req); err != nil {
 		return err
 	}
 
@@ -89,7 +134,7 @@ func writeArgs(call *tchannel.OutboundCall, headers map[string]string, req thrif
 
 // readResponse reads the response struct into resp, and returns:
 // (response headers, whether there was an application error, unexpected error).
-func readResponse(response *tchannel.OutboundCallResponse, resp thrift.TStruct) (map[string]string, bool, error) {
+func readResponse(response *tchannel.OutboundCallResponse, protocol Protocol, resp thrift.TStruct) (map[string]string, bool, error) {
 	reader, err := response.Arg2Reader()
 	if err != nil {
 		return nil, false, err
@@ -114,7 +159,7 @@ func readResponse(response *tchannel.OutboundCallResponse, resp thrift.TStruct)
 		return headers, success, err
 	}
 
-	if err := ReadStruct(reader, resp); err != nil {
+	if err := readStruct(protocol, reader, resp); err != nil {
 		return headers, success, err
 	}
 
@@ -133,22 +178,31 @@ func (c *client) Call(ctx Context, thriftService, methodName string, req, resp t
 		isOK        bool
 	)
 
-	err := c.ch.RunWithRetry(ctx, func(ctx context.Context, rs *tchannel.RequestState) error {
+	var (
+		methodOpts   = getMethodOptions(ctx)
+		retryOptions *tchannel.RetryOptions
+	)
+	if methodOpts.RetryOn != tchannel.RetryDefault {
+		retryOptions = &tchannel.RetryOptions{RetryOn: methodOpts.RetryOn}
+	}
+
+	err := c.ch.RunWithDefaultRetry(ctx, retryOptions, func(ctx context.Context, rs *tchannel.RequestState) error {
 		respHeaders, isOK = nil, false
 
 		call, err := c.startCall(ctx, thriftService+"::"+methodName, &tchannel.CallOptions{
-			Format:       tchannel.Thrift,
+			Format:       c.opts.Protocol.format(),
+			ShardKey:     methodOpts.ShardKey,
 			RequestState: rs,
 		})
 		if err != nil {
 			return err
 		}
 
-		if err := writeArgs(call, headers, req); err != nil {
+		if err := writeArgs(call, c.opts.Protocol, headers, req); err != nil {
 			return err
 		}
 
-		respHeaders, isOK, err = readResponse(call.Response(), resp)
+		respHeaders, isOK, err = readResponse(call.Response(), c.opts.Protocol, resp)
 		return err
 	})
 	if err != nil {
@@ -0,0 +1,99 @@
+// Copyright (c) 2015 Uber Technologies, Inc.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dynamic
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/uber/tchannel-go/thrift"
+)
+
+// Exception is a Thrift exception declared in a method's throws clause.
+// It is returned by Client when the server returns an exception, and can be
+// returned by a Handler to return an exception to the caller.
+type Exception struct {
+	// Name is the name of the exception in the throws clause. Handlers may
+	// also use the exception's type name.
+	Name string
+
+	// Value is the exception's fields keyed by name.
+	Value map[string]interface{}
+}
+
+func (e *Exception) Error() string {
+	return fmt.Sprintf("thrift exception %v: %v", e.Name, e.Value)
+}
+
+// Client makes Thrift calls to methods defined in an IDL.
+type Client struct {
+	idl    *IDL
+	client thrift.TChanClient
+}
+
+// NewClient returns a client that makes calls to methods in the given IDL
+// using the given Thrift client.
+func NewClient(idl *IDL, client thrift.TChanClient) *Client {
+	return &Client{idl: idl, client: client}
+}
+
+// Call calls the given method with arguments keyed by name, and returns the
+// method's return value, which is nil for void methods. If the server returns
+// an exception, it is returned as an *Exception.
+func (c *Client) Call(ctx thrift.Context, service, method string, args map[string]interface{}) (interface{}, error) {
+	m, err := c.idl.Method(service, method)
+	if err != nil {
+		return nil, err
+	}
+
+	result := m.Result()
+	success, err := c.client.Call(ctx, m.Service(), m.Name(), m.Args(args), result)
+	if err != nil {
+		return nil, err
+	}
+	if !success {
+		if exc := m.exception(result); exc != nil {
+			return nil, exc
+		}
+		return nil, fmt.Errorf("received no result or unknown exception for %v", method)
+	}
+	return result.Value["success"], nil
+}
+
+// CallJSON is similar to Call, but the arguments are a JSON object, and the
+// return value is encoded as JSON.
+func (c *Client) CallJSON(ctx thrift.Context, service, method string, args []byte) ([]byte, error) {
+	var argsMap map[string]interface{}
+	if len(args) > 0 {
+		decoder := json.NewDecoder(bytes.NewReader(args))
+		decoder.UseNumber()
+		if err := decoder.Decode(&argsMap); err != nil {
+			return nil, fmt.Errorf("failed to parse arguments: %v", err)
+		}
+	}
+
+	v, err := c.Call(ctx, service, method, argsMap)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(v)
+}
@@ -0,0 +1,46 @@
+// Copyright (c) 2015 Uber Technologies, Inc.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+/*
+Package dynamic makes and handles Thrift calls over TChannel using a parsed
+Thrift IDL instead of code generated by thrift-gen. It is intended for
+proxies, gateways and tools that need to speak Thrift to services whose
+generated code they do not compile in.
+
+Arguments, results and exceptions are represented as Go values, with structs
+as maps keyed by field name. Values decoded using encoding/json can be used
+directly, see Struct for how each Thrift type is represented.
+
+To make calls, parse the IDL and create a client:
+
+	idl, err := dynamic.Parse("keyvalue.thrift")
+	client := dynamic.NewClient(idl, thrift.NewClient(ch, "keyvalue", nil))
+	value, err := client.Call(ctx, "KeyValue", "Get", map[string]interface{}{"key": "foo"})
+
+To handle calls, register handlers for the service's methods:
+
+	server, err := dynamic.NewServer(idl, "KeyValue", dynamic.Handlers{
+	  "Get": func(ctx thrift.Context, args map[string]interface{}) (interface{}, error) {
+	    return "bar", nil
+	  },
+	})
+	thrift.NewServer(ch).Register(server)
+*/
+package dynamic
@@ -0,0 +1,119 @@
+// Copyright (c) 2015 Uber Technologies, Inc.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dynamic_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/uber/tchannel-go/thrift/dynamic"
+
+	"github.com/uber/tchannel-go/testutils"
+	"github.com/uber/tchannel-go/thrift"
+	gen "github.com/uber/tchannel-go/thrift/gen-go/test"
+	"github.com/uber/tchannel-go/thrift/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const testIDL = "../test.thrift"
+
+func withClientServer(t *testing.T, f func(serverCh thrift.TChanClient, server *thrift.Server, ctx thrift.Context)) {
+	server := testutils.NewServer(t, testutils.NewOpts().SetServiceName("svc"))
+	defer server.Close()
+
+	client := testutils.NewClient(t, nil)
+	defer client.Close()
+	client.Peers().Add(server.PeerInfo().HostPort)
+
+	ctx, cancel := thrift.NewContext(time.Second)
+	defer cancel()
+
+	f(thrift.NewClient(client, "svc", nil), thrift.NewServer(server), ctx)
+}
+
+func TestClientCallsGeneratedServer(t *testing.T) {
+	idl, err := Parse(testIDL)
+	require.NoError(t, err, "Parse failed")
+
+	withClientServer(t, func(tchanClient thrift.TChanClient, server *thrift.Server, ctx thrift.Context) {
+		handler := new(mocks.TChanSimpleService)
+		server.Register(gen.NewTChanSimpleServiceServer(handler))
+		client := NewClient(idl, tchanClient)
+
+		handler.On("Call", mock.Anything, &gen.Data{B1: true, S2: "foo", I3: 3}).
+			Return(&gen.Data{S2: "bar", I3: 4}, nil)
+		res, err := client.CallJSON(ctx, "SimpleService", "Call", []byte(`{"arg": {"b1": true, "s2": "foo", "i3": 3}}`))
+		require.NoError(t, err, "CallJSON failed")
+		assert.JSONEq(t, `{"b1": false, "s2": "bar", "i3": 4}`, string(res))
+
+		handler.On("Simple", mock.Anything).Return(&gen.SimpleErr{Message: "err"})
+		_, err = client.Call(ctx, "SimpleService", "Simple", nil)
+		assert.Equal(t, &Exception{Name: "simpleErr", Value: map[string]interface{}{"message": "err"}}, err)
+
+		_, err = client.Call(ctx, "SimpleService", "Unknown", nil)
+		assert.Error(t, err, "Call to unknown method should fail")
+	})
+}
+
+func TestServerHandlesGeneratedClient(t *testing.T) {
+	idl, err := Parse(testIDL)
+	require.NoError(t, err, "Parse failed")
+
+	withClientServer(t, func(tchanClient thrift.TChanClient, server *thrift.Server, ctx thrift.Context) {
+		dynamicServer, err := NewServer(idl, "SimpleService", Handlers{
+			"Call": func(ctx thrift.Context, args map[string]interface{}) (interface{}, error) {
+				arg := args["arg"].(map[string]interface{})
+				return map[string]interface{}{"s2": arg["s2"].(string) + "-echo", "i3": arg["i3"]}, nil
+			},
+			"Simple": func(ctx thrift.Context, args map[string]interface{}) (interface{}, error) {
+				return nil, &Exception{Name: "SimpleErr", Value: map[string]interface{}{"message": "err"}}
+			},
+			"SimpleFuture": func(ctx thrift.Context, args map[string]interface{}) (interface{}, error) {
+				return nil, errors.New("unexpected")
+			},
+		})
+		require.NoError(t, err, "NewServer failed")
+		server.Register(dynamicServer)
+		client := gen.NewTChanSimpleServiceClient(tchanClient)
+
+		res, err := client.Call(ctx, &gen.Data{S2: "foo", I3: 3})
+		require.NoError(t, err, "Call failed")
+		assert.Equal(t, &gen.Data{S2: "foo-echo", I3: 3}, res)
+
+		err = client.Simple(ctx)
+		assert.Equal(t, &gen.SimpleErr{Message: "err"}, err)
+
+		err = client.SimpleFuture(ctx)
+		assert.Error(t, err, "SimpleFuture should fail with a system error")
+	})
+}
+
+func TestNewServerUnknownMethod(t *testing.T) {
+	idl, err := Parse(testIDL)
+	require.NoError(t, err, "Parse failed")
+
+	_, err = NewServer(idl, "SimpleService", Handlers{"Unknown": nil})
+	assert.Error(t, err, "NewServer should fail for unknown methods")
+}
@@ -0,0 +1,194 @@
+// Copyright (c) 2015 Uber Technologies, Inc.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dynamic
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/samuel/go-thrift/parser"
+)
+
+// IDL is a parsed Thrift file along with all the files that it includes.
+type IDL struct {
+	entry string
+	files map[string]*parser.Thrift
+}
+
+// Parse parses the given Thrift file and any files that it includes.
+func Parse(filename string) (*IDL, error) {
+	return parse(&parser.Parser{}, filename)
+}
+
+// ParseFiles parses Thrift files that are held in memory, keyed by their
+// path. Includes are resolved relative to the including file.
+func ParseFiles(files map[string]string, entry string) (*IDL, error) {
+	return parse(&parser.Parser{Filesystem: memFS(files)}, entry)
+}
+
+func parse(p *parser.Parser, filename string) (*IDL, error) {
+	files, entry, err := p.ParseFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %v: %v", filename, err)
+	}
+	return &IDL{entry: entry, files: files}, nil
+}
+
+// Services returns the names of the services defined in the entry file.
+func (idl *IDL) Services() []string {
+	var names []string
+	for name := range idl.files[idl.entry].Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Method returns the given method of the given service. The service must be
+// defined in the entry file, and the method may be inherited using extends.
+func (idl *IDL) Method(service, method string) (*Method, error) {
+	file := idl.entry
+	svc, ok := idl.files[file].Services[service]
+	if !ok {
+		return nil, fmt.Errorf("service %q not found in %v", service, filepath.Base(file))
+	}
+
+	for {
+		if m, ok := svc.Methods[method]; ok {
+			return &Method{idl: idl, file: file, service: service, m: m}, nil
+		}
+		if svc.Extends == "" {
+			return nil, fmt.Errorf("method %q not found in service %q", method, service)
+		}
+
+		extendsFile, name := idl.splitName(file, svc.Extends)
+		extends, ok := idl.files[extendsFile].Services[name]
+		if !ok {
+			return nil, fmt.Errorf("service %q extends unknown service %q", svc.Name, svc.Extends)
+		}
+		file, svc = extendsFile, extends
+	}
+}
+
+// splitName returns the file and unqualified name for a possibly included
+// identifier such as "shared.Base" that is referenced from the given file.
+func (idl *IDL) splitName(file, name string) (string, string) {
+	parts := strings.SplitN(name, ".", 2)
+	if len(parts) != 2 {
+		return file, name
+	}
+	if included, ok := idl.files[file].Includes[parts[0]]; ok {
+		return included, parts[1]
+	}
+	return file, name
+}
+
+// Method is a Thrift method resolved from an IDL.
+type Method struct {
+	idl     *IDL
+	file    string
+	service string
+	m       *parser.Method
+}
+
+// Service returns the service name that the method was looked up on, which
+// is the service name used by TChannel, even for inherited methods.
+func (m *Method) Service() string {
+	return m.service
+}
+
+// Name returns the method's name.
+func (m *Method) Name() string {
+	return m.m.Name
+}
+
+// Args returns a struct containing the method's arguments.
+func (m *Method) Args(args map[string]interface{}) *Struct {
+	return &Struct{
+		idl:   m.idl,
+		file:  m.file,
+		def:   &parser.Struct{Name: m.m.Name + "_args", Fields: m.m.Arguments},
+		Value: args,
+	}
+}
+
+// Result returns an empty struct that can hold the method's result, which
+// contains either the return value as "success" or one of the exceptions.
+func (m *Method) Result() *Struct {
+	var fields []*parser.Field
+	if m.m.ReturnType != nil {
+		fields = append(fields, &parser.Field{ID: 0, Name: "success", Optional: true, Type: m.m.ReturnType})
+	}
+	fields = append(fields, m.m.Exceptions...)
+
+	return &Struct{
+		idl:  m.idl,
+		file: m.file,
+		def:  &parser.Struct{Name: m.m.Name + "_result", Fields: fields},
+	}
+}
+
+// exception returns the exception set in the given result, or nil if no
+// exception is set.
+func (m *Method) exception(result *Struct) *Exception {
+	for _, f := range m.m.Exceptions {
+		if v, ok := result.Value[f.Name]; ok && v != nil {
+			value, _ := v.(map[string]interface{})
+			return &Exception{Name: f.Name, Value: value}
+		}
+	}
+	return nil
+}
+
+// exceptionField returns the field in the throws clause for an exception,
+// which can be identified by the field name or the exception type.
+func (m *Method) exceptionField(name string) *parser.Field {
+	for _, f := range m.m.Exceptions {
+		if f.Name == name {
+			return f
+		}
+	}
+	for _, f := range m.m.Exceptions {
+		if _, typeName := m.idl.splitName(m.file, f.Type.Name); typeName == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// memFS implements parser.Filesystem using in-memory files.
+type memFS map[string]string
+
+func (fs memFS) Open(filename string) (io.ReadCloser, error) {
+	contents, ok := fs[filename]
+	if !ok {
+		return nil, fmt.Errorf("file not found: %v", filename)
+	}
+	return ioutil.NopCloser(strings.NewReader(contents)), nil
+}
+
+func (fs memFS) Abs(path string) (string, error) {
+	return filepath.Clean(path), nil
+}
@@ -0,0 +1,129 @@
+// Copyright (c) 2015 Uber Technologies, Inc.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dynamic
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/uber/tchannel-go/thrift"
+
+	athrift "github.com/apache/thrift/lib/go/thrift"
+)
+
+// Handler handles a call to a method. It receives the arguments keyed by
+// name, and returns the method's return value, which is ignored for void
+// methods. To return an exception declared by the method, return an
+// *Exception. Any other error is returned to the caller as a system error.
+type Handler func(ctx thrift.Context, args map[string]interface{}) (interface{}, error)
+
+// Handlers is a map from method name to the handler for that method.
+type Handlers map[string]Handler
+
+// server implements thrift.TChanArgsServer using an IDL.
+type server struct {
+	idl      *IDL
+	service  string
+	handlers Handlers
+}
+
+// NewServer returns a thrift.TChanServer for the given service in the IDL,
+// which can be registered with a thrift.Server. Only the methods that have a
+// handler are registered.
+func NewServer(idl *IDL, service string, handlers Handlers) (thrift.TChanServer, error) {
+	for method := range handlers {
+		if _, err := idl.Method(service, method); err != nil {
+			return nil, err
+		}
+	}
+	return &server{idl: idl, service: service, handlers: handlers}, nil
+}
+
+func (s *server) Service() string {
+	return s.service
+}
+
+func (s *server) Methods() []string {
+	methods := make([]string, 0, len(s.handlers))
+	for method := range s.handlers {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+	return methods
+}
+
+func (s *server) Handle(ctx thrift.Context, methodName string, protocol athrift.TProtocol) (bool, athrift.TStruct, error) {
+	args, ok := s.NewArgs(methodName)
+	if !ok {
+		return false, nil, fmt.Errorf("method %v not found in service %v", methodName, s.service)
+	}
+	if err := args.Read(protocol); err != nil {
+		return false, nil, err
+	}
+	return s.HandleArgs(ctx, methodName, args)
+}
+
+func (s *server) NewArgs(methodName string) (athrift.TStruct, bool) {
+	if _, ok := s.handlers[methodName]; !ok {
+		return nil, false
+	}
+	m, err := s.idl.Method(s.service, methodName)
+	if err != nil {
+		return nil, false
+	}
+	return m.Args(nil), true
+}
+
+func (s *server) HandleArgs(ctx thrift.Context, methodName string, args athrift.TStruct) (bool, athrift.TStruct, error) {
+	handler, ok := s.handlers[methodName]
+	if !ok {
+		return false, nil, fmt.Errorf("method %v not found in service %v", methodName, s.service)
+	}
+	m, err := s.idl.Method(s.service, methodName)
+	if err != nil {
+		return false, nil, err
+	}
+	req, ok := args.(*Struct)
+	if !ok {
+		return false, nil, fmt.Errorf("unexpected arguments %T for method %v", args, methodName)
+	}
+
+	r, err := handler(ctx, req.Value)
+	result := m.Result()
+	if err != nil {
+		exc, ok := err.(*Exception)
+		if !ok {
+			return false, nil, err
+		}
+
+		f := m.exceptionField(exc.Name)
+		if f == nil {
+			return false, nil, fmt.Errorf("handler for %v returned exception %v which is not declared", methodName, exc.Name)
+		}
+		result.Value = map[string]interface{}{f.Name: exc.Value}
+		return false, result, nil
+	}
+
+	if m.m.ReturnType != nil {
+		result.Value = map[string]interface{}{"success": r}
+	}
+	return true, result, nil
+}
@@ -0,0 +1,676 @@
+// Copyright (c) 2015 Uber Technologies, Inc.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dynamic
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+
+	"github.com/apache/thrift/lib/go/thrift"
+	"github.com/samuel/go-thrift/parser"
+)
+
+// Struct is a thrift.TStruct whose fields are described by an IDL struct and
+// whose value is held as a map from field name to a Go value.
+//
+// When writing, values may be any Go value that matches the field type,
+// including values decoded using encoding/json. Binary fields may be
+// specified as base64-encoded strings, enums as names or numbers, and maps
+// as JSON objects or lists of {"key": k, "value": v} objects. Missing fields
+// that are not optional are written as zero values, the same as generated code.
+//
+// When reading, values are decoded into types that encoding/json can marshal:
+// integers use the width of the Thrift type, enums are returned as names,
+// containers use []interface{}, and maps with keys that can't be represented
+// as strings are returned as lists of {"key": k, "value": v} objects.
+type Struct struct {
+	idl  *IDL
+	file string
+	def  *parser.Struct
+
+	// Value is the struct's fields keyed by name.
+	Value map[string]interface{}
+}
+
+// Write writes the struct's value to the given protocol.
+func (s *Struct) Write(p thrift.TProtocol) error {
+	return s.idl.writeStruct(p, s.file, s.def, s.Value)
+}
+
+// Read reads the struct from the given protocol, replacing the current value.
+func (s *Struct) Read(p thrift.TProtocol) error {
+	v, err := s.idl.readStruct(p, s.file, s.def)
+	s.Value = v
+	return err
+}
+
+var _ thrift.TStruct = (*Struct)(nil)
+
+// kind is the category of a resolved Thrift type.
+type kind int
+
+const (
+	kindBase kind = iota
+	kindEnum
+	kindStruct
+	kindList
+	kindSet
+	kindMap
+)
+
+// resolvedType is a Thrift type with typedefs and included names resolved.
+type resolvedType struct {
+	kind  kind
+	ttype thrift.TType
+
+	// file is the file that nested type names are relative to.
+	file string
+
+	base       string
+	enum       *parser.Enum
+	strct      *parser.Struct
+	key, value *parser.Type
+}
+
+var baseTypes = map[string]thrift.TType{
+	"bool":   thrift.BOOL,
+	"byte":   thrift.BYTE,
+	"i8":     thrift.BYTE,
+	"i16":    thrift.I16,
+	"i32":    thrift.I32,
+	"i64":    thrift.I64,
+	"double": thrift.DOUBLE,
+	"string": thrift.STRING,
+	"binary": thrift.STRING,
+}
+
+func (idl *IDL) resolve(file string, t *parser.Type) (*resolvedType, error) {
+	if t == nil {
+		return nil, fmt.Errorf("missing type")
+	}
+
+	switch t.Name {
+	case "list":
+		return &resolvedType{kind: kindList, ttype: thrift.LIST, file: file, value: t.ValueType}, nil
+	case "set":
+		return &resolvedType{kind: kindSet, ttype: thrift.SET, file: file, value: t.ValueType}, nil
+	case "map":
+		return &resolvedType{kind: kindMap, ttype: thrift.MAP, file: file, key: t.KeyType, value: t.ValueType}, nil
+	}
+	if ttype, ok := baseTypes[t.Name]; ok {
+		return &resolvedType{kind: kindBase, ttype: ttype, file: file, base: t.Name}, nil
+	}
+
+	file, name := idl.splitName(file, t.Name)
+	parsed, ok := idl.files[file]
+	if !ok {
+		return nil, fmt.Errorf("unknown type %q", t.Name)
+	}
+	if typedef, ok := parsed.Typedefs[name]; ok {
+		return idl.resolve(file, typedef.Type)
+	}
+	if enum, ok := parsed.Enums[name]; ok {
+		return &resolvedType{kind: kindEnum, ttype: thrift.I32, file: file, enum: enum}, nil
+	}
+	for _, structs := range []map[string]*parser.Struct{parsed.Structs, parsed.Exceptions, parsed.Unions} {
+		if s, ok := structs[name]; ok {
+			return &resolvedType{kind: kindStruct, ttype: thrift.STRUCT, file: file, strct: s}, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown type %q", t.Name)
+}
+
+func (idl *IDL) writeStruct(p thrift.TProtocol, file string, s *parser.Struct, v interface{}) error {
+	fields, err := toFieldMap(v)
+	if err != nil {
+		return fmt.Errorf("struct %v: %v", s.Name, err)
+	}
+	for name := range fields {
+		if findField(s, name) == nil {
+			return fmt.Errorf("struct %v has no field %q", s.Name, name)
+		}
+	}
+
+	if err := p.WriteStructBegin(s.Name); err != nil {
+		return err
+	}
+	for _, f := range s.Fields {
+		t, err := idl.resolve(file, f.Type)
+		if err != nil {
+			return fmt.Errorf("struct %v field %v: %v", s.Name, f.Name, err)
+		}
+
+		fv := fields[f.Name]
+		if fv == nil && !f.Optional {
+			// Generated code always writes fields that are not optional,
+			// and fails to read structs missing required fields.
+			fv = zeroValue(t)
+		}
+		if fv == nil {
+			continue
+		}
+
+		if err := p.WriteFieldBegin(f.Name, t.ttype, int16(f.ID)); err != nil {
+			return err
+		}
+		if err := idl.writeValue(p, t, fv); err != nil {
+			return fmt.Errorf("struct %v field %v: %v", s.Name, f.Name, err)
+		}
+		if err := p.WriteFieldEnd(); err != nil {
+			return err
+		}
+	}
+	if err := p.WriteFieldStop(); err != nil {
+		return err
+	}
+	return p.WriteStructEnd()
+}
+
+func (idl *IDL) writeValue(p thrift.TProtocol, t *resolvedType, v interface{}) error {
+	switch t.kind {
+	case kindStruct:
+		return idl.writeStruct(p, t.file, t.strct, v)
+	case kindEnum:
+		n, err := enumValue(t.enum, v)
+		if err != nil {
+			return err
+		}
+		return p.WriteI32(n)
+	case kindList, kindSet:
+		return idl.writeList(p, t, v)
+	case kindMap:
+		return idl.writeMap(p, t, v)
+	}
+	return writeBase(p, t.base, v)
+}
+
+func (idl *IDL) writeList(p thrift.TProtocol, t *resolvedType, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return fmt.Errorf("expected list, got %T", v)
+	}
+	elem, err := idl.resolve(t.file, t.value)
+	if err != nil {
+		return err
+	}
+
+	if t.kind == kindSet {
+		err = p.WriteSetBegin(elem.ttype, rv.Len())
+	} else {
+		err = p.WriteListBegin(elem.ttype, rv.Len())
+	}
+	if err != nil {
+		return err
+	}
+	for i := 0; i < rv.Len(); i++ {
+		if err := idl.writeValue(p, elem, rv.Index(i).Interface()); err != nil {
+			return fmt.Errorf("[%v]: %v", i, err)
+		}
+	}
+	if t.kind == kindSet {
+		return p.WriteSetEnd()
+	}
+	return p.WriteListEnd()
+}
+
+func (idl *IDL) writeMap(p thrift.TProtocol, t *resolvedType, v interface{}) error {
+	keyType, err := idl.resolve(t.file, t.key)
+	if err != nil {
+		return err
+	}
+	valueType, err := idl.resolve(t.file, t.value)
+	if err != nil {
+		return err
+	}
+
+	type entry struct{ key, value interface{} }
+	var entries []entry
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Map:
+		for _, k := range rv.MapKeys() {
+			key := k.Interface()
+			if s, ok := key.(string); ok {
+				if key, err = keyFromString(keyType, s); err != nil {
+					return err
+				}
+			}
+			entries = append(entries, entry{key, rv.MapIndex(k).Interface()})
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			kv, ok := rv.Index(i).Interface().(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("expected map entry with key and value, got %T", rv.Index(i).Interface())
+			}
+			entries = append(entries, entry{kv["key"], kv["value"]})
+		}
+	default:
+		return fmt.Errorf("expected map, got %T", v)
+	}
+
+	if err := p.WriteMapBegin(keyType.ttype, valueType.ttype, len(entries)); err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if err := idl.writeValue(p, keyType, e.key); err != nil {
+			return fmt.Errorf("map key %v: %v", e.key, err)
+		}
+		if err := idl.writeValue(p, valueType, e.value); err != nil {
+			return fmt.Errorf("map value for %v: %v", e.key, err)
+		}
+	}
+	return p.WriteMapEnd()
+}
+
+func writeBase(p thrift.TProtocol, base string, v interface{}) error {
+	switch base {
+	case "bool":
+		b, ok := v.(bool)
+		if !ok {
+			return fmt.Errorf("expected bool, got %T", v)
+		}
+		return p.WriteBool(b)
+	case "byte", "i8":
+		n, err := toInt(v, math.MinInt8, math.MaxInt8)
+		if err != nil {
+			return err
+		}
+		return p.WriteByte(int8(n))
+	case "i16":
+		n, err := toInt(v, math.MinInt16, math.MaxInt16)
+		if err != nil {
+			return err
+		}
+		return p.WriteI16(int16(n))
+	case "i32":
+		n, err := toInt(v, math.MinInt32, math.MaxInt32)
+		if err != nil {
+			return err
+		}
+		return p.WriteI32(int32(n))
+	case "i64":
+		n, err := toInt(v, math.MinInt64, math.MaxInt64)
+		if err != nil {
+			return err
+		}
+		return p.WriteI64(n)
+	case "double":
+		f, err := toFloat(v)
+		if err != nil {
+			return err
+		}
+		return p.WriteDouble(f)
+	case "string":
+		s, ok := v.(string)
+		if !ok {
+			return fmt.Errorf("expected string, got %T", v)
+		}
+		return p.WriteString(s)
+	case "binary":
+		switch b := v.(type) {
+		case []byte:
+			return p.WriteBinary(b)
+		case string:
+			decoded, err := base64.StdEncoding.DecodeString(b)
+			if err != nil {
+				return fmt.Errorf("binary must be base64 encoded: %v", err)
+			}
+			return p.WriteBinary(decoded)
+		}
+		return fmt.Errorf("expected binary, got %T", v)
+	}
+	return fmt.Errorf("unknown base type %v", base)
+}
+
+func (idl *IDL) readStruct(p thrift.TProtocol, file string, s *parser.Struct) (map[string]interface{}, error) {
+	if _, err := p.ReadStructBegin(); err != nil {
+		return nil, err
+	}
+
+	fields := make(map[string]interface{})
+	for {
+		_, ttype, id, err := p.ReadFieldBegin()
+		if err != nil {
+			return nil, err
+		}
+		if ttype == thrift.STOP {
+			break
+		}
+
+		f := findFieldByID(s, int(id))
+		var t *resolvedType
+		if f != nil {
+			if t, err = idl.resolve(file, f.Type); err != nil {
+				return nil, fmt.Errorf("struct %v field %v: %v", s.Name, f.Name, err)
+			}
+		}
+		if t == nil || t.ttype != ttype {
+			// Unknown fields and fields with mismatched types are skipped,
+			// the same as generated code.
+			if err := p.Skip(ttype); err != nil {
+				return nil, err
+			}
+		} else {
+			v, err := idl.readValue(p, t)
+			if err != nil {
+				return nil, fmt.Errorf("struct %v field %v: %v", s.Name, f.Name, err)
+			}
+			fields[f.Name] = v
+		}
+
+		if err := p.ReadFieldEnd(); err != nil {
+			return nil, err
+		}
+	}
+	return fields, p.ReadStructEnd()
+}
+
+func (idl *IDL) readValue(p thrift.TProtocol, t *resolvedType) (interface{}, error) {
+	switch t.kind {
+	case kindStruct:
+		return idl.readStruct(p, t.file, t.strct)
+	case kindEnum:
+		n, err := p.ReadI32()
+		if err != nil {
+			return nil, err
+		}
+		return enumName(t.enum, n), nil
+	case kindList, kindSet:
+		return idl.readList(p, t)
+	case kindMap:
+		return idl.readMap(p, t)
+	}
+	return readBase(p, t.base)
+}
+
+func (idl *IDL) readList(p thrift.TProtocol, t *resolvedType) (interface{}, error) {
+	var (
+		size int
+		err  error
+	)
+	if t.kind == kindSet {
+		_, size, err = p.ReadSetBegin()
+	} else {
+		_, size, err = p.ReadListBegin()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	elem, err := idl.resolve(t.file, t.value)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]interface{}, 0, size)
+	for i := 0; i < size; i++ {
+		v, err := idl.readValue(p, elem)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, v)
+	}
+
+	if t.kind == kindSet {
+		return list, p.ReadSetEnd()
+	}
+	return list, p.ReadListEnd()
+}
+
+func (idl *IDL) readMap(p thrift.TProtocol, t *resolvedType) (interface{}, error) {
+	_, _, size, err := p.ReadMapBegin()
+	if err != nil {
+		return nil, err
+	}
+	keyType, err := idl.resolve(t.file, t.key)
+	if err != nil {
+		return nil, err
+	}
+	valueType, err := idl.resolve(t.file, t.value)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		stringKeys = keyType.kind == kindEnum || (keyType.kind == kindBase && keyType.base != "binary")
+		asMap      = make(map[string]interface{}, size)
+		asList     []interface{}
+	)
+	for i := 0; i < size; i++ {
+		k, err := idl.readValue(p, keyType)
+		if err != nil {
+			return nil, err
+		}
+		v, err := idl.readValue(p, valueType)
+		if err != nil {
+			return nil, err
+		}
+		if stringKeys {
+			asMap[fmt.Sprint(k)] = v
+		} else {
+			asList = append(asList, map[string]interface{}{"key": k, "value": v})
+		}
+	}
+
+	if err := p.ReadMapEnd(); err != nil {
+		return nil, err
+	}
+	if stringKeys {
+		return asMap, nil
+	}
+	return asList, nil
+}
+
+func readBase(p thrift.TProtocol, base string) (interface{}, error) {
+	switch base {
+	case "bool":
+		return p.ReadBool()
+	case "byte", "i8":
+		return p.ReadByte()
+	case "i16":
+		return p.ReadI16()
+	case "i32":
+		return p.ReadI32()
+	case "i64":
+		return p.ReadI64()
+	case "double":
+		return p.ReadDouble()
+	case "string":
+		return p.ReadString()
+	case "binary":
+		return p.ReadBinary()
+	}
+	return nil, fmt.Errorf("unknown base type %v", base)
+}
+
+func findField(s *parser.Struct, name string) *parser.Field {
+	for _, f := range s.Fields {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+func findFieldByID(s *parser.Struct, id int) *parser.Field {
+	for _, f := range s.Fields {
+		if f.ID == id {
+			return f
+		}
+	}
+	return nil
+}
+
+// zeroValue returns the zero value for a type, or nil for structs, which
+// cannot always be created without values for their own fields.
+func zeroValue(t *resolvedType) interface{} {
+	switch t.kind {
+	case kindEnum:
+		return 0
+	case kindList, kindSet:
+		return []interface{}{}
+	case kindMap:
+		return map[string]interface{}{}
+	case kindBase:
+		switch t.base {
+		case "bool":
+			return false
+		case "double":
+			return 0.0
+		case "string":
+			return ""
+		case "binary":
+			return []byte{}
+		}
+		return 0
+	}
+	return nil
+}
+
+// toFieldMap converts a struct value to a map keyed by field name.
+func toFieldMap(v interface{}) (map[string]interface{}, error) {
+	switch v := v.(type) {
+	case nil:
+		return nil, nil
+	case map[string]interface{}:
+		return v, nil
+	case *Struct:
+		return v.Value, nil
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Map || rv.Type().Key().Kind() != reflect.String {
+		return nil, fmt.Errorf("expected map of field names to values, got %T", v)
+	}
+	fields := make(map[string]interface{}, rv.Len())
+	for _, k := range rv.MapKeys() {
+		fields[k.String()] = rv.MapIndex(k).Interface()
+	}
+	return fields, nil
+}
+
+func enumValue(enum *parser.Enum, v interface{}) (int32, error) {
+	if name, ok := v.(string); ok {
+		if ev, ok := enum.Values[name]; ok {
+			return int32(ev.Value), nil
+		}
+		return 0, fmt.Errorf("unknown value %q for enum %v", name, enum.Name)
+	}
+	n, err := toInt(v, math.MinInt32, math.MaxInt32)
+	return int32(n), err
+}
+
+func enumName(enum *parser.Enum, n int32) interface{} {
+	// Iterate in a stable order in case multiple names share a value.
+	names := make([]string, 0, len(enum.Values))
+	for name := range enum.Values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if int32(enum.Values[name].Value) == n {
+			return name
+		}
+	}
+	return n
+}
+
+// keyFromString converts a JSON object key to a value for the given map key type.
+func keyFromString(t *resolvedType, s string) (interface{}, error) {
+	if t.kind == kindEnum {
+		return s, nil
+	}
+	if t.kind != kindBase {
+		return nil, fmt.Errorf("map keys of type %v must be specified as a list of key and value entries", t.ttype)
+	}
+
+	switch t.base {
+	case "string", "binary":
+		return s, nil
+	case "bool":
+		return strconv.ParseBool(s)
+	case "double":
+		return strconv.ParseFloat(s, 64)
+	}
+	return json.Number(s), nil
+}
+
+func toInt(v interface{}, min, max int64) (int64, error) {
+	var n int64
+	switch v := v.(type) {
+	case int:
+		n = int64(v)
+	case int8:
+		n = int64(v)
+	case int16:
+		n = int64(v)
+	case int32:
+		n = int64(v)
+	case int64:
+		n = v
+	case uint8:
+		n = int64(v)
+	case uint16:
+		n = int64(v)
+	case uint32:
+		n = int64(v)
+	case float64:
+		if v != math.Trunc(v) {
+			return 0, fmt.Errorf("expected integer, got %v", v)
+		}
+		n = int64(v)
+	case json.Number:
+		var err error
+		if n, err = v.Int64(); err != nil {
+			return 0, fmt.Errorf("expected integer, got %v", v)
+		}
+	default:
+		return 0, fmt.Errorf("expected integer, got %T", v)
+	}
+
+	if n < min || n > max {
+		return 0, fmt.Errorf("value %v out of range [%v, %v]", n, min, max)
+	}
+	return n, nil
+}
+
+func toFloat(v interface{}) (float64, error) {
+	switch v := v.(type) {
+	case float32:
+		return float64(v), nil
+	case float64:
+		return v, nil
+	case json.Number:
+		return v.Float64()
+	}
+
+	n, err := toInt(v, math.MinInt64, math.MaxInt64)
+	if err != nil {
+		return 0, fmt.Errorf("expected number, got %T", v)
+	}
+	return float64(n), nil
+}
@@ -0,0 +1,223 @@
+// Copyright (c) 2015 Uber Technologies, Inc.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dynamic
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/apache/thrift/lib/go/thrift"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	tthrift "github.com/uber/tchannel-go/thrift"
+	gentest "github.com/uber/tchannel-go/thrift/gen-go/test"
+)
+
+const sharedThrift = `
+enum Color {
+  RED = 1
+  GREEN = 2
+}
+
+typedef string UUID
+
+struct Point {
+  1: required i32 x
+  2: required i32 y
+}
+
+service Base {
+  string ping()
+}
+`
+
+const testThrift = `
+include "shared.thrift"
+
+struct Data {
+  1: required bool b1,
+  2: required string s2,
+  3: required i32 i3
+}
+
+struct All {
+  1: optional shared.UUID id
+  2: optional shared.Color color
+  3: optional list<shared.Point> points
+  4: optional set<string> tags
+  5: optional map<i32, string> names
+  6: optional map<shared.Point, string> labels
+  7: optional binary raw
+  8: optional double ratio
+  9: optional i64 big
+}
+
+exception SimpleErr {
+  1: string message
+}
+
+service SimpleService extends shared.Base {
+  Data Call(1: Data arg)
+  void Simple() throws (1: SimpleErr simpleErr)
+  All Echo(1: All all)
+}
+`
+
+func parseTestIDL(t *testing.T) *IDL {
+	idl, err := ParseFiles(map[string]string{
+		"/idl/test.thrift":   testThrift,
+		"/idl/shared.thrift": sharedThrift,
+	}, "/idl/test.thrift")
+	require.NoError(t, err, "ParseFiles failed")
+	return idl
+}
+
+func writeStruct(t *testing.T, s thrift.TStruct) []byte {
+	buf := &bytes.Buffer{}
+	require.NoError(t, tthrift.WriteStruct(buf, s), "WriteStruct failed")
+	return buf.Bytes()
+}
+
+func TestMethodLookup(t *testing.T) {
+	idl := parseTestIDL(t)
+	assert.Equal(t, []string{"SimpleService"}, idl.Services())
+
+	m, err := idl.Method("SimpleService", "ping")
+	require.NoError(t, err, "inherited method lookup failed")
+	assert.Equal(t, "SimpleService", m.Service())
+	assert.Equal(t, "ping", m.Name())
+
+	_, err = idl.Method("SimpleService", "unknown")
+	assert.Error(t, err, "unknown method should fail")
+
+	_, err = idl.Method("Unknown", "Call")
+	assert.Error(t, err, "unknown service should fail")
+}
+
+func TestMatchesGeneratedCode(t *testing.T) {
+	idl := parseTestIDL(t)
+	m, err := idl.Method("SimpleService", "Call")
+	require.NoError(t, err, "Method failed")
+
+	var args map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(`{"arg": {"b1": true, "s2": "foo", "i3": 3}}`), &args))
+
+	want := writeStruct(t, &gentest.SimpleServiceCallArgs{
+		Arg: &gentest.Data{B1: true, S2: "foo", I3: 3},
+	})
+	assert.Equal(t, want, writeStruct(t, m.Args(args)), "encoded args mismatch")
+
+	result := m.Result()
+	require.NoError(t, tthrift.ReadStruct(bytes.NewReader(writeStruct(t, &gentest.SimpleServiceCallResult{
+		Success: &gentest.Data{B1: true, S2: "bar", I3: 4},
+	})), result))
+	assert.Equal(t, map[string]interface{}{
+		"success": map[string]interface{}{"b1": true, "s2": "bar", "i3": int32(4)},
+	}, result.Value)
+}
+
+func TestException(t *testing.T) {
+	idl := parseTestIDL(t)
+	m, err := idl.Method("SimpleService", "Simple")
+	require.NoError(t, err, "Method failed")
+
+	result := m.Result()
+	require.NoError(t, tthrift.ReadStruct(bytes.NewReader(writeStruct(t, &gentest.SimpleServiceSimpleResult{
+		SimpleErr: &gentest.SimpleErr{Message: "err"},
+	})), result))
+
+	assert.Equal(t, &Exception{
+		Name:  "simpleErr",
+		Value: map[string]interface{}{"message": "err"},
+	}, m.exception(result))
+}
+
+func TestRoundTrip(t *testing.T) {
+	idl := parseTestIDL(t)
+	m, err := idl.Method("SimpleService", "Echo")
+	require.NoError(t, err, "Method failed")
+
+	decoder := json.NewDecoder(bytes.NewReader([]byte(`{
+		"all": {
+			"id": "abc",
+			"color": "GREEN",
+			"points": [{"x": 1, "y": 2}],
+			"tags": ["a"],
+			"names": {"1": "one"},
+			"labels": [{"key": {"x": 3, "y": 4}, "value": "p"}],
+			"raw": "AQI=",
+			"ratio": 1.5,
+			"big": 9007199254740993
+		}
+	}`)))
+	decoder.UseNumber()
+	var args map[string]interface{}
+	require.NoError(t, decoder.Decode(&args))
+
+	decoded := m.Args(nil)
+	require.NoError(t, tthrift.ReadStruct(bytes.NewReader(writeStruct(t, m.Args(args))), decoded))
+	assert.Equal(t, map[string]interface{}{
+		"all": map[string]interface{}{
+			"id":     "abc",
+			"color":  "GREEN",
+			"points": []interface{}{map[string]interface{}{"x": int32(1), "y": int32(2)}},
+			"tags":   []interface{}{"a"},
+			"names":  map[string]interface{}{"1": "one"},
+			"labels": []interface{}{map[string]interface{}{
+				"key":   map[string]interface{}{"x": int32(3), "y": int32(4)},
+				"value": "p",
+			}},
+			"raw":   []byte{1, 2},
+			"ratio": 1.5,
+			"big":   int64(9007199254740993),
+		},
+	}, decoded.Value)
+}
+
+func TestWriteErrors(t *testing.T) {
+	idl := parseTestIDL(t)
+	m, err := idl.Method("SimpleService", "Echo")
+	require.NoError(t, err, "Method failed")
+
+	tests := []struct {
+		msg  string
+		args map[string]interface{}
+	}{
+		{"unknown field", map[string]interface{}{"unknown": 1}},
+		{"unknown nested field", map[string]interface{}{"all": map[string]interface{}{"unknown": 1}}},
+		{"wrong type", map[string]interface{}{"all": map[string]interface{}{"id": 1}}},
+		{"unknown enum", map[string]interface{}{"all": map[string]interface{}{"color": "BLUE"}}},
+		{"out of range", map[string]interface{}{"all": map[string]interface{}{"points": []interface{}{
+			map[string]interface{}{"x": int64(1 << 40)},
+		}}}},
+		{"fractional integer", map[string]interface{}{"all": map[string]interface{}{"big": 1.5}}},
+		{"bad base64", map[string]interface{}{"all": map[string]interface{}{"raw": "!"}}},
+		{"struct map key as object", map[string]interface{}{"all": map[string]interface{}{
+			"labels": map[string]interface{}{"a": "b"},
+		}}},
+	}
+
+	for _, tt := range tests {
+		err := tthrift.WriteStruct(&bytes.Buffer{}, m.Args(tt.args))
+		assert.Error(t, err, "%v: expected write to fail", tt.msg)
+	}
+}
@@ -84,6 +84,10 @@ func (s *tchanMetaServer) Methods() []string {
 	}
 }
 
+func (s *tchanMetaServer) ThriftIDL() (map[string]string, string) {
+	return tchanThriftIDLs, tchanThriftIDLEntryPoint
+}
+
 func (s *tchanMetaServer) Handle(ctx thrift.Context, methodName string, protocol athrift.TProtocol) (bool, athrift.TStruct, error) {
 	switch methodName {
 	case "health":
@@ -94,14 +98,41 @@ func (s *tchanMetaServer) Handle(ctx thrift.Context, methodName string, protocol
 	}
 }
 
+func (s *tchanMetaServer) NewArgs(methodName string) (athrift.TStruct, bool) {
+	switch methodName {
+	case "health":
+		return &MetaHealthArgs{}, true
+
+	}
+	return nil, false
+}
+
+func (s *tchanMetaServer) HandleArgs(ctx thrift.Context, methodName string, args athrift.TStruct) (bool, athrift.TStruct, error) {
+	switch methodName {
+	case "health":
+		if req, ok := args.(*MetaHealthArgs); ok {
+			return s.handleDecodedHealth(ctx, req)
+		}
+
+	default:
+		return false, nil, fmt.Errorf("method %v not found in service %v", methodName, s.Service())
+	}
+	return false, nil, fmt.Errorf("unexpected arguments %T for method %v", args, methodName)
+}
+
 func (s *tchanMetaServer) handleHealth(ctx thrift.Context, protocol athrift.TProtocol) (bool, athrift.TStruct, error) {
 	var req MetaHealthArgs
-	var res MetaHealthResult
 
 	if err := req.Read(protocol); err != nil {
 		return false, nil, err
 	}
 
+	return s.handleDecodedHealth(ctx, &req)
+}
+
+func (s *tchanMetaServer) handleDecodedHealth(ctx thrift.Context, req *MetaHealthArgs) (bool, athrift.TStruct, error) {
+	var res MetaHealthResult
+
 	r, err :=
 		s.handler.Health(ctx)
 
@@ -169,6 +200,10 @@ func (s *tchanSecondServiceServer) Methods() []string {
 	}
 }
 
+func (s *tchanSecondServiceServer) ThriftIDL() (map[string]string, string) {
+	return tchanThriftIDLs, tchanThriftIDLEntryPoint
+}
+
 func (s *tchanSecondServiceServer) Handle(ctx thrift.Context, methodName string, protocol athrift.TProtocol) (bool, athrift.TStruct, error) {
 	switch methodName {
 	case "Echo":
@@ -179,14 +214,41 @@ func (s *tchanSecondServiceServer) Handle(ctx thrift.Context, methodName string,
 	}
 }
 
+func (s *tchanSecondServiceServer) NewArgs(methodName string) (athrift.TStruct, bool) {
+	switch methodName {
+	case "Echo":
+		return &SecondServiceEchoArgs{}, true
+
+	}
+	return nil, false
+}
+
+func (s *tchanSecondServiceServer) HandleArgs(ctx thrift.Context, methodName string, args athrift.TStruct) (bool, athrift.TStruct, error) {
+	switch methodName {
+	case "Echo":
+		if req, ok := args.(*SecondServiceEchoArgs); ok {
+			return s.handleDecodedEcho(ctx, req)
+		}
+
+	default:
+		return false, nil, fmt.Errorf("method %v not found in service %v", methodName, s.Service())
+	}
+	return false, nil, fmt.Errorf("unexpected arguments %T for method %v", args, methodName)
+}
+
 func (s *tchanSecondServiceServer) handleEcho(ctx thrift.Context, protocol athrift.TProtocol) (bool, athrift.TStruct, error) {
 	var req SecondServiceEchoArgs
-	var res SecondServiceEchoResult
 
 	if err := req.Read(protocol); err != nil {
 		return false, nil, err
 	}
 
+	return s.handleDecodedEcho(ctx, &req)
+}
+
+func (s *tchanSecondServiceServer) handleDecodedEcho(ctx thrift.Context, req *SecondServiceEchoArgs) (bool, athrift.TStruct, error) {
+	var res SecondServiceEchoResult
+
 	r, err :=
 		s.handler.Echo(ctx, req.Arg)
 
@@ -290,6 +352,10 @@ func (s *tchanSimpleServiceServer) Methods() []string {
 	}
 }
 
+func (s *tchanSimpleServiceServer) ThriftIDL() (map[string]string, string) {
+	return tchanThriftIDLs, tchanThriftIDLEntryPoint
+}
+
 func (s *tchanSimpleServiceServer) Handle(ctx thrift.Context, methodName string, protocol athrift.TProtocol) (bool, athrift.TStruct, error) {
 	switch methodName {
 	case "Call":
@@ -304,14 +370,53 @@ func (s *tchanSimpleServiceServer) Handle(ctx thrift.Context, methodName string,
 	}
 }
 
+func (s *tchanSimpleServiceServer) NewArgs(methodName string) (athrift.TStruct, bool) {
+	switch methodName {
+	case "Call":
+		return &SimpleServiceCallArgs{}, true
+	case "Simple":
+		return &SimpleServiceSimpleArgs{}, true
+	case "SimpleFuture":
+		return &SimpleServiceSimpleFutureArgs{}, true
+
+	}
+	return nil, false
+}
+
+func (s *tchanSimpleServiceServer) HandleArgs(ctx thrift.Context, methodName string, args athrift.TStruct) (bool, athrift.TStruct, error) {
+	switch methodName {
+	case "Call":
+		if req, ok := args.(*SimpleServiceCallArgs); ok {
+			return s.handleDecodedCall(ctx, req)
+		}
+	case "Simple":
+		if req, ok := args.(*SimpleServiceSimpleArgs); ok {
+			return s.handleDecodedSimple(ctx, req)
+		}
+	case "SimpleFuture":
+		if req, ok := args.(*SimpleServiceSimpleFutureArgs); ok {
+			return s.handleDecodedSimpleFuture(ctx, req)
+		}
+
+	default:
+		return false, nil, fmt.Errorf("method %v not found in service %v", methodName, s.Service())
+	}
+	return false, nil, fmt.Errorf("unexpected arguments %T for method %v", args, methodName)
+}
+
 func (s *tchanSimpleServiceServer) handleCall(ctx thrift.Context, protocol athrift.TProtocol) (bool, athrift.TStruct, error) {
 	var req SimpleServiceCallArgs
-	var res SimpleServiceCallResult
 
 	if err := req.Read(protocol); err != nil {
 		return false, nil, err
 	}
 
+	return s.handleDecodedCall(ctx, &req)
+}
+
+func (s *tchanSimpleServiceServer) handleDecodedCall(ctx thrift.Context, req *SimpleServiceCallArgs) (bool, athrift.TStruct, error) {
+	var res SimpleServiceCallResult
+
 	r, err :=
 		s.handler.Call(ctx, req.Arg)
 
@@ -326,12 +431,17 @@ func (s *tchanSimpleServiceServer) handleCall(ctx thrift.Context, protocol athri
 
 func (s *tchanSimpleServiceServer) handleSimple(ctx thrift.Context, protocol athrift.TProtocol) (bool, athrift.TStruct, error) {
 	var req SimpleServiceSimpleArgs
-	var res SimpleServiceSimpleResult
 
 	if err := req.Read(protocol); err != nil {
 		return false, nil, err
 	}
 
+	return s.handleDecodedSimple(ctx, &req)
+}
+
+func (s *tchanSimpleServiceServer) handleDecodedSimple(ctx thrift.Context, req *SimpleServiceSimpleArgs) (bool, athrift.TStruct, error) {
+	var res SimpleServiceSimpleResult
+
 	err :=
 		s.handler.Simple(ctx)
 
@@ -353,12 +463,17 @@ func (s *tchanSimpleServiceServer) handleSimple(ctx thrift.Context, protocol ath
 
 func (s *tchanSimpleServiceServer) handleSimpleFuture(ctx thrift.Context, protocol athrift.TProtocol) (bool, athrift.TStruct, error) {
 	var req SimpleServiceSimpleFutureArgs
-	var res SimpleServiceSimpleFutureResult
 
 	if err := req.Read(protocol); err != nil {
 		return false, nil, err
 	}
 
+	return s.handleDecodedSimpleFuture(ctx, &req)
+}
+
+func (s *tchanSimpleServiceServer) handleDecodedSimpleFuture(ctx thrift.Context, req *SimpleServiceSimpleFutureArgs) (bool, athrift.TStruct, error) {
+	var res SimpleServiceSimpleFutureResult
+
 	err :=
 		s.handler.SimpleFuture(ctx)
 
@@ -382,3 +497,42 @@ func (s *tchanSimpleServiceServer) handleSimpleFuture(ctx thrift.Context, protoc
 
 	return err == nil, &res, nil
 }
+
+// tchanThriftIDLs are the Thrift IDL files that this package was generated from.
+var tchanThriftIDLs = map[string]string{
+	"test.thrift": "struct Data {\n" +
+		"  1: required bool b1,\n" +
+		"  2: required string s2,\n" +
+		"  3: required i32 i3\n" +
+		"}\n" +
+		"\n" +
+		"exception SimpleErr {\n" +
+		"  1: string message\n" +
+		"}\n" +
+		"\n" +
+		"exception NewErr {\n" +
+		"  1: string message\n" +
+		"}\n" +
+		"\n" +
+		"service SimpleService {\n" +
+		"  Data Call(1: Data arg)\n" +
+		"  void Simple() throws (1: SimpleErr simpleErr)\n" +
+		"  void SimpleFuture() throws (1: SimpleErr simpleErr, 2: NewErr newErr)\n" +
+		"}\n" +
+		"\n" +
+		"service SecondService {\n" +
+		"  string Echo(1: string arg)\n" +
+		"}\n" +
+		"\n" +
+		"struct HealthStatus {\n" +
+		"    1: required bool ok\n" +
+		"    2: optional string message\n" +
+		"}\n" +
+		"\n" +
+		"// Meta contains the old health endpoint without arguments.\n" +
+		"service Meta {\n" +
+		"    HealthStatus health()\n" +
+		"}",
+}
+
+const tchanThriftIDLEntryPoint = "test.thrift"
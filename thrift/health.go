@@ -0,0 +1,203 @@
+// Copyright (c) 2015 Uber Technologies, Inc.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package thrift
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+const (
+	defaultProbeTimeout  = time.Second
+	defaultProbeCacheTTL = time.Second
+)
+
+// HealthProbe checks a single component of the service's health.
+// It returns a non-nil error if the component is unhealthy.
+type HealthProbe func(ctx Context) error
+
+// HealthProbeOptions are options for a registered HealthProbe.
+type HealthProbeOptions struct {
+	// Timeout is the maximum time the probe may run for before it is
+	// considered failed. The default timeout is 1 second.
+	Timeout time.Duration
+
+	// CacheTTL is how long the result of the probe is reused for by
+	// subsequent health checks. The default is 1 second, and a negative
+	// value disables caching.
+	CacheTTL time.Duration
+}
+
+// probeResult is the result of a single run of a probe.
+type probeResult struct {
+	err     error
+	checked time.Time
+}
+
+type healthProbe struct {
+	name  string
+	probe HealthProbe
+	opts  HealthProbeOptions
+
+	mut  sync.Mutex
+	last *probeResult
+}
+
+// healthRegistry contains the liveness and readiness probes registered on a Server.
+// Liveness probes are used by Process health checks, while Traffic health checks
+// use both liveness and readiness probes.
+type healthRegistry struct {
+	sync.RWMutex
+
+	timeNow   func() time.Time
+	liveness  map[string]*healthProbe
+	readiness map[string]*healthProbe
+}
+
+func newHealthRegistry() *healthRegistry {
+	return &healthRegistry{
+		timeNow:   time.Now,
+		liveness:  make(map[string]*healthProbe),
+		readiness: make(map[string]*healthProbe),
+	}
+}
+
+func newHealthProbe(name string, probe HealthProbe, opts *HealthProbeOptions) *healthProbe {
+	p := &healthProbe{name: name, probe: probe}
+	if opts != nil {
+		p.opts = *opts
+	}
+	if p.opts.Timeout <= 0 {
+		p.opts.Timeout = defaultProbeTimeout
+	}
+	if p.opts.CacheTTL == 0 {
+		p.opts.CacheTTL = defaultProbeCacheTTL
+	}
+	return p
+}
+
+func (r *healthRegistry) addLiveness(name string, probe HealthProbe, opts *HealthProbeOptions) {
+	r.Lock()
+	r.liveness[name] = newHealthProbe(name, probe, opts)
+	r.Unlock()
+}
+
+func (r *healthRegistry) addReadiness(name string, probe HealthProbe, opts *HealthProbeOptions) {
+	r.Lock()
+	r.readiness[name] = newHealthProbe(name, probe, opts)
+	r.Unlock()
+}
+
+// probes returns the probes used for the given type of health check.
+func (r *healthRegistry) probes(t HealthRequestType) []*healthProbe {
+	r.RLock()
+	defer r.RUnlock()
+
+	probes := make([]*healthProbe, 0, len(r.liveness)+len(r.readiness))
+	for _, p := range r.liveness {
+		probes = append(probes, p)
+	}
+	if t == Traffic {
+		for _, p := range r.readiness {
+			probes = append(probes, p)
+		}
+	}
+	sort.Slice(probes, func(i, j int) bool {
+		return probes[i].name < probes[j].name
+	})
+	return probes
+}
+
+// check runs all the probes for the given type of health check concurrently.
+// It returns whether all probes succeeded, and a message describing any failures.
+func (r *healthRegistry) check(ctx Context, t HealthRequestType) (ok bool, message string) {
+	probes := r.probes(t)
+	errs := make([]error, len(probes))
+
+	var wg sync.WaitGroup
+	for i, p := range probes {
+		wg.Add(1)
+		go func(i int, p *healthProbe) {
+			defer wg.Done()
+			errs[i] = p.check(ctx, r.timeNow)
+		}(i, p)
+	}
+	wg.Wait()
+
+	var failures []string
+	for i, err := range errs {
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%v: %v", probes[i].name, err))
+		}
+	}
+	return len(failures) == 0, strings.Join(failures, "; ")
+}
+
+// check returns the cached result of the probe if it is still valid,
+// otherwise it runs the probe.
+func (p *healthProbe) check(ctx Context, timeNow func() time.Time) error {
+	p.mut.Lock()
+	last := p.last
+	p.mut.Unlock()
+
+	if last != nil && timeNow().Sub(last.checked) < p.opts.CacheTTL {
+		return last.err
+	}
+
+	err := p.run(ctx)
+
+	// If the caller's context ended, the probe may not have had its full
+	// timeout, so the result is not cached.
+	if ctx.Err() != nil {
+		return err
+	}
+
+	p.mut.Lock()
+	p.last = &probeResult{err: err, checked: timeNow()}
+	p.mut.Unlock()
+	return err
+}
+
+// run runs the probe, failing it if it does not complete within the timeout.
+func (p *healthProbe) run(ctx Context) error {
+	probeCtx, cancel := context.WithTimeout(ctx, p.opts.Timeout)
+	defer cancel()
+
+	errC := make(chan error, 1)
+	go func() {
+		errC <- p.probe(Wrap(probeCtx))
+	}()
+
+	select {
+	case err := <-errC:
+		return err
+	case <-probeCtx.Done():
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		return fmt.Errorf("timed out after %v", p.opts.Timeout)
+	}
+}
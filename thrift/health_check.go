@@ -0,0 +1,71 @@
+// Copyright (c) 2015 Uber Technologies, Inc.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package thrift
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/uber/tchannel-go"
+	"github.com/uber/tchannel-go/thrift/gen-go/meta"
+
+	"golang.org/x/net/context"
+)
+
+// HealthCheck returns a tchannel.PeerHealthCheckFunc that makes a TRAFFIC health
+// request to the Meta::health endpoint of serviceName on each peer. A peer is
+// unhealthy if the request fails, or the peer reports that it is not ok.
+//
+// Use it to enable health checks on a PeerList:
+//
+//	ch.Peers().SetHealthChecks(tchannel.PeerHealthCheckOptions{
+//	  Check:    thrift.HealthCheck(ch, "service"),
+//	  Interval: time.Second,
+//	})
+func HealthCheck(ch *tchannel.Channel, serviceName string) tchannel.PeerHealthCheckFunc {
+	return func(ctx context.Context, peer *tchannel.Peer) error {
+		timeout := time.Second
+		if deadline, ok := ctx.Deadline(); ok {
+			timeout = deadline.Sub(time.Now())
+		}
+
+		// Health checks must only be sent to the given peer, and should not be retried.
+		checkCtx, cancel := tchannel.NewContextBuilder(timeout).
+			SetParentContext(ctx).
+			SetRetryOptions(&tchannel.RetryOptions{RetryOn: tchannel.RetryNever}).
+			Build()
+		defer cancel()
+
+		client := newTChanMetaClient(NewClient(ch, serviceName, &ClientOptions{
+			HostPort: peer.HostPort(),
+		}))
+		status, err := client.Health(checkCtx, &meta.HealthRequest{
+			Type: meta.HealthRequestTypePtr(meta.HealthRequestType_TRAFFIC),
+		})
+		if err != nil {
+			return err
+		}
+		if !status.Ok {
+			return fmt.Errorf("peer reported unhealthy: %v", status.GetMessage())
+		}
+		return nil
+	}
+}
@@ -44,3 +44,27 @@ type TChanServer interface {
 	// Methods returns the method names handled by this server.
 	Methods() []string
 }
+
+// TChanArgsServer is implemented by generated servers that can decode the
+// arguments separately from handling the call, which allows Middleware to
+// access the decoded request.
+type TChanArgsServer interface {
+	TChanServer
+
+	// NewArgs returns an empty arguments struct for the given method.
+	NewArgs(methodName string) (args athrift.TStruct, ok bool)
+
+	// HandleArgs handles a call using arguments returned by NewArgs.
+	// The values returned are the same as Handle.
+	HandleArgs(ctx Context, methodName string, args athrift.TStruct) (success bool, resp athrift.TStruct, err error)
+}
+
+// TChanIDLServer is implemented by generated servers that embed the Thrift IDL
+// they were generated from, which is returned by the meta ThriftIDL endpoint.
+type TChanIDLServer interface {
+	TChanServer
+
+	// ThriftIDL returns a map from filename to the contents for the IDL and
+	// all the files it includes, and the filename of the entry point.
+	ThriftIDL() (idls map[string]string, entryPoint string)
+}
@@ -22,13 +22,21 @@ package thrift
 
 import (
 	"errors"
+	"fmt"
+	"reflect"
 	"runtime"
 	"strings"
+	"sync"
 
 	"github.com/uber/tchannel-go"
 	"github.com/uber/tchannel-go/thrift/gen-go/meta"
 )
 
+var (
+	errNoThriftIDL        = errors.New("no registered services include a Thrift IDL")
+	errMultipleThriftIDLs = errors.New("registered services include different Thrift IDLs, use Server.SetThriftIDLService to choose one")
+)
+
 // HealthFunc is the interface for custom health endpoints.
 // ok is whether the service health is OK, and message is optional additional information for the health result.
 type HealthFunc func(ctx Context) (ok bool, message string)
@@ -60,25 +68,151 @@ type HealthRequestFunc func(Context, HealthRequest) (ok bool, message string)
 // healthHandler implements the default health check enpoint.
 type metaHandler struct {
 	healthFn HealthRequestFunc
+	health   *healthRegistry
+	chState  func() tchannel.ChannelState
+
+	idlMut     sync.RWMutex
+	idls       map[string]serviceIDL
+	idlService string
+}
+
+// serviceIDL is the Thrift IDL embedded in a registered service.
+type serviceIDL struct {
+	files      map[string]string
+	entryPoint string
 }
 
 // newMetaHandler return a new HealthHandler instance.
-func newMetaHandler() *metaHandler {
-	return &metaHandler{healthFn: defaultHealth}
+func newMetaHandler(registrar tchannel.Registrar) *metaHandler {
+	h := &metaHandler{
+		healthFn: defaultHealth,
+		health:   newHealthRegistry(),
+	}
+	if ch, ok := registrar.(channelStater); ok {
+		h.chState = ch.State
+	}
+	return h
 }
 
-// Health returns true as default Health endpoint.
+// channelStater is implemented by a Registrar that can report the state of the channel.
+type channelStater interface {
+	State() tchannel.ChannelState
+}
+
+// Health aggregates the health handler and registered probes. Once the channel
+// starts closing, the state is reported as STOPPING and Traffic checks fail.
 func (h *metaHandler) Health(ctx Context, req *meta.HealthRequest) (*meta.HealthStatus, error) {
-	ok, message := h.healthFn(ctx, metaReqToReq(req))
-	if message == "" {
-		return &meta.HealthStatus{Ok: ok}, nil
+	r := metaReqToReq(req)
+	ok, message := h.healthFn(ctx, r)
+	probesOK, probesMessage := h.health.check(ctx, r.Type)
+	ok = ok && probesOK
+	message = joinMessages(message, probesMessage)
+
+	state := meta.HealthState_REFUSING
+	if ok {
+		state = meta.HealthState_ACCEPTING
+	}
+	if stopState, stopping := h.stopState(); stopping {
+		state = stopState
+		if r.Type == Traffic {
+			ok = false
+			message = joinMessages(message, "channel is "+strings.ToLower(stopState.String()))
+		}
+	}
+
+	status := &meta.HealthStatus{Ok: ok, State: &state}
+	if message != "" {
+		status.Message = &message
+	}
+	return status, nil
+}
+
+// stopState returns the health state if the channel is closing.
+func (h *metaHandler) stopState() (meta.HealthState, bool) {
+	if h.chState == nil {
+		return 0, false
+	}
+	switch h.chState() {
+	case tchannel.ChannelStartClose, tchannel.ChannelInboundClosed:
+		return meta.HealthState_STOPPING, true
+	case tchannel.ChannelClosed:
+		return meta.HealthState_STOPPED, true
+	}
+	return 0, false
+}
+
+func joinMessages(m1, m2 string) string {
+	switch {
+	case m1 == "":
+		return m2
+	case m2 == "":
+		return m1
 	}
-	return &meta.HealthStatus{Ok: ok, Message: &message}, nil
+	return m1 + "; " + m2
 }
 
 func (h *metaHandler) ThriftIDL(ctx Context) (*meta.ThriftIDLs, error) {
-	// TODO(prashant): Add thriftIDL to the generated code.
-	return nil, errors.New("unimplemented")
+	h.idlMut.RLock()
+	defer h.idlMut.RUnlock()
+
+	idl, err := h.selectedIDL()
+	if err != nil {
+		return nil, err
+	}
+
+	idls := make(map[meta.Filename]string, len(idl.files))
+	for filename, contents := range idl.files {
+		idls[meta.Filename(filename)] = contents
+	}
+	return &meta.ThriftIDLs{
+		Idls:       idls,
+		EntryPoint: meta.Filename(idl.entryPoint),
+	}, nil
+}
+
+// selectedIDL returns the IDL of the service set using setIDLService. If no
+// service is set, all services with an IDL must have the same IDL, as they do
+// when they are generated from the same file. It must be called with idlMut held.
+func (h *metaHandler) selectedIDL() (serviceIDL, error) {
+	if h.idlService != "" {
+		idl, ok := h.idls[h.idlService]
+		if !ok {
+			return serviceIDL{}, fmt.Errorf("service %v does not include a Thrift IDL", h.idlService)
+		}
+		return idl, nil
+	}
+
+	if len(h.idls) == 0 {
+		return serviceIDL{}, errNoThriftIDL
+	}
+
+	var selected *serviceIDL
+	for _, idl := range h.idls {
+		if selected != nil && !reflect.DeepEqual(*selected, idl) {
+			return serviceIDL{}, errMultipleThriftIDLs
+		}
+		idl := idl
+		selected = &idl
+	}
+	return *selected, nil
+}
+
+// addIDL adds the IDL files for a registered service. Each service's IDL is
+// kept separately, since services may use the same filenames.
+func (h *metaHandler) addIDL(service string, files map[string]string, entryPoint string) {
+	h.idlMut.Lock()
+	defer h.idlMut.Unlock()
+
+	if h.idls == nil {
+		h.idls = make(map[string]serviceIDL)
+	}
+	h.idls[service] = serviceIDL{files: files, entryPoint: entryPoint}
+}
+
+func (h *metaHandler) setIDLService(service string) {
+	h.idlMut.Lock()
+	defer h.idlMut.Unlock()
+	h.idlService = service
 }
 
 func (h *metaHandler) VersionInfo(ctx Context) (*meta.VersionInfo, error) {
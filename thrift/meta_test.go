@@ -21,6 +21,8 @@
 package thrift
 
 import (
+	"errors"
+	"fmt"
 	"runtime"
 	"strings"
 	"testing"
@@ -32,13 +34,79 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
 )
 
+type idlServer struct {
+	TChanServer
+
+	service    string
+	idls       map[string]string
+	entryPoint string
+}
+
+func (s idlServer) Service() string   { return s.service }
+func (s idlServer) Methods() []string { return nil }
+func (s idlServer) ThriftIDL() (map[string]string, string) {
+	return s.idls, s.entryPoint
+}
+
 func TestThriftIDL(t *testing.T) {
 	withMetaSetup(t, func(ctx Context, c tchanMeta, server *Server) {
 		_, err := c.ThriftIDL(ctx)
-		assert.Error(t, err, "Health endpoint failed")
-		assert.Contains(t, err.Error(), "unimplemented")
+		assert.Error(t, err, "ThriftIDL should fail with no IDLs registered")
+		assert.Contains(t, err.Error(), errNoThriftIDL.Error())
+
+		s1IDL := idlServer{
+			service:    "s1",
+			idls:       map[string]string{"s1.thrift": "include \"base.thrift\"", "base.thrift": "s1 base"},
+			entryPoint: "s1.thrift",
+		}
+		server.Register(s1IDL)
+		s1IDL.service = "s1-other"
+		server.Register(s1IDL)
+
+		// Services generated from the same IDL don't need a service chosen.
+		ret, err := c.ThriftIDL(ctx)
+		require.NoError(t, err, "ThriftIDL failed")
+		assert.Equal(t, &meta.ThriftIDLs{
+			Idls: map[meta.Filename]string{
+				"s1.thrift":   "include \"base.thrift\"",
+				"base.thrift": "s1 base",
+			},
+			EntryPoint: "s1.thrift",
+		}, ret, "Unexpected IDLs")
+
+		// Both services have a base.thrift, which should not be overwritten.
+		server.Register(idlServer{
+			service:    "s2",
+			idls:       map[string]string{"s2.thrift": "include \"base.thrift\"", "base.thrift": "s2 base"},
+			entryPoint: "s2.thrift",
+		})
+		_, err = c.ThriftIDL(ctx)
+		require.Error(t, err, "ThriftIDL should fail without choosing a service")
+		assert.Contains(t, err.Error(), errMultipleThriftIDLs.Error())
+
+		server.SetThriftIDLService("s2")
+		ret, err = c.ThriftIDL(ctx)
+		require.NoError(t, err, "ThriftIDL failed")
+		assert.Equal(t, &meta.ThriftIDLs{
+			Idls: map[meta.Filename]string{
+				"s2.thrift":   "include \"base.thrift\"",
+				"base.thrift": "s2 base",
+			},
+			EntryPoint: "s2.thrift",
+		}, ret, "Unexpected IDLs")
+
+		server.SetThriftIDLService("s1")
+		ret, err = c.ThriftIDL(ctx)
+		require.NoError(t, err, "ThriftIDL failed")
+		assert.Equal(t, "s1 base", ret.Idls["base.thrift"], "Unexpected IDL for s1")
+
+		server.SetThriftIDLService("unknown")
+		_, err = c.ThriftIDL(ctx)
+		require.Error(t, err, "ThriftIDL should fail for a service without an IDL")
+		assert.Contains(t, err.Error(), "unknown does not include a Thrift IDL")
 	})
 }
 
@@ -126,6 +194,260 @@ func TestHealth(t *testing.T) {
 	}
 }
 
+func TestHealthProbes(t *testing.T) {
+	withMetaSetup(t, func(ctx Context, c tchanMeta, server *Server) {
+		var dbErr, warmupErr error = errors.New("connection refused"), errors.New("warming up")
+		noCache := &HealthProbeOptions{CacheTTL: -1}
+		server.RegisterLivenessProbe("db", func(Context) error { return dbErr }, noCache)
+		server.RegisterReadinessProbe("warmup", func(Context) error { return warmupErr }, noCache)
+
+		processReq := &meta.HealthRequest{}
+		trafficReq := &meta.HealthRequest{Type: meta.HealthRequestTypePtr(meta.HealthRequestType_TRAFFIC)}
+
+		tests := []struct {
+			msg         string
+			dbErr       error
+			warmupErr   error
+			req         *meta.HealthRequest
+			wantOK      bool
+			wantMessage *string
+			wantState   meta.HealthState
+		}{
+			{
+				msg:         "process check with failing liveness probe",
+				dbErr:       dbErr,
+				warmupErr:   warmupErr,
+				req:         processReq,
+				wantMessage: stringPtr("db: connection refused"),
+				wantState:   meta.HealthState_REFUSING,
+			},
+			{
+				msg:         "traffic check with failing probes",
+				dbErr:       dbErr,
+				warmupErr:   warmupErr,
+				req:         trafficReq,
+				wantMessage: stringPtr("db: connection refused; warmup: warming up"),
+				wantState:   meta.HealthState_REFUSING,
+			},
+			{
+				msg:       "process check ignores readiness probe",
+				warmupErr: warmupErr,
+				req:       processReq,
+				wantOK:    true,
+				wantState: meta.HealthState_ACCEPTING,
+			},
+			{
+				msg:         "traffic check with failing readiness probe",
+				warmupErr:   warmupErr,
+				req:         trafficReq,
+				wantMessage: stringPtr("warmup: warming up"),
+				wantState:   meta.HealthState_REFUSING,
+			},
+			{
+				msg:       "traffic check with passing probes",
+				req:       trafficReq,
+				wantOK:    true,
+				wantState: meta.HealthState_ACCEPTING,
+			},
+		}
+
+		for _, tt := range tests {
+			dbErr, warmupErr = tt.dbErr, tt.warmupErr
+			ret, err := c.Health(ctx, tt.req)
+			require.NoError(t, err, "%v: Health endpoint failed", tt.msg)
+			assert.Equal(t, tt.wantOK, ret.Ok, "%v: Health status mismatch", tt.msg)
+			assert.Equal(t, tt.wantMessage, ret.Message, "%v: Health message mismatch", tt.msg)
+			assert.Equal(t, &tt.wantState, ret.State, "%v: Health state mismatch", tt.msg)
+		}
+	})
+}
+
+func TestHealthProbeWithHandler(t *testing.T) {
+	withMetaSetup(t, func(ctx Context, c tchanMeta, server *Server) {
+		server.RegisterHealthHandler(func(Context) (bool, string) {
+			return false, "handler failed"
+		})
+		server.RegisterLivenessProbe("db", func(Context) error {
+			return errors.New("connection refused")
+		}, nil)
+
+		ret, err := c.Health(ctx, &meta.HealthRequest{})
+		require.NoError(t, err, "Health endpoint failed")
+		assert.False(t, ret.Ok, "Health status mismatch")
+		assert.Equal(t, stringPtr("handler failed; db: connection refused"), ret.Message, "Health message mismatch")
+	})
+}
+
+func TestHealthProbeTimeout(t *testing.T) {
+	withMetaSetup(t, func(ctx Context, c tchanMeta, server *Server) {
+		server.RegisterLivenessProbe("slow", func(ctx Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}, &HealthProbeOptions{Timeout: testutils.Timeout(10 * time.Millisecond)})
+
+		ret, err := c.Health(ctx, &meta.HealthRequest{})
+		require.NoError(t, err, "Health endpoint failed")
+		assert.False(t, ret.Ok, "Health status mismatch")
+		require.NotNil(t, ret.Message, "Missing health message")
+		assert.Contains(t, *ret.Message, "slow: timed out after", "Health message mismatch")
+	})
+}
+
+func TestHealthProbeCache(t *testing.T) {
+	withMetaSetup(t, func(ctx Context, c tchanMeta, server *Server) {
+		now := time.Unix(1000, 0)
+		server.metaHandler.health.timeNow = func() time.Time { return now }
+
+		var calls int
+		server.RegisterLivenessProbe("counter", func(Context) error {
+			calls++
+			return nil
+		}, &HealthProbeOptions{CacheTTL: time.Minute})
+
+		for i := 0; i < 3; i++ {
+			_, err := c.Health(ctx, &meta.HealthRequest{})
+			require.NoError(t, err, "Health endpoint failed")
+		}
+		assert.Equal(t, 1, calls, "Probe result should be cached")
+
+		now = now.Add(time.Minute)
+		_, err := c.Health(ctx, &meta.HealthRequest{})
+		require.NoError(t, err, "Health endpoint failed")
+		assert.Equal(t, 2, calls, "Probe should run after the cache expires")
+	})
+}
+
+func TestHealthProbeCacheCanceled(t *testing.T) {
+	var calls atomic.Int32
+	block := atomic.NewBool(true)
+	p := newHealthProbe("counter", func(ctx Context) error {
+		calls.Inc()
+		if block.Load() {
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		return nil
+	}, &HealthProbeOptions{Timeout: time.Second, CacheTTL: time.Minute})
+
+	ctx, cancel := NewContext(testutils.Timeout(10 * time.Millisecond))
+	defer cancel()
+	assert.Error(t, p.check(ctx, time.Now), "Probe should fail when the caller's context ends")
+	assert.Nil(t, p.last, "Result should not be cached when the caller's context ends")
+
+	block.Store(false)
+	ctx, cancel = NewContext(time.Second)
+	defer cancel()
+	assert.NoError(t, p.check(ctx, time.Now), "Probe should run again")
+	assert.EqualValues(t, 2, calls.Load(), "Probe should not use the result from the canceled check")
+}
+
+func TestHealthChannelClosing(t *testing.T) {
+	tests := []struct {
+		chState     tchannel.ChannelState
+		req         HealthRequestType
+		wantOK      bool
+		wantMessage *string
+		wantState   meta.HealthState
+	}{
+		{
+			chState:   tchannel.ChannelListening,
+			req:       Traffic,
+			wantOK:    true,
+			wantState: meta.HealthState_ACCEPTING,
+		},
+		{
+			chState:   tchannel.ChannelStartClose,
+			req:       Process,
+			wantOK:    true,
+			wantState: meta.HealthState_STOPPING,
+		},
+		{
+			chState:     tchannel.ChannelStartClose,
+			req:         Traffic,
+			wantMessage: stringPtr("channel is stopping"),
+			wantState:   meta.HealthState_STOPPING,
+		},
+		{
+			chState:     tchannel.ChannelInboundClosed,
+			req:         Traffic,
+			wantMessage: stringPtr("channel is stopping"),
+			wantState:   meta.HealthState_STOPPING,
+		},
+		{
+			chState:     tchannel.ChannelClosed,
+			req:         Traffic,
+			wantMessage: stringPtr("channel is stopped"),
+			wantState:   meta.HealthState_STOPPED,
+		},
+	}
+
+	ctx, cancel := NewContext(time.Second)
+	defer cancel()
+
+	for _, tt := range tests {
+		h := newMetaHandler(nil /* registrar */)
+		h.chState = func() tchannel.ChannelState { return tt.chState }
+
+		reqType := meta.HealthRequestType(tt.req)
+		ret, err := h.Health(ctx, &meta.HealthRequest{Type: &reqType})
+		require.NoError(t, err, "Health failed")
+
+		msg := fmt.Sprintf("%v %v check", tt.chState, tt.req)
+		assert.Equal(t, tt.wantOK, ret.Ok, "%v: Health status mismatch", msg)
+		assert.Equal(t, tt.wantMessage, ret.Message, "%v: Health message mismatch", msg)
+		assert.Equal(t, &tt.wantState, ret.State, "%v: Health state mismatch", msg)
+	}
+}
+
+func TestHealthDrainingChannel(t *testing.T) {
+	ch := testutils.NewServer(t, testutils.NewOpts().SetServiceName("meta"))
+	server := NewServer(ch)
+
+	ctx, cancel := NewContext(time.Second)
+	defer cancel()
+
+	ret, err := server.metaHandler.Health(ctx, &meta.HealthRequest{})
+	require.NoError(t, err, "Health failed")
+	assert.Equal(t, meta.HealthState_ACCEPTING, ret.GetState(), "Unexpected state before Close")
+
+	ch.Close()
+	ret, err = server.metaHandler.Health(ctx, &meta.HealthRequest{})
+	require.NoError(t, err, "Health failed")
+	assert.Equal(t, meta.HealthState_STOPPED, ret.GetState(), "Unexpected state after Close")
+}
+
+func TestHealthCheck(t *testing.T) {
+	serverCh, server := setupMetaServer(t)
+	defer serverCh.Close()
+
+	var ready bool
+	server.RegisterReadinessProbe("warmup", func(Context) error {
+		if !ready {
+			return errors.New("warming up")
+		}
+		return nil
+	}, &HealthProbeOptions{CacheTTL: -1})
+
+	clientCh := testutils.NewClient(t, nil)
+	defer clientCh.Close()
+
+	check := HealthCheck(clientCh, "meta")
+	peer := clientCh.Peers().Add(serverCh.PeerInfo().HostPort)
+
+	ctx, cancel := NewContext(time.Second)
+	defer cancel()
+
+	err := check(ctx, peer)
+	require.Error(t, err, "Health check should fail while not ready")
+	assert.Contains(t, err.Error(), "warmup: warming up", "Unexpected error")
+
+	ready = true
+	assert.NoError(t, check(ctx, peer), "Health check should pass once ready")
+
+	serverCh.Close()
+	assert.Error(t, check(ctx, peer), "Health check should fail once the server is closed")
+}
+
 func TestMetaReqToReq(t *testing.T) {
 	tests := []struct {
 		msg  string
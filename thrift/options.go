@@ -45,3 +45,36 @@ func OptPostResponse(cb PostResponseCB) RegisterOption {
 func (o optPostResponse) Apply(h *handler) {
 	h.postResponseCB = PostResponseCB(o)
 }
+
+// Middleware intercepts calls handled by a Thrift server, with access to the
+// decoded request and the response. It can be used for validation, auditing
+// or caching.
+//
+// The decoded request is only available for services generated by a version
+// of thrift-gen that implements TChanArgsServer, otherwise it is nil.
+type Middleware interface {
+	// Before is called with the decoded request before the handler.
+	// To skip the handler, return a non-nil response or error. The response
+	// should be the method's result struct, and success should be false if
+	// the result contains an exception. An error is returned to the caller
+	// as a system error.
+	Before(ctx Context, service, method string, req thrift.TStruct) (success bool, resp thrift.TStruct, err error)
+
+	// After is called with the result of the handler, or the result
+	// returned by Before, and returns the result to use for the response.
+	After(ctx Context, service, method string, success bool, resp thrift.TStruct, err error) (bool, thrift.TStruct, error)
+}
+
+type optMiddleware []Middleware
+
+// OptMiddleware registers Middleware for the service. Before is called in the
+// order that the middleware is specified, and After in the reverse order.
+// If Before skips the handler, only the middleware that has already run
+// Before has After called.
+func OptMiddleware(middleware ...Middleware) RegisterOption {
+	return optMiddleware(middleware)
+}
+
+func (o optMiddleware) Apply(h *handler) {
+	h.middleware = append(h.middleware, o...)
+}
@@ -21,6 +21,8 @@
 package thrift
 
 import (
+	"io"
+	"io/ioutil"
 	"log"
 	"strings"
 	"sync"
@@ -35,6 +37,7 @@ import (
 type handler struct {
 	server         TChanServer
 	postResponseCB PostResponseCB
+	middleware     []Middleware
 }
 
 // Server handles incoming TChannel calls and forwards them to the matching TChanServer.
@@ -49,7 +52,7 @@ type Server struct {
 
 // NewServer returns a server that can serve thrift services over TChannel.
 func NewServer(registrar tchannel.Registrar) *Server {
-	metaHandler := newMetaHandler()
+	metaHandler := newMetaHandler(registrar)
 	server := &Server{
 		ch:          registrar,
 		log:         registrar.Logger(),
@@ -78,11 +81,23 @@ func (s *Server) Register(svr TChanServer, opts ...RegisterOption) {
 	s.handlers[service] = *handler
 	s.Unlock()
 
+	if idlServer, ok := svr.(TChanIDLServer); ok {
+		idls, entryPoint := idlServer.ThriftIDL()
+		s.metaHandler.addIDL(service, idls, entryPoint)
+	}
+
 	for _, m := range svr.Methods() {
 		s.ch.Register(s, service+"::"+m)
 	}
 }
 
+// SetThriftIDLService sets the service whose Thrift IDL is returned by the
+// meta ThriftIDL endpoint. It's required when registered services include
+// different Thrift IDLs.
+func (s *Server) SetThriftIDLService(service string) {
+	s.metaHandler.setIDLService(service)
+}
+
 // RegisterHealthHandler uses the user-specified function f for the Health endpoint.
 func (s *Server) RegisterHealthHandler(f HealthFunc) {
 	wrapped := func(ctx Context, r HealthRequest) (bool, string) {
@@ -98,6 +113,19 @@ func (s *Server) RegisterHealthRequestHandler(f HealthRequestFunc) {
 	s.metaHandler.setHandler(f)
 }
 
+// RegisterLivenessProbe registers a named probe that is checked by both Process
+// and Traffic health checks. Registering a probe with an existing name replaces it.
+func (s *Server) RegisterLivenessProbe(name string, probe HealthProbe, opts *HealthProbeOptions) {
+	s.metaHandler.health.addLiveness(name, probe, opts)
+}
+
+// RegisterReadinessProbe registers a named probe that is only checked by Traffic
+// health checks, such as a probe that fails until the service has warmed up.
+// Registering a probe with an existing name replaces it.
+func (s *Server) RegisterReadinessProbe(name string, probe HealthProbe, opts *HealthProbeOptions) {
+	s.metaHandler.health.addReadiness(name, probe, opts)
+}
+
 // SetContextFn sets the function used to convert a context.Context to a thrift.Context.
 // Note: This API may change and is only intended to bridge different contexts.
 func (s *Server) SetContextFn(f func(ctx context.Context, method string, headers map[string]string) Context) {
@@ -159,9 +187,22 @@ func (s *Server) handle(origCtx context.Context, handler handler, method string,
 	origCtx = tchannel.ExtractInboundSpan(origCtx, call, headers, tracer)
 	ctx := s.ctxFn(origCtx, method, headers)
 
-	wp := getProtocolReader(reader)
-	success, resp, err := handler.server.Handle(ctx, method, wp.protocol)
-	thriftProtocolPool.Put(wp)
+	var (
+		protocol = protocolForFormat(call.Format())
+
+		success bool
+		resp    thrift.TStruct
+	)
+	if len(handler.middleware) > 0 {
+		success, resp, err = handler.handleWithMiddleware(ctx, method, protocol, reader)
+		if err == nil && resp == nil {
+			err = tchannel.NewSystemError(tchannel.ErrCodeUnexpected, "middleware returned nil response")
+		}
+	} else {
+		wp := getProtocolReader(protocol, reader)
+		success, resp, err = handler.server.Handle(ctx, method, wp.protocol)
+		putProtocol(wp, err)
+	}
 
 	if handler.postResponseCB != nil {
 		defer handler.postResponseCB(ctx, method, resp)
@@ -203,10 +244,10 @@ func (s *Server) handle(origCtx context.Context, handler handler, method string,
 
 	writer, err = call.Response().Arg3Writer()
 
-	wp = getProtocolWriter(writer)
-	defer thriftProtocolPool.Put(wp)
-
-	if err := resp.Write(wp.protocol); err != nil {
+	wp := getProtocolWriter(protocol, writer)
+	err = resp.Write(wp.protocol)
+	putProtocol(wp, err)
+	if err != nil {
 		call.Response().SendSystemError(err)
 		return err
 	}
@@ -214,6 +255,61 @@ func (s *Server) handle(origCtx context.Context, handler handler, method string,
 	return writer.Close()
 }
 
+// handleWithMiddleware decodes the request if the server supports it, and
+// runs the handler wrapped by the middleware.
+func (h handler) handleWithMiddleware(ctx Context, method string, protocol Protocol, reader io.Reader) (bool, thrift.TStruct, error) {
+	var (
+		service       = h.server.Service()
+		argsServer, _ = h.server.(TChanArgsServer)
+
+		req thrift.TStruct
+		err error
+	)
+	if argsServer != nil {
+		var ok bool
+		if req, ok = argsServer.NewArgs(method); !ok {
+			return false, nil, tchannel.NewSystemError(tchannel.ErrCodeBadRequest, "method %v not found in service %v", method, service)
+		}
+
+		if err = readStruct(protocol, reader, req); err != nil {
+			return false, nil, err
+		}
+	}
+
+	var (
+		success bool
+		resp    thrift.TStruct
+		ran     int
+	)
+	for _, m := range h.middleware {
+		ran++
+		if success, resp, err = m.Before(ctx, service, method, req); resp != nil || err != nil {
+			break
+		}
+	}
+
+	if resp == nil && err == nil {
+		if argsServer != nil {
+			success, resp, err = argsServer.HandleArgs(ctx, method, req)
+		} else {
+			wp := getProtocolReader(protocol, reader)
+			success, resp, err = h.server.Handle(ctx, method, wp.protocol)
+			putProtocol(wp, err)
+		}
+	} else if argsServer == nil {
+		// The middleware skipped the handler before the request was read, so
+		// discard it to leave the reader empty.
+		if _, discardErr := io.Copy(ioutil.Discard, reader); discardErr != nil {
+			return false, nil, discardErr
+		}
+	}
+
+	for i := ran - 1; i >= 0; i-- {
+		success, resp, err = h.middleware[i].After(ctx, service, method, success, resp, err)
+	}
+	return success, resp, err
+}
+
 func getServiceMethod(method string) (string, string, bool) {
 	s := string(method)
 	sep := strings.Index(s, "::")
@@ -28,16 +28,24 @@ import (
 
 // WriteStruct writes the given Thrift struct to a writer. It pools TProtocols.
 func WriteStruct(writer io.Writer, s thrift.TStruct) error {
-	wp := getProtocolWriter(writer)
-	err := s.Write(wp.protocol)
-	thriftProtocolPool.Put(wp)
-	return err
+	return writeStruct(BinaryProtocol, writer, s)
 }
 
 // ReadStruct reads the given Thrift struct. It pools TProtocols.
 func ReadStruct(reader io.Reader, s thrift.TStruct) error {
-	wp := getProtocolReader(reader)
+	return readStruct(BinaryProtocol, reader, s)
+}
+
+func writeStruct(p Protocol, writer io.Writer, s thrift.TStruct) error {
+	wp := getProtocolWriter(p, writer)
+	err := s.Write(wp.protocol)
+	putProtocol(wp, err)
+	return err
+}
+
+func readStruct(p Protocol, reader io.Reader, s thrift.TStruct) error {
+	wp := getProtocolReader(p, reader)
 	err := s.Read(wp.protocol)
-	thriftProtocolPool.Put(wp)
+	putProtocol(wp, err)
 	return err
 }
@@ -0,0 +1,182 @@
+// Copyright (c) 2015 Uber Technologies, Inc.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Annotations on methods that set the options used by the generated client.
+const (
+	timeoutAnnotation       = "tchannel.timeout"
+	retryAnnotation         = "tchannel.retry"
+	shardKeyFieldAnnotation = "tchannel.shard_key_field"
+)
+
+// retryOnValues maps values of the retry annotation to tchannel.RetryOn values.
+var retryOnValues = map[string]string{
+	"connection":    "RetryConnectionError",
+	"never":         "RetryNever",
+	"nonIdempotent": "RetryNonIdempotent",
+	"unexpected":    "RetryUnexpected",
+	"idempotent":    "RetryIdempotent",
+}
+
+// MethodOptions are the client options for a method set using annotations.
+type MethodOptions struct {
+	Timeout       time.Duration
+	RetryOn       string
+	ShardKeyField *Field
+}
+
+// setMethodOptions parses the annotations for all methods. It is done after
+// includes are set, as the shard key field may use an included typedef.
+func setMethodOptions(all map[string]parseState) error {
+	for _, v := range all {
+		for _, s := range v.services {
+			for _, m := range s.Methods() {
+				opts, err := parseMethodOptions(m)
+				if err != nil {
+					return fmt.Errorf("invalid annotations for %v.%v: %v", s.Name, m.ThriftName(), err)
+				}
+				m.options = opts
+			}
+		}
+	}
+	return nil
+}
+
+func parseMethodOptions(m *Method) (*MethodOptions, error) {
+	var opts *MethodOptions
+	for _, a := range m.Method.Annotations {
+		if !strings.HasPrefix(a.Name, "tchannel.") {
+			continue
+		}
+		if opts == nil {
+			opts = &MethodOptions{}
+		}
+
+		switch a.Name {
+		case timeoutAnnotation:
+			timeout, err := time.ParseDuration(a.Value)
+			if err != nil {
+				return nil, fmt.Errorf("%v: %v", a.Name, err)
+			}
+			if timeout <= 0 {
+				return nil, fmt.Errorf("%v must be positive, got %v", a.Name, a.Value)
+			}
+			opts.Timeout = timeout
+		case retryAnnotation:
+			retryOn, ok := retryOnValues[a.Value]
+			if !ok {
+				return nil, fmt.Errorf("%v has unknown value %q", a.Name, a.Value)
+			}
+			opts.RetryOn = retryOn
+		case shardKeyFieldAnnotation:
+			field, err := shardKeyField(m, a.Value)
+			if err != nil {
+				return nil, fmt.Errorf("%v: %v", a.Name, err)
+			}
+			opts.ShardKeyField = field
+		default:
+			return nil, fmt.Errorf("unknown annotation %v", a.Name)
+		}
+	}
+	return opts, nil
+}
+
+func shardKeyField(m *Method, name string) (*Field, error) {
+	for _, arg := range m.Arguments() {
+		if arg.Field.Name != name {
+			continue
+		}
+		if rootType := m.state.rootType(arg.Type); rootType.Name != "string" {
+			return nil, fmt.Errorf("argument %v must be a string, got %v", name, rootType.Name)
+		}
+		return arg, nil
+	}
+	return nil, fmt.Errorf("argument %v not found", name)
+}
+
+// goDuration returns a Go expression for the given duration.
+func goDuration(d time.Duration) string {
+	units := []struct {
+		unit time.Duration
+		name string
+	}{
+		{time.Hour, "time.Hour"},
+		{time.Minute, "time.Minute"},
+		{time.Second, "time.Second"},
+		{time.Millisecond, "time.Millisecond"},
+		{time.Microsecond, "time.Microsecond"},
+	}
+	for _, u := range units {
+		if d%u.unit == 0 {
+			return fmt.Sprintf("%d * %v", d/u.unit, u.name)
+		}
+	}
+	return fmt.Sprintf("%d * time.Nanosecond", d)
+}
+
+// HasOptions returns whether the method has any client options set using annotations.
+func (m *Method) HasOptions() bool {
+	return m.options != nil
+}
+
+// Options returns the thrift.MethodOptions literal for the method's client options.
+func (m *Method) Options() string {
+	var fields []string
+	if opts := m.options; opts != nil {
+		if opts.Timeout > 0 {
+			fields = append(fields, "Timeout: "+goDuration(opts.Timeout)+",")
+		}
+		if opts.RetryOn != "" {
+			fields = append(fields, "RetryOn: tchannel."+opts.RetryOn+",")
+		}
+		if opts.ShardKeyField != nil {
+			fields = append(fields, "ShardKey: string(args."+opts.ShardKeyField.ArgStructName()+"),")
+		}
+	}
+	return "thrift.MethodOptions{\n" + strings.Join(fields, "\n") + "\n}"
+}
+
+// UsesTimeoutOptions returns whether any method sets a timeout using annotations.
+func (td TemplateData) UsesTimeoutOptions() bool {
+	return td.anyMethodOptions(func(opts *MethodOptions) bool { return opts.Timeout > 0 })
+}
+
+// UsesRetryOptions returns whether any method sets retry options using annotations.
+func (td TemplateData) UsesRetryOptions() bool {
+	return td.anyMethodOptions(func(opts *MethodOptions) bool { return opts.RetryOn != "" })
+}
+
+func (td TemplateData) anyMethodOptions(f func(*MethodOptions) bool) bool {
+	for _, s := range td.Services {
+		for _, m := range s.Methods() {
+			if m.options != nil && f(m.options) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,145 @@
+// Copyright (c) 2015 Uber Technologies, Inc.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func parseServiceMethods(t *testing.T, file string) map[string]*Method {
+	allParsed, err := parseFile(file)
+	require.NoError(t, err, "parseFile(%v) failed", file)
+
+	methods := make(map[string]*Method)
+	for _, v := range allParsed {
+		for _, s := range v.services {
+			for _, m := range s.Methods() {
+				methods[m.ThriftName()] = m
+			}
+		}
+	}
+	return methods
+}
+
+func TestMethodOptions(t *testing.T) {
+	methods := parseServiceMethods(t, "test_files/annotations.thrift")
+
+	tests := []struct {
+		method string
+		want   string
+	}{
+		{
+			method: "getUser",
+			want: "thrift.MethodOptions{\n" +
+				"Timeout: 500 * time.Millisecond,\n" +
+				"RetryOn: tchannel.RetryIdempotent,\n" +
+				"ShardKey: string(args.UserId),\n" +
+				"}",
+		},
+		{
+			method: "setUser",
+			want: "thrift.MethodOptions{\n" +
+				"RetryOn: tchannel.RetryNever,\n" +
+				"ShardKey: string(args.UserId),\n" +
+				"}",
+		},
+		{
+			method: "ping",
+			want:   "thrift.MethodOptions{\nTimeout: 2 * time.Second,\n}",
+		},
+	}
+
+	for _, tt := range tests {
+		m := methods[tt.method]
+		require.NotNil(t, m, "method %v not found", tt.method)
+		assert.True(t, m.HasOptions(), "%v should have options", tt.method)
+		assert.Equal(t, tt.want, m.Options(), "%v options mismatch", tt.method)
+	}
+
+	methods = parseServiceMethods(t, "test_files/binary.thrift")
+	for name, m := range methods {
+		assert.False(t, m.HasOptions(), "%v should not have options", name)
+	}
+}
+
+func TestMethodOptionsErrors(t *testing.T) {
+	tests := []struct {
+		annotations string
+		wantErr     string
+	}{
+		{
+			annotations: `tchannel.timeout = "1x"`,
+			wantErr:     "tchannel.timeout",
+		},
+		{
+			annotations: `tchannel.timeout = "-1s"`,
+			wantErr:     "must be positive",
+		},
+		{
+			annotations: `tchannel.retry = "always"`,
+			wantErr:     `unknown value "always"`,
+		},
+		{
+			annotations: `tchannel.shard_key_field = "missing"`,
+			wantErr:     "argument missing not found",
+		},
+		{
+			annotations: `tchannel.shard_key_field = "count"`,
+			wantErr:     "argument count must be a string, got i32",
+		},
+		{
+			annotations: `tchannel.unknown = "value"`,
+			wantErr:     "unknown annotation tchannel.unknown",
+		},
+	}
+
+	for _, tt := range tests {
+		file := writeTempFile(t, `service S {
+  void m(1: string key, 2: i32 count) (`+tt.annotations+`)
+}`)
+		_, err := parseFile(file)
+		if assert.Error(t, err, "%v should fail", tt.annotations) {
+			assert.Contains(t, err.Error(), tt.wantErr, "%v: unexpected error", tt.annotations)
+			assert.Contains(t, err.Error(), "invalid annotations for S.m", "%v: unexpected error", tt.annotations)
+		}
+	}
+}
+
+func TestGoDuration(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{2 * time.Hour, "2 * time.Hour"},
+		{90 * time.Minute, "90 * time.Minute"},
+		{1500 * time.Millisecond, "1500 * time.Millisecond"},
+		{time.Microsecond, "1 * time.Microsecond"},
+		{3, "3 * time.Nanosecond"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, goDuration(tt.d), "goDuration(%v)", tt.d)
+	}
+}
@@ -304,7 +304,7 @@ func runBuildTest(t *testing.T, thriftFile string) error {
 		return checkDirectoryFiles(filepath.Join(dir, defaultPackageName(thriftFile)), 4)
 	}
 
-	opts := processOptions{InputFile: thriftFile}
+	opts := processOptions{InputFile: thriftFile, GenerateMocks: true, GenerateHTTP: true}
 	return runTest(t, opts, extraChecks)
 }
 
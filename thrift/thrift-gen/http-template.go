@@ -0,0 +1,159 @@
+// Copyright (c) 2015 Uber Technologies, Inc.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+var httpTmpl = `
+// @generated Code generated by thrift-gen. Do not modify.
+
+package {{ .Package }}
+
+import (
+"encoding/json"
+"fmt"
+"io"
+"net/http"
+"strings"
+"time"
+
+"{{ .Imports.TChannel }}"
+"golang.org/x/net/context"
+
+{{ range .Includes }}
+	"{{ .Import }}"
+{{ end }}
+)
+
+{{ range .Includes }}
+	var _ = {{ .Package }}.GoUnusedProtection__
+{{ end }}
+
+// HTTP handlers that serve the services defined in the IDL using JSON.
+// Requests are made using POST to /Service/method with the arguments
+// encoded as a JSON object. Results are returned as JSON with status 200,
+// exceptions with status 400 as an object with the exception name as the key,
+// and any other errors with an object containing the error.
+
+{{ range $svc := .Services }}
+// {{ .HTTPHandlerStruct }} is an http.Handler for {{ .ThriftName }} that makes calls
+// using a {{ .Interface }} client.
+type {{ .HTTPHandlerStruct }} struct {
+	{{ if .HasExtends }}
+		*{{ .ExtendsServicePrefix }}{{ .ExtendsService.HTTPHandlerStruct }}
+
+	{{ end }}
+	client  {{ .Interface }}
+	timeout time.Duration
+}
+
+// {{ .HTTPHandlerConstructor }} returns an http.Handler that serves requests
+// for {{ .ThriftName }} at /{{ .ThriftName }}/method using client, with the given
+// timeout for each call.
+func {{ .HTTPHandlerConstructor }}(client {{ .Interface }}, timeout time.Duration) *{{ .HTTPHandlerStruct }} {
+	return &{{ .HTTPHandlerStruct }}{
+		{{ if .HasExtends }}
+			{{ .ExtendsServicePrefix }}{{ .ExtendsService.HTTPHandlerConstructor }}(client, timeout),
+		{{ end }}
+		client,
+		timeout,
+	}
+}
+
+func (h *{{ .HTTPHandlerStruct }}) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	method := strings.TrimPrefix(r.URL.Path, "/{{ .ThriftName }}/")
+	if method == r.URL.Path {
+		tchanHTTPError(w, http.StatusNotFound, fmt.Errorf("path %v is not for service {{ .ThriftName }}", r.URL.Path))
+		return
+	}
+	if r.Method != "POST" {
+		tchanHTTPError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %v is not allowed, use POST", r.Method))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.timeout)
+	defer cancel()
+	h.ServeMethod(thrift.Wrap(ctx), w, r, method)
+}
+
+// ServeMethod handles a request for the given Thrift method. It is used by
+// the handlers for services that extend {{ .ThriftName }}.
+func (h *{{ .HTTPHandlerStruct }}) ServeMethod(ctx {{ contextType }}, w http.ResponseWriter, r *http.Request, method string) {
+	switch method {
+	{{ range $m := .Methods }}
+		case "{{ .ThriftName }}":
+			var args {{ .ArgsType }}
+			if err := tchanHTTPDecode(r, &args); err != nil {
+				tchanHTTPError(w, http.StatusBadRequest, err)
+				return
+			}
+
+			{{ if .HasReturn }}
+				res, err :=
+			{{ else }}
+				err :=
+			{{ end }}
+					h.client.{{ .Name }}({{ .CallList "args" }})
+			if err != nil {
+				{{ if .HasExceptions }}
+				switch v := err.(type) {
+					{{ range .Exceptions }}
+						case {{ .ArgType }}:
+							tchanHTTPWrite(w, http.StatusBadRequest, &{{ $m.ResultType }}{ {{ .ArgStructName }}: v })
+							return
+					{{ end }}
+				}
+				{{ end }}
+				tchanHTTPError(w, http.StatusBadGateway, err)
+				return
+			}
+
+			{{ if .HasReturn }}
+				tchanHTTPWrite(w, http.StatusOK, res)
+			{{ else }}
+				tchanHTTPWrite(w, http.StatusOK, struct{}{})
+			{{ end }}
+	{{ end }}
+	default:
+		{{ if .HasExtends }}
+			h.{{ .ExtendsService.HTTPHandlerStruct }}.ServeMethod(ctx, w, r, method)
+		{{ else }}
+			tchanHTTPError(w, http.StatusNotFound, fmt.Errorf("method %v not found", method))
+		{{ end }}
+	}
+}
+{{ end }}
+
+func tchanHTTPDecode(r *http.Request, args interface{}) error {
+	if err := json.NewDecoder(r.Body).Decode(args); err != nil && err != io.EOF {
+		return fmt.Errorf("failed to decode JSON arguments: %v", err)
+	}
+	return nil
+}
+
+func tchanHTTPWrite(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func tchanHTTPError(w http.ResponseWriter, status int, err error) {
+	tchanHTTPWrite(w, status, map[string]string{"error": err.Error()})
+}
+`
@@ -0,0 +1,90 @@
+// Copyright (c) 2015 Uber Technologies, Inc.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// IDL is the Thrift IDL that a package was generated from, which is embedded
+// in the generated code so it can be returned by the meta ThriftIDL endpoint.
+type IDL struct {
+	// Files is a map from the filename, relative to the entry point, to the contents.
+	Files map[string]string
+
+	// EntryPoint is the filename of the Thrift file the package was generated from.
+	EntryPoint string
+}
+
+// readIDL reads the given Thrift file and all the files it includes.
+func readIDL(filename string, all map[string]parseState) (*IDL, error) {
+	baseDir := filepath.Dir(filename)
+	idl := &IDL{
+		Files:      make(map[string]string),
+		EntryPoint: filepath.Base(filename),
+	}
+
+	pending := []string{filename}
+	seen := map[string]bool{filename: true}
+	for len(pending) > 0 {
+		file := pending[0]
+		pending = pending[1:]
+
+		contents, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read Thrift file %q: %v", file, err)
+		}
+
+		relPath, err := filepath.Rel(baseDir, file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get path for %q relative to %q: %v", file, baseDir, err)
+		}
+		idl.Files[filepath.ToSlash(relPath)] = string(contents)
+
+		for _, include := range all[file].ast.Includes {
+			if !seen[include] {
+				seen[include] = true
+				pending = append(pending, include)
+			}
+		}
+	}
+
+	return idl, nil
+}
+
+// quoteLines returns a Go string expression for s, with each line quoted
+// separately so that multi-line strings remain readable in generated code.
+func quoteLines(s string) string {
+	var quoted []string
+	for _, line := range strings.SplitAfter(s, "\n") {
+		if line != "" {
+			quoted = append(quoted, strconv.Quote(line))
+		}
+	}
+	if len(quoted) == 0 {
+		return `""`
+	}
+	return strings.Join(quoted, " +\n")
+}
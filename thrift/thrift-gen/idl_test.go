@@ -0,0 +1,90 @@
+// Copyright (c) 2015 Uber Technologies, Inc.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadIDL(t *testing.T) {
+	tests := []struct {
+		file      string
+		wantFiles []string
+	}{
+		{
+			file:      "test_files/binary.thrift",
+			wantFiles: []string{"binary.thrift"},
+		},
+		{
+			file:      "test_files/include_test/simple/simple.thrift",
+			wantFiles: []string{"simple.thrift", "shared.thrift", "shared2.thrift"},
+		},
+		{
+			file:      "test_files/include_test/namespace/namespace.thrift",
+			wantFiles: []string{"namespace.thrift", "a/shared.thrift", "b/shared.thrift"},
+		},
+		{
+			file:      "test_files/include_test/namespace/a/shared.thrift",
+			wantFiles: []string{"shared.thrift", "../b/shared.thrift"},
+		},
+	}
+
+	for _, tt := range tests {
+		absPath, err := filepath.Abs(tt.file)
+		require.NoError(t, err, "Abs failed")
+
+		allParsed, err := parseFile(absPath)
+		require.NoError(t, err, "parseFile(%v) failed", tt.file)
+
+		idl, err := readIDL(absPath, allParsed)
+		require.NoError(t, err, "readIDL(%v) failed", tt.file)
+		assert.Equal(t, filepath.Base(tt.file), idl.EntryPoint, "%v: unexpected entry point", tt.file)
+
+		want := make(map[string]string)
+		for _, f := range tt.wantFiles {
+			contents, err := ioutil.ReadFile(filepath.Join(filepath.Dir(tt.file), f))
+			require.NoError(t, err, "ReadFile failed")
+			want[f] = string(contents)
+		}
+		assert.Equal(t, want, idl.Files, "%v: unexpected files", tt.file)
+	}
+}
+
+func TestQuoteLines(t *testing.T) {
+	tests := []struct {
+		s    string
+		want string
+	}{
+		{"", `""`},
+		{"a", `"a"`},
+		{"a\n", `"a\n"`},
+		{"a\n\"b\"\n  \nc", "\"a\\n\" +\n\"\\\"b\\\"\\n\" +\n\"  \\n\" +\n\"c\""},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, quoteLines(tt.s), "quoteLines(%q)", tt.s)
+	}
+}
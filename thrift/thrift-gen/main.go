@@ -44,6 +44,8 @@ var (
 	inputFile      = flag.String("inputFile", "", "The .thrift file to generate a client for")
 	outputDir      = flag.String("outputDir", "gen-go", "The output directory to generate go code to.")
 	skipTChannel   = flag.Bool("skipTChannel", false, "Whether to skip the TChannel template")
+	generateMocks  = flag.Bool("generateMocks", false, "Whether to generate mocks for the TChannel interfaces")
+	generateHTTP   = flag.Bool("generateHTTP", false, "Whether to generate HTTP handlers that serve the services using JSON")
 	templateFiles  = NewStringSliceFlag("template", "Template file to compile code from")
 
 	nlSpaceNL = regexp.MustCompile(`\n[ \t]+\n`)
@@ -56,6 +58,7 @@ type TemplateData struct {
 	Services []*Service
 	Includes map[string]*Include
 	Imports  imports
+	IDL      *IDL
 
 	// global should not be directly exported to the template, but functions on
 	// global can be exposed to templates.
@@ -78,6 +81,8 @@ func main() {
 		GenerateThrift: *generateThrift,
 		OutputDir:      *outputDir,
 		SkipTChannel:   *skipTChannel,
+		GenerateMocks:  *generateMocks,
+		GenerateHTTP:   *generateHTTP,
 		TemplateFiles:  *templateFiles,
 	}
 	if err := processFile(opts); err != nil {
@@ -90,6 +95,8 @@ type processOptions struct {
 	GenerateThrift bool
 	OutputDir      string
 	SkipTChannel   bool
+	GenerateMocks  bool
+	GenerateHTTP   bool
 	TemplateFiles  []string
 }
 
@@ -109,7 +116,7 @@ func processFile(opts processOptions) error {
 		return fmt.Errorf("failed to parse file %q: %v", opts.InputFile, err)
 	}
 
-	allTemplates, err := parseTemplates(opts.SkipTChannel, opts.TemplateFiles)
+	allTemplates, err := parseTemplates(opts)
 	if err != nil {
 		return fmt.Errorf("failed to parse templates: %v", err)
 	}
@@ -117,9 +124,14 @@ func processFile(opts processOptions) error {
 	for filename, v := range allParsed {
 		pkg := getNamespace(filename, v.ast)
 
+		idl, err := readIDL(filename, allParsed)
+		if err != nil {
+			return err
+		}
+
 		for _, template := range allTemplates {
 			outputFile := filepath.Join(opts.OutputDir, pkg, template.outputFile(pkg))
-			if err := generateCode(outputFile, template, pkg, v); err != nil {
+			if err := generateCode(outputFile, template, pkg, v, idl); err != nil {
 				return err
 			}
 		}
@@ -135,18 +147,32 @@ type parseState struct {
 	services  []*Service
 }
 
-// parseTemplates returns a list of Templates that must be rendered given the template files.
-func parseTemplates(skipTChannel bool, templateFiles []string) ([]*Template, error) {
+// parseTemplates returns a list of Templates that must be rendered given the options.
+func parseTemplates(opts processOptions) ([]*Template, error) {
 	var templates []*Template
 
-	if !skipTChannel {
+	if !opts.SkipTChannel {
 		templates = append(templates, &Template{
 			name:     "tchan",
 			template: template.Must(parseTemplate(tchannelTmpl)),
 		})
 	}
 
-	for _, f := range templateFiles {
+	if opts.GenerateMocks {
+		templates = append(templates, &Template{
+			name:     "mock",
+			template: template.Must(parseTemplate(mockTmpl)),
+		})
+	}
+
+	if opts.GenerateHTTP {
+		templates = append(templates, &Template{
+			name:     "http",
+			template: template.Must(parseTemplate(httpTmpl)),
+		})
+	}
+
+	for _, f := range opts.TemplateFiles {
 		t, err := parseTemplateFile(f)
 		if err != nil {
 			return nil, err
@@ -177,6 +203,9 @@ func parseFile(inputFile string) (map[string]parseState, error) {
 		allParsed[filename] = parseState{v, namespace, state, services}
 	}
 	setIncludes(allParsed)
+	if err := setMethodOptions(allParsed); err != nil {
+		return nil, err
+	}
 	return allParsed, setExtends(allParsed)
 }
 
@@ -195,7 +224,7 @@ func getNamespace(filename string, v *parser.Thrift) string {
 	return defaultPackageName(filename)
 }
 
-func generateCode(outputFile string, template *Template, pkg string, state parseState) error {
+func generateCode(outputFile string, template *Template, pkg string, state parseState, idl *IDL) error {
 	if outputFile == "" {
 		return fmt.Errorf("must speciy an output file")
 	}
@@ -208,6 +237,7 @@ func generateCode(outputFile string, template *Template, pkg string, state parse
 		AST:      state.ast,
 		Includes: state.global.includes,
 		Services: state.services,
+		IDL:      idl,
 		global:   state.global,
 		Imports: imports{
 			Thrift:   *apacheThriftImport,
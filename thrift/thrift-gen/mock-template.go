@@ -0,0 +1,90 @@
+// Copyright (c) 2015 Uber Technologies, Inc.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+var mockTmpl = `
+// @generated Code generated by thrift-gen. Do not modify.
+
+package {{ .Package }}
+
+import (
+"{{ .Imports.TChannel }}"
+"github.com/stretchr/testify/mock"
+
+{{ range .Includes }}
+	"{{ .Import }}"
+{{ end }}
+)
+
+{{ range .Includes }}
+	var _ = {{ .Package }}.GoUnusedProtection__
+{{ end }}
+
+// Mock implementations of the interfaces for the services defined in the IDL.
+// Expectations are recorded using On with the method name and arguments, which
+// may be matchers such as mock.Anything, and Return sets the canned result,
+// which may be an exception defined in the IDL, or a function with the same
+// signature as the method to compute the result.
+
+{{ range $svc := .Services }}
+// {{ .MockStruct }} is a mock implementation of {{ .Interface }}.
+type {{ .MockStruct }} struct {
+	{{ if .HasExtends }}
+		{{ .ExtendsServicePrefix }}{{ .ExtendsService.MockStruct }}
+	{{ else }}
+		mock.Mock
+	{{ end }}
+}
+
+var _ {{ .Interface }} = (*{{ .MockStruct }})(nil)
+
+{{ range .Methods }}
+	// {{ .Name }} records the call and returns the result set using Return.
+	func (_m *{{ $svc.MockStruct }}) {{ .Name }}({{ .ArgList }}) {{ .RetType }} {
+		_ret := _m.Called({{ .ArgNames }})
+
+		{{ if .HasReturn }}
+			var _r0 {{ .ReturnGoType }}
+			if _rf, _ok := _ret.Get(0).(func({{ .ArgTypes }}) {{ .ReturnGoType }}); _ok {
+				_r0 = _rf({{ .ArgNames }})
+			} else if _ret.Get(0) != nil {
+				_r0 = _ret.Get(0).({{ .ReturnGoType }})
+			}
+
+			var _r1 error
+			if _rf, _ok := _ret.Get(1).(func({{ .ArgTypes }}) error); _ok {
+				_r1 = _rf({{ .ArgNames }})
+			} else {
+				_r1 = _ret.Error(1)
+			}
+
+			return _r0, _r1
+		{{ else }}
+			if _rf, _ok := _ret.Get(0).(func({{ .ArgTypes }}) error); _ok {
+				return _rf({{ .ArgNames }})
+			}
+			return _ret.Error(0)
+		{{ end }}
+	}
+{{ end }}
+
+{{ end }}
+`
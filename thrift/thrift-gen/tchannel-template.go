@@ -8,8 +8,14 @@ package {{ .Package }}
 
 import (
 "fmt"
+{{- if .UsesTimeoutOptions }}
+"time"
+{{- end }}
 
 athrift "{{ .Imports.Thrift }}"
+{{- if .UsesRetryOptions }}
+tchannel "github.com/uber/tchannel-go"
+{{- end }}
 "{{ .Imports.TChannel }}"
 
 {{ range .Includes }}
@@ -75,6 +81,11 @@ func {{ .ClientConstructor }}(client thrift.TChanClient) {{ .Interface }} {
 				{{ .ArgStructName }}: {{ .Name }},
 			{{ end }}
 		}
+		{{ if .HasOptions }}
+			ctx, cancel := thrift.WithMethodOptions(ctx, {{ .Options }})
+			defer cancel()
+
+		{{ end }}
 		success, err := c.client.Call(ctx, c.thriftService, "{{ .ThriftName }}", &args, &resp)
 		if err == nil && !success {
 			switch {
@@ -129,6 +140,10 @@ func (s *{{ .ServerStruct }}) Methods() []string {
 	}
 }
 
+func (s *{{ .ServerStruct }}) ThriftIDL() (map[string]string, string) {
+	return tchanThriftIDLs, tchanThriftIDLEntryPoint
+}
+
 func (s *{{ .ServerStruct }}) Handle(ctx {{ contextType }}, methodName string, protocol athrift.TProtocol) (bool, athrift.TStruct, error) {
 	switch methodName {
 		{{ range .Methods }}
@@ -144,15 +159,56 @@ func (s *{{ .ServerStruct }}) Handle(ctx {{ contextType }}, methodName string, p
 	}
 }
 
+func (s *{{ .ServerStruct }}) NewArgs(methodName string) (athrift.TStruct, bool) {
+	switch methodName {
+		{{ range .Methods }}
+			case "{{ .ThriftName }}":
+				return &{{ .ArgsType }}{}, true
+		{{ end }}
+		{{ range .InheritedMethods }}
+			case "{{ . }}":
+				if s, ok := s.TChanServer.(thrift.TChanArgsServer); ok {
+					return s.NewArgs(methodName)
+				}
+		{{ end }}
+	}
+	return nil, false
+}
+
+func (s *{{ .ServerStruct }}) HandleArgs(ctx {{ contextType }}, methodName string, args athrift.TStruct) (bool, athrift.TStruct, error) {
+	switch methodName {
+		{{ range .Methods }}
+			case "{{ .ThriftName }}":
+				if req, ok := args.(*{{ .ArgsType }}); ok {
+					return s.{{ .HandleDecodedFunc }}(ctx, req)
+				}
+		{{ end }}
+		{{ range .InheritedMethods }}
+			case "{{ . }}":
+				if s, ok := s.TChanServer.(thrift.TChanArgsServer); ok {
+					return s.HandleArgs(ctx, methodName, args)
+				}
+		{{ end }}
+		default:
+			return false, nil, fmt.Errorf("method %v not found in service %v", methodName, s.Service())
+	}
+	return false, nil, fmt.Errorf("unexpected arguments %T for method %v", args, methodName)
+}
+
 {{ range .Methods }}
 	func (s *{{ $svc.ServerStruct }}) {{ .HandleFunc }}(ctx {{ contextType }}, protocol athrift.TProtocol) (bool, athrift.TStruct, error) {
 		var req {{ .ArgsType }}
-		var res {{ .ResultType }}
 
 		if err := req.Read(protocol); err != nil {
 			return false, nil, err
 		}
 
+		return s.{{ .HandleDecodedFunc }}(ctx, &req)
+	}
+
+	func (s *{{ $svc.ServerStruct }}) {{ .HandleDecodedFunc }}(ctx {{ contextType }}, req *{{ .ArgsType }}) (bool, athrift.TStruct, error) {
+		var res {{ .ResultType }}
+
 		{{ if .HasReturn }}
 			r, err :=
 		{{ else }}
@@ -188,4 +244,13 @@ func (s *{{ .ServerStruct }}) Handle(ctx {{ contextType }}, methodName string, p
 {{ end }}
 
 {{ end }}
+
+// tchanThriftIDLs are the Thrift IDL files that this package was generated from.
+var tchanThriftIDLs = map[string]string{
+	{{ range $file, $contents := .IDL.Files }}
+		{{ printf "%q" $file }}: {{ quoteLines $contents }},
+	{{ end }}
+}
+
+const tchanThriftIDLEntryPoint = {{ printf "%q" .IDL.EntryPoint }}
 `
@@ -51,6 +51,7 @@ func parseTemplate(contents string) (*template.Template, error) {
 		"goPrivateName": goName,
 		"goPublicName":  goPublicName,
 		"goType":        dummyGoType,
+		"quoteLines":    quoteLines,
 	}
 	return template.New("thrift-gen").Funcs(funcs).Parse(contents)
 }
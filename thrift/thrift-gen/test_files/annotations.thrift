@@ -0,0 +1,11 @@
+typedef string UserID
+
+struct User {
+  1: required UserID id
+}
+
+service Users {
+  User getUser(1: UserID userId) (tchannel.timeout = "500ms", tchannel.retry = "idempotent", tchannel.shard_key_field = "userId")
+  void setUser(1: string userId, 2: User user) (tchannel.retry = "never", tchannel.shard_key_field = "userId")
+  void ping() (tchannel.timeout = "2s")
+}
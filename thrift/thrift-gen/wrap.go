@@ -84,6 +84,21 @@ func (s *Service) ServerConstructor() string {
 	return "NewTChan" + goPublicName(s.Name) + "Server"
 }
 
+// MockStruct returns the name of the mock implementation of the interface.
+func (s *Service) MockStruct() string {
+	return "MockTChan" + goPublicName(s.Name)
+}
+
+// HTTPHandlerStruct returns the name of the struct that serves the service over HTTP.
+func (s *Service) HTTPHandlerStruct() string {
+	return "TChan" + goPublicName(s.Name) + "HTTPHandler"
+}
+
+// HTTPHandlerConstructor returns the name of the constructor for the HTTP handler.
+func (s *Service) HTTPHandlerConstructor() string {
+	return "NewTChan" + goPublicName(s.Name) + "HTTPHandler"
+}
+
 // HasExtends returns whether this service extends another service.
 func (s *Service) HasExtends() bool {
 	return s.ExtendsService != nil
@@ -110,7 +125,7 @@ func (s *Service) Methods() []*Method {
 	}
 
 	for _, m := range s.Service.Methods {
-		s.methods = append(s.methods, &Method{m, s, s.state})
+		s.methods = append(s.methods, &Method{Method: m, service: s, state: s.state})
 	}
 	sort.Sort(byMethodName(s.methods))
 	return s.methods
@@ -138,6 +153,9 @@ type Method struct {
 
 	service *Service
 	state   *State
+
+	// options is set in setMethodOptions.
+	options *MethodOptions
 }
 
 // ThriftName returns the thrift identifier for this function.
@@ -155,6 +173,12 @@ func (m *Method) HandleFunc() string {
 	return "handle" + goPublicName(m.Method.Name)
 }
 
+// HandleDecodedFunc is the go method name for the handle function which is
+// called with the decoded arguments.
+func (m *Method) HandleDecodedFunc() string {
+	return "handleDecoded" + goPublicName(m.Method.Name)
+}
+
 // Arguments returns the argument declarations for this method.
 func (m *Method) Arguments() []*Field {
 	var args []*Field
@@ -215,6 +239,32 @@ func (m *Method) CallList(reqStruct string) string {
 	return strings.Join(args, ", ")
 }
 
+// ArgNames returns the names of the arguments for the function, including the context.
+func (m *Method) ArgNames() string {
+	args := []string{"ctx"}
+	for _, arg := range m.Arguments() {
+		args = append(args, arg.Name())
+	}
+	return strings.Join(args, ", ")
+}
+
+// ArgTypes returns the types of the arguments for the function, including the context.
+func (m *Method) ArgTypes() string {
+	args := []string{contextType()}
+	for _, arg := range m.Arguments() {
+		args = append(args, arg.ArgType())
+	}
+	return strings.Join(args, ", ")
+}
+
+// ReturnGoType returns the go type of the method's result, excluding the error.
+func (m *Method) ReturnGoType() string {
+	if !m.HasReturn() {
+		panic("cannot get the return type when there is no return")
+	}
+	return m.state.goType(m.Method.ReturnType)
+}
+
 // RetType returns the go return type of the method.
 func (m *Method) RetType() string {
 	if !m.HasReturn() {
@@ -23,6 +23,9 @@ package thrift_test
 import (
 	"errors"
 	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
 	"time"
@@ -333,6 +336,203 @@ func TestClientHostPort(t *testing.T) {
 	assert.Equal(t, "call2", res)
 }
 
+func TestCompactProtocol(t *testing.T) {
+	withSetup(t, func(ctx Context, args testArgs) {
+		client := NewClient(args.clientCh, args.serverCh.ServiceName(), &ClientOptions{
+			Protocol: CompactProtocol,
+		})
+		c1 := gen.NewTChanSimpleServiceClient(client)
+
+		arg := &gen.Data{B1: true, S2: "str", I3: 102}
+		ret := &gen.Data{B1: false, S2: "return-str", I3: -105}
+		args.s1.On("Call", ctxArg(), arg).Return(ret, nil)
+		got, err := c1.Call(ctx, arg)
+		require.NoError(t, err, "Call failed")
+		assert.Equal(t, ret, got, "Call response mismatch")
+
+		thriftErr := &gen.SimpleErr{Message: "this is the error"}
+		args.s1.On("Simple", ctxArg()).Return(thriftErr)
+		assert.Equal(t, thriftErr, c1.Simple(ctx), "Simple error mismatch")
+
+		// Clients using the default protocol are unaffected.
+		got, err = args.c1.Call(ctx, arg)
+		require.NoError(t, err, "Call with binary protocol failed")
+		assert.Equal(t, ret, got, "Call with binary protocol response mismatch")
+	})
+}
+
+func TestCompactProtocolMiddleware(t *testing.T) {
+	var (
+		calls  []string
+		gotReq thrift.TStruct
+	)
+	m := testMiddleware{name: "m", calls: &calls, before: func(req thrift.TStruct) (bool, thrift.TStruct, error) {
+		gotReq = req
+		return false, nil, nil
+	}}
+
+	withSetup(t, func(ctx Context, args testArgs) {
+		args.server.Register(gen.NewTChanSimpleServiceServer(args.s1), OptMiddleware(m))
+		client := NewClient(args.clientCh, args.serverCh.ServiceName(), &ClientOptions{
+			Protocol: CompactProtocol,
+		})
+		c1 := gen.NewTChanSimpleServiceClient(client)
+
+		arg := &gen.Data{B1: true, S2: "str", I3: 102}
+		args.s1.On("Call", ctxArg(), arg).Return(arg, nil)
+		got, err := c1.Call(ctx, arg)
+		require.NoError(t, err, "Call failed")
+		assert.Equal(t, arg, got, "Call response mismatch")
+		assert.Equal(t, &gen.SimpleServiceCallArgs{Arg: arg}, gotReq, "Middleware got unexpected request")
+	})
+}
+
+func TestGeneratedThriftIDL(t *testing.T) {
+	contents, err := ioutil.ReadFile("test.thrift")
+	require.NoError(t, err, "Failed to read test.thrift")
+
+	server, ok := gen.NewTChanSimpleServiceServer(nil).(TChanIDLServer)
+	require.True(t, ok, "Generated server should implement TChanIDLServer")
+
+	idls, entryPoint := server.ThriftIDL()
+	assert.Equal(t, "test.thrift", entryPoint, "Unexpected entry point")
+	assert.Equal(t, map[string]string{"test.thrift": string(contents)}, idls, "Unexpected IDLs")
+}
+
+func TestGeneratedMock(t *testing.T) {
+	ctx, cancel := NewContext(time.Second)
+	defer cancel()
+
+	svc := &gen.MockTChanSimpleService{}
+	serverCh := testutils.NewServer(t, nil)
+	defer serverCh.Close()
+	NewServer(serverCh).Register(gen.NewTChanSimpleServiceServer(svc))
+
+	clientCh := testutils.NewClient(t, nil)
+	defer clientCh.Close()
+	clientCh.Peers().Add(serverCh.PeerInfo().HostPort)
+	client := gen.NewTChanSimpleServiceClient(NewClient(clientCh, serverCh.ServiceName(), nil))
+
+	matchS2 := mock.MatchedBy(func(d *gen.Data) bool { return d.S2 == "echo" })
+	svc.On("Call", mock.Anything, matchS2).Return(func(_ Context, d *gen.Data) *gen.Data {
+		return &gen.Data{S2: d.S2 + "-reply"}
+	}, nil)
+	svc.On("Call", mock.Anything, mock.Anything).Return(&gen.Data{S2: "default"}, nil)
+	svc.On("Simple", mock.Anything).Return(&gen.SimpleErr{Message: "canned"})
+
+	res, err := client.Call(ctx, &gen.Data{S2: "echo"})
+	require.NoError(t, err, "Call failed")
+	assert.Equal(t, "echo-reply", res.S2, "Call with matched argument got unexpected result")
+
+	res, err = client.Call(ctx, &gen.Data{S2: "other"})
+	require.NoError(t, err, "Call failed")
+	assert.Equal(t, "default", res.S2, "Call got unexpected result")
+
+	assert.Equal(t, &gen.SimpleErr{Message: "canned"}, client.Simple(ctx), "Simple should return exception")
+
+	svc.AssertExpectations(t)
+	svc.AssertNumberOfCalls(t, "Call", 2)
+}
+
+func TestGeneratedHTTPHandler(t *testing.T) {
+	tests := []struct {
+		msg        string
+		method     string
+		path       string
+		body       string
+		setup      func(args testArgs)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			msg:  "result",
+			path: "/SimpleService/Call",
+			body: `{"arg": {"b1": true, "s2": "str", "i3": 3}}`,
+			setup: func(args testArgs) {
+				args.s1.On("Call", ctxArg(), &gen.Data{B1: true, S2: "str", I3: 3}).
+					Return(&gen.Data{S2: "res"}, nil)
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `{"b1":false,"s2":"res","i3":0}`,
+		},
+		{
+			msg:        "void result",
+			path:       "/SimpleService/Simple",
+			setup:      func(args testArgs) { args.s1.On("Simple", ctxArg()).Return(nil) },
+			wantStatus: http.StatusOK,
+			wantBody:   `{}`,
+		},
+		{
+			msg:  "exception",
+			path: "/SimpleService/SimpleFuture",
+			setup: func(args testArgs) {
+				args.s1.On("SimpleFuture", ctxArg()).Return(&gen.NewErr_{Message: "err"})
+			},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"newErr":{"message":"err"}}`,
+		},
+		{
+			msg:  "unexpected error",
+			path: "/SimpleService/Simple",
+			setup: func(args testArgs) {
+				args.s1.On("Simple", ctxArg()).Return(errors.New("unexpected"))
+			},
+			wantStatus: http.StatusBadGateway,
+			wantBody:   `{"error":"tchannel error ErrCodeUnexpected: unexpected"}`,
+		},
+		{
+			msg:        "invalid JSON",
+			path:       "/SimpleService/Call",
+			body:       `{`,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":"failed to decode JSON arguments: unexpected EOF"}`,
+		},
+		{
+			msg:        "unknown method",
+			path:       "/SimpleService/Unknown",
+			wantStatus: http.StatusNotFound,
+			wantBody:   `{"error":"method Unknown not found"}`,
+		},
+		{
+			msg:        "unknown service",
+			path:       "/SecondService/Echo",
+			wantStatus: http.StatusNotFound,
+			wantBody:   `{"error":"path /SecondService/Echo is not for service SimpleService"}`,
+		},
+		{
+			msg:        "GET not allowed",
+			method:     "GET",
+			path:       "/SimpleService/Simple",
+			wantStatus: http.StatusMethodNotAllowed,
+			wantBody:   `{"error":"method GET is not allowed, use POST"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.msg, func(t *testing.T) {
+			withSetup(t, func(ctx Context, args testArgs) {
+				if tt.setup != nil {
+					tt.setup(args)
+				}
+
+				method := tt.method
+				if method == "" {
+					method = "POST"
+				}
+
+				handler := gen.NewTChanSimpleServiceHTTPHandler(args.c1, time.Second)
+				req := httptest.NewRequest(method, tt.path, strings.NewReader(tt.body))
+				recorder := httptest.NewRecorder()
+				handler.ServeHTTP(recorder, req)
+
+				assert.Equal(t, tt.wantStatus, recorder.Code, "Unexpected status")
+				assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"), "Unexpected content type")
+				assert.JSONEq(t, tt.wantBody, recorder.Body.String(), "Unexpected body")
+			})
+		})
+	}
+}
+
 func TestRegisterPostResponseCB(t *testing.T) {
 	withSetup(t, func(ctx Context, args testArgs) {
 		var createdCtx Context
@@ -425,6 +625,163 @@ func TestRegisterPostResponseCBCalledOnError(t *testing.T) {
 	})
 }
 
+// testMiddleware is a Middleware that records calls and uses the
+// optional functions to override results.
+type testMiddleware struct {
+	name   string
+	calls  *[]string
+	before func(req thrift.TStruct) (bool, thrift.TStruct, error)
+	after  func(success bool, resp thrift.TStruct, err error) (bool, thrift.TStruct, error)
+}
+
+func (m testMiddleware) Before(ctx Context, service, method string, req thrift.TStruct) (bool, thrift.TStruct, error) {
+	*m.calls = append(*m.calls, fmt.Sprintf("%v.Before(%v::%v)", m.name, service, method))
+	if m.before != nil {
+		return m.before(req)
+	}
+	return false, nil, nil
+}
+
+func (m testMiddleware) After(ctx Context, service, method string, success bool, resp thrift.TStruct, err error) (bool, thrift.TStruct, error) {
+	*m.calls = append(*m.calls, fmt.Sprintf("%v.After(%v::%v)", m.name, service, method))
+	if m.after != nil {
+		return m.after(success, resp, err)
+	}
+	return success, resp, err
+}
+
+func TestMiddleware(t *testing.T) {
+	withSetup(t, func(ctx Context, args testArgs) {
+		arg := &gen.Data{B1: true, S2: "str", I3: 102}
+		ret := &gen.Data{B1: false, S2: "return-str", I3: 105}
+
+		var calls []string
+		m1 := testMiddleware{name: "m1", calls: &calls}
+		m1.before = func(req thrift.TStruct) (bool, thrift.TStruct, error) {
+			assert.Equal(t, &gen.SimpleServiceCallArgs{Arg: arg}, req, "unexpected decoded request")
+			return false, nil, nil
+		}
+		m2 := testMiddleware{name: "m2", calls: &calls}
+		m2.after = func(success bool, resp thrift.TStruct, err error) (bool, thrift.TStruct, error) {
+			assert.True(t, success, "expected success")
+			assert.NoError(t, err, "unexpected error")
+			assert.Equal(t, &gen.SimpleServiceCallResult{Success: ret}, resp, "unexpected response")
+			return success, resp, err
+		}
+		args.server.Register(gen.NewTChanSimpleServiceServer(args.s1), OptMiddleware(m1, m2))
+
+		args.s1.On("Call", ctxArg(), arg).Return(ret, nil)
+		res, err := args.c1.Call(ctx, arg)
+		require.NoError(t, err, "Call failed")
+		assert.Equal(t, ret, res, "Call return value wrong")
+		assert.Equal(t, []string{
+			"m1.Before(SimpleService::Call)",
+			"m2.Before(SimpleService::Call)",
+			"m2.After(SimpleService::Call)",
+			"m1.After(SimpleService::Call)",
+		}, calls, "unexpected middleware calls")
+	})
+}
+
+func TestMiddlewareShortCircuit(t *testing.T) {
+	withSetup(t, func(ctx Context, args testArgs) {
+		var calls []string
+		m1 := testMiddleware{name: "m1", calls: &calls}
+		m2 := testMiddleware{name: "m2", calls: &calls}
+		m2.before = func(req thrift.TStruct) (bool, thrift.TStruct, error) {
+			switch req.(type) {
+			case *gen.SimpleServiceSimpleArgs:
+				return false, &gen.SimpleServiceSimpleResult{SimpleErr: &gen.SimpleErr{Message: "rejected"}}, nil
+			case *gen.SimpleServiceSimpleFutureArgs:
+				return false, nil, tchannel.NewSystemError(tchannel.ErrCodeBusy, "overloaded")
+			}
+			return false, nil, nil
+		}
+		m3 := testMiddleware{name: "m3", calls: &calls}
+		args.server.Register(gen.NewTChanSimpleServiceServer(args.s1), OptMiddleware(m1, m2, m3))
+
+		// The handler should not be called, so there are no expectations on args.s1.
+		err := args.c1.Simple(ctx)
+		assert.Equal(t, &gen.SimpleErr{Message: "rejected"}, err, "expected exception from middleware")
+		assert.Equal(t, []string{
+			"m1.Before(SimpleService::Simple)",
+			"m2.Before(SimpleService::Simple)",
+			"m2.After(SimpleService::Simple)",
+			"m1.After(SimpleService::Simple)",
+		}, calls, "unexpected middleware calls")
+
+		calls = nil
+		nonRetryCtx, cancel := tchannel.NewContextBuilder(time.Second).
+			SetRetryOptions(&tchannel.RetryOptions{RetryOn: tchannel.RetryNever}).
+			Build()
+		defer cancel()
+		err = args.c1.SimpleFuture(nonRetryCtx)
+		assert.Equal(t, tchannel.ErrCodeBusy, tchannel.GetSystemErrorCode(err), "expected system error from middleware")
+		assert.Len(t, calls, 4, "unexpected middleware calls")
+	})
+}
+
+// argsUnsupportedServer hides the TChanArgsServer methods of a server.
+type argsUnsupportedServer struct {
+	TChanServer
+}
+
+func TestMiddlewareWithoutArgs(t *testing.T) {
+	withSetup(t, func(ctx Context, args testArgs) {
+		var calls []string
+		m := testMiddleware{name: "m", calls: &calls}
+		m.before = func(req thrift.TStruct) (bool, thrift.TStruct, error) {
+			assert.Nil(t, req, "request should not be decoded")
+			return false, nil, nil
+		}
+		server := argsUnsupportedServer{gen.NewTChanSimpleServiceServer(args.s1)}
+		args.server.Register(server, OptMiddleware(m))
+
+		args.s1.On("Simple", ctxArg()).Return(nil)
+		require.NoError(t, args.c1.Simple(ctx), "Simple failed")
+		assert.Equal(t, []string{"m.Before(SimpleService::Simple)", "m.After(SimpleService::Simple)"}, calls)
+	})
+}
+
+func TestMiddlewareWithoutArgsShortCircuit(t *testing.T) {
+	withSetup(t, func(ctx Context, args testArgs) {
+		ret := &gen.Data{B1: true, S2: "from-middleware", I3: 1}
+
+		var calls []string
+		m := testMiddleware{name: "m", calls: &calls}
+		m.before = func(req thrift.TStruct) (bool, thrift.TStruct, error) {
+			return true, &gen.SimpleServiceCallResult{Success: ret}, nil
+		}
+		server := argsUnsupportedServer{gen.NewTChanSimpleServiceServer(args.s1)}
+		args.server.Register(server, OptMiddleware(m))
+
+		// The handler should not be called, so there are no expectations on args.s1.
+		res, err := args.c1.Call(ctx, &gen.Data{S2: "unread request body"})
+		require.NoError(t, err, "Call failed")
+		assert.Equal(t, ret, res, "Call should return the middleware's response")
+		assert.Equal(t, []string{"m.Before(SimpleService::Call)", "m.After(SimpleService::Call)"}, calls)
+	})
+}
+
+func TestMiddlewareNilResponse(t *testing.T) {
+	withSetup(t, func(ctx Context, args testArgs) {
+		var calls []string
+		m := testMiddleware{name: "m", calls: &calls}
+		m.after = func(success bool, resp thrift.TStruct, err error) (bool, thrift.TStruct, error) {
+			return true, nil, nil
+		}
+		args.server.Register(gen.NewTChanSimpleServiceServer(args.s1), OptMiddleware(m))
+
+		args.s1.On("Simple", ctxArg()).Return(nil)
+		nonRetryCtx, cancel := tchannel.NewContextBuilder(time.Second).
+			SetRetryOptions(&tchannel.RetryOptions{RetryOn: tchannel.RetryNever}).
+			Build()
+		defer cancel()
+		err := args.c1.Simple(nonRetryCtx)
+		assert.Equal(t, tchannel.ErrCodeUnexpected, tchannel.GetSystemErrorCode(err), "expected system error for nil response")
+	})
+}
+
 func TestThriftTimeout(t *testing.T) {
 	withSetup(t, func(ctx Context, args testArgs) {
 		handler := make(chan struct{})
@@ -449,6 +806,82 @@ func TestThriftTimeout(t *testing.T) {
 	})
 }
 
+func TestMethodOptionsShardKey(t *testing.T) {
+	withSetup(t, func(ctx Context, args testArgs) {
+		var shardKeys []string
+		args.s1.On("Simple", ctxArg()).Return(nil).Run(func(args mock.Arguments) {
+			ctx := args.Get(0).(context.Context)
+			shardKeys = append(shardKeys, tchannel.CurrentCall(ctx).ShardKey())
+		})
+
+		optsCtx, cancel := WithMethodOptions(ctx, MethodOptions{ShardKey: "annotated"})
+		defer cancel()
+		require.NoError(t, args.c1.Simple(optsCtx), "Simple failed")
+
+		// A shard key set by the caller overrides the method options.
+		callerCtx, callerCancel := tchannel.NewContextBuilder(time.Second).SetShardKey("caller").Build()
+		defer callerCancel()
+		optsCtx, cancel = WithMethodOptions(callerCtx, MethodOptions{ShardKey: "annotated"})
+		defer cancel()
+		require.NoError(t, args.c1.Simple(optsCtx), "Simple failed")
+
+		assert.Equal(t, []string{"annotated", "caller"}, shardKeys, "Unexpected shard keys")
+	})
+}
+
+func TestMethodOptionsTimeout(t *testing.T) {
+	withSetup(t, func(ctx Context, args testArgs) {
+		var deadlines []time.Duration
+		args.s1.On("Simple", ctxArg()).Return(nil).Run(func(args mock.Arguments) {
+			ctx := args.Get(0).(context.Context)
+			deadline, ok := ctx.Deadline()
+			require.True(t, ok, "Missing deadline")
+			deadlines = append(deadlines, deadline.Sub(time.Now()))
+		})
+
+		optsCtx, cancel := WithMethodOptions(ctx, MethodOptions{Timeout: testutils.Timeout(100 * time.Millisecond)})
+		defer cancel()
+		require.NoError(t, args.c1.Simple(optsCtx), "Simple failed")
+
+		// An earlier deadline on the caller's Context is used over the method timeout.
+		shortCtx, shortCancel := NewContext(testutils.Timeout(50 * time.Millisecond))
+		defer shortCancel()
+		optsCtx, cancel = WithMethodOptions(shortCtx, MethodOptions{Timeout: time.Minute})
+		defer cancel()
+		require.NoError(t, args.c1.Simple(optsCtx), "Simple failed")
+
+		require.Len(t, deadlines, 2, "Unexpected number of calls")
+		assert.True(t, deadlines[0] <= testutils.Timeout(100*time.Millisecond), "Method timeout not applied")
+		assert.True(t, deadlines[1] <= testutils.Timeout(50*time.Millisecond), "Caller deadline not applied")
+	})
+}
+
+func TestMethodOptionsRetryOn(t *testing.T) {
+	withSetup(t, func(ctx Context, args testArgs) {
+		count := 0
+		args.s1.On("Simple", ctxArg()).Return(tchannel.ErrServerBusy).
+			Run(func(args mock.Arguments) {
+				count++
+			})
+
+		optsCtx, cancel := WithMethodOptions(ctx, MethodOptions{RetryOn: tchannel.RetryNever})
+		defer cancel()
+		require.Error(t, args.c1.Simple(optsCtx), "Simple expected to fail")
+		assert.Equal(t, 1, count, "Expected Simple not to be retried")
+
+		// Retry options set by the caller override the method options.
+		count = 0
+		callerCtx, callerCancel := tchannel.NewContextBuilder(time.Second).
+			SetRetryOptions(&tchannel.RetryOptions{RetryOn: tchannel.RetryUnexpected, MaxAttempts: 3}).
+			Build()
+		defer callerCancel()
+		optsCtx, cancel = WithMethodOptions(callerCtx, MethodOptions{RetryOn: tchannel.RetryNever})
+		defer cancel()
+		require.Error(t, args.c1.Simple(optsCtx), "Simple expected to fail")
+		assert.Equal(t, 3, count, "Expected Simple to be retried 3 times")
+	})
+}
+
 func TestThriftContextFn(t *testing.T) {
 	withSetup(t, func(ctx Context, args testArgs) {
 		args.server.SetContextFn(func(ctx context.Context, method string, headers map[string]string) Context {
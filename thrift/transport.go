@@ -22,9 +22,12 @@ package thrift
 
 import (
 	"errors"
+	"fmt"
 	"io"
 	"sync"
 
+	"github.com/uber/tchannel-go"
+
 	"github.com/apache/thrift/lib/go/thrift"
 )
 
@@ -110,28 +113,100 @@ func (t *readWriterTransport) RemainingBytes() uint64 {
 
 var _ thrift.TRichTransport = &readWriterTransport{}
 
+// Protocol is the Thrift protocol used to encode the call arguments and results.
+type Protocol int
+
+const (
+	// BinaryProtocol is the Thrift binary protocol, and is the default.
+	BinaryProtocol Protocol = iota
+
+	// CompactProtocol is the Thrift compact protocol, which uses variable-length
+	// integers and field ID deltas, making it smaller on the wire for large structs.
+	// It is only supported by servers that understand CompactFormat.
+	CompactProtocol
+)
+
+// CompactFormat is the arg scheme sent in the "as" header by clients that
+// encode calls using CompactProtocol. Servers use the same protocol for the response.
+const CompactFormat tchannel.Format = "thrift+compact"
+
+func (p Protocol) String() string {
+	switch p {
+	case BinaryProtocol:
+		return "binary"
+	case CompactProtocol:
+		return "compact"
+	default:
+		return fmt.Sprintf("Protocol(%d)", int(p))
+	}
+}
+
+// format returns the arg scheme used to signal the protocol to the server.
+func (p Protocol) format() tchannel.Format {
+	if p == CompactProtocol {
+		return CompactFormat
+	}
+	return tchannel.Thrift
+}
+
+// protocolForFormat returns the protocol used by a call with the given arg scheme.
+// Callers that do not set the arg scheme, or use "thrift", use the binary protocol.
+func protocolForFormat(f tchannel.Format) Protocol {
+	if f == CompactFormat {
+		return CompactProtocol
+	}
+	return BinaryProtocol
+}
+
 type thriftProtocol struct {
 	transport *readWriterTransport
-	protocol  *thrift.TBinaryProtocol
+	protocol  thrift.TProtocol
+	kind      Protocol
 }
 
-var thriftProtocolPool = sync.Pool{
-	New: func() interface{} {
-		transport := &readWriterTransport{}
-		protocol := thrift.NewTBinaryProtocolTransport(transport)
-		return &thriftProtocol{transport, protocol}
+var thriftProtocolPools = [...]sync.Pool{
+	BinaryProtocol: {
+		New: func() interface{} {
+			transport := &readWriterTransport{}
+			protocol := thrift.NewTBinaryProtocolTransport(transport)
+			return &thriftProtocol{transport, protocol, BinaryProtocol}
+		},
 	},
+	CompactProtocol: {
+		New: func() interface{} {
+			transport := &readWriterTransport{}
+			protocol := thrift.NewTCompactProtocol(transport)
+			return &thriftProtocol{transport, protocol, CompactProtocol}
+		},
+	},
+}
+
+func getProtocol(p Protocol) *thriftProtocol {
+	if p < 0 || int(p) >= len(thriftProtocolPools) {
+		p = BinaryProtocol
+	}
+	return thriftProtocolPools[p].Get().(*thriftProtocol)
+}
+
+// putProtocol returns the protocol to its pool. err is the error from using the
+// protocol: TCompactProtocol keeps state between calls, such as the last field
+// ID, so a compact protocol that failed part way through a struct is discarded.
+func putProtocol(wp *thriftProtocol, err error) {
+	if err != nil && wp.kind == CompactProtocol {
+		return
+	}
+	thriftProtocolPools[wp.kind].Put(wp)
 }
 
-func getProtocolWriter(writer io.Writer) *thriftProtocol {
-	wp := thriftProtocolPool.Get().(*thriftProtocol)
+func getProtocolWriter(p Protocol, writer io.Writer) *thriftProtocol {
+	wp := getProtocol(p)
 	wp.transport.Reader = nil
 	wp.transport.Writer = writer
 	return wp
 }
 
-func getProtocolReader(reader io.Reader) *thriftProtocol {
-	wp := thriftProtocolPool.Get().(*thriftProtocol)
+func getProtocolReader(p Protocol, reader io.Reader) *thriftProtocol {
+	wp := getProtocol(p)
 	wp.transport.Reader = reader
 	wp.transport.Writer = nil
 	return wp
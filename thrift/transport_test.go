@@ -22,17 +22,21 @@ package thrift
 
 import (
 	"bytes"
+	"errors"
 	"io"
 	"testing"
 
+	"github.com/uber/tchannel-go"
 	"github.com/uber/tchannel-go/testutils/testreader"
 	"github.com/uber/tchannel-go/testutils/testwriter"
 
+	"github.com/apache/thrift/lib/go/thrift"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func writeByte(writer io.Writer, b byte) error {
-	protocol := getProtocolWriter(writer)
+	protocol := getProtocolWriter(BinaryProtocol, writer)
 	return protocol.transport.WriteByte(b)
 }
 
@@ -55,7 +59,7 @@ func TestWriteByteFailed(t *testing.T) {
 
 func TestReadByte0Byte(t *testing.T) {
 	chunkWriter, chunkReader := testreader.ChunkReader()
-	reader := getProtocolReader(chunkReader)
+	reader := getProtocolReader(BinaryProtocol, chunkReader)
 
 	chunkWriter <- []byte{}
 	chunkWriter <- []byte{}
@@ -78,3 +82,83 @@ func TestReadByte0Byte(t *testing.T) {
 	b, err = reader.transport.ReadByte()
 	assert.Equal(t, io.EOF, err, "ReadByte should EOF")
 }
+
+func TestProtocolRoundTrip(t *testing.T) {
+	sizes := make(map[Protocol]int)
+	for _, p := range []Protocol{BinaryProtocol, CompactProtocol} {
+		buf := &bytes.Buffer{}
+		wp := getProtocolWriter(p, buf)
+		require.NoError(t, wp.protocol.WriteStructBegin("s"), "%v: write struct failed", p)
+		require.NoError(t, wp.protocol.WriteFieldBegin("f", thrift.I32, 1), "%v: write field failed", p)
+		require.NoError(t, wp.protocol.WriteI32(5), "%v: write i32 failed", p)
+		require.NoError(t, wp.protocol.WriteFieldStop(), "%v: write stop failed", p)
+		require.NoError(t, wp.protocol.WriteStructEnd(), "%v: write struct end failed", p)
+		putProtocol(wp, nil)
+		sizes[p] = buf.Len()
+
+		rp := getProtocolReader(p, buf)
+		_, err := rp.protocol.ReadStructBegin()
+		require.NoError(t, err, "%v: read struct failed", p)
+		_, typeID, id, err := rp.protocol.ReadFieldBegin()
+		require.NoError(t, err, "%v: read field failed", p)
+		assert.EqualValues(t, thrift.I32, typeID, "%v: field type mismatch", p)
+		assert.EqualValues(t, 1, id, "%v: field ID mismatch", p)
+		v, err := rp.protocol.ReadI32()
+		require.NoError(t, err, "%v: read i32 failed", p)
+		assert.EqualValues(t, 5, v, "%v: value mismatch", p)
+		putProtocol(rp, nil)
+	}
+	assert.True(t, sizes[CompactProtocol] < sizes[BinaryProtocol],
+		"Compact protocol should be smaller than binary, got %v", sizes)
+}
+
+func TestCompactProtocolReuseAfterError(t *testing.T) {
+	buf := &bytes.Buffer{}
+	wp := getProtocolWriter(CompactProtocol, buf)
+	require.NoError(t, wp.protocol.WriteStructBegin("s"), "write struct failed")
+	require.NoError(t, wp.protocol.WriteFieldBegin("f", thrift.BOOL, 1), "write field failed")
+	require.NoError(t, wp.protocol.WriteBool(true), "write bool failed")
+	putProtocol(wp, nil)
+
+	// The compact protocol stores a bool field's value in the field header, and
+	// keeps it until ReadBool is called. Fail the decode before then.
+	rp := getProtocolReader(CompactProtocol, buf)
+	_, err := rp.protocol.ReadStructBegin()
+	require.NoError(t, err, "read struct failed")
+	_, _, _, err = rp.protocol.ReadFieldBegin()
+	require.NoError(t, err, "read field failed")
+	putProtocol(rp, errors.New("decode failed"))
+
+	buf.Reset()
+	wp = getProtocolWriter(CompactProtocol, buf)
+	require.NoError(t, wp.protocol.WriteListBegin(thrift.BOOL, 1), "write list failed")
+	require.NoError(t, wp.protocol.WriteBool(false), "write bool failed")
+	require.NoError(t, wp.protocol.WriteListEnd(), "write list end failed")
+	putProtocol(wp, nil)
+
+	rp = getProtocolReader(CompactProtocol, buf)
+	_, _, err = rp.protocol.ReadListBegin()
+	require.NoError(t, err, "read list failed")
+	v, err := rp.protocol.ReadBool()
+	require.NoError(t, err, "read bool failed")
+	assert.False(t, v, "Pooled protocol should not use state from the failed decode")
+	putProtocol(rp, nil)
+}
+
+func TestProtocolForFormat(t *testing.T) {
+	tests := []struct {
+		format tchannel.Format
+		want   Protocol
+	}{
+		{"", BinaryProtocol},
+		{tchannel.Thrift, BinaryProtocol},
+		{CompactFormat, CompactProtocol},
+		{tchannel.JSON, BinaryProtocol},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, protocolForFormat(tt.format), "protocolForFormat(%q)", tt.format)
+	}
+
+	assert.Equal(t, tchannel.Thrift, BinaryProtocol.format(), "Binary protocol format")
+	assert.Equal(t, CompactFormat, CompactProtocol.format(), "Compact protocol format")
+}
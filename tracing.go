@@ -29,6 +29,7 @@ import (
 
 	"github.com/opentracing/opentracing-go"
 	"github.com/opentracing/opentracing-go/ext"
+	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/net/context"
 )
 
@@ -117,7 +118,8 @@ func (s *injectableSpan) initFromOpenTracing(span opentracing.Span) error {
 
 // CurrentSpan extracts OpenTracing Span from the Context, and if found tries to
 // extract zipkin-style trace/span IDs from it using ZipkinSpanFormat carrier.
-// If there is no OpenTracing Span in the Context, an empty span is returned.
+// Otherwise, the IDs are taken from the OpenTelemetry span in the Context.
+// If there is no span in the Context, an empty span is returned.
 func CurrentSpan(ctx context.Context) *Span {
 	if sp := opentracing.SpanFromContext(ctx); sp != nil {
 		var injectable injectableSpan
@@ -126,6 +128,11 @@ func CurrentSpan(ctx context.Context) *Span {
 			return &span
 		}
 		// return empty span on error, instead of possibly a partially filled one
+		return &emptySpan
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		span := spanFromOTel(sc, trace.SpanContext{})
+		return &span
 	}
 	return &emptySpan
 }
@@ -169,18 +176,24 @@ func (c *Connection) startOutboundSpan(ctx context.Context, serviceName, methodN
 //
 // Sometimes caller pass a shared instance of the `headers` map, so instead of modifying
 // it we clone it into the new map (assuming that Tracer actually injects some tracing keys).
+//
+// When OpenTelemetry is enabled, the span context is serialized using the W3C
+// traceparent and tracestate formats.
 func InjectOutboundSpan(response *OutboundCallResponse, headers map[string]string) map[string]string {
-	span := response.span
-	if span == nil {
+	var newHeaders map[string]string
+	if span := response.otelSpan; span != nil {
+		newHeaders = injectOutboundOTelSpan(span)
+	} else if span := response.span; span != nil {
+		newHeaders = make(map[string]string)
+		carrier := tracingHeadersCarrier(newHeaders)
+		if err := span.Tracer().Inject(span.Context(), opentracing.TextMap, carrier); err != nil {
+			// Something had to go seriously wrong for Inject to fail, usually a setup problem.
+			// A good Tracer implementation may also emit a metric.
+			response.log.WithFields(ErrField(err)).Error("Failed to inject tracing span.")
+		}
+	} else {
 		return headers
 	}
-	newHeaders := make(map[string]string)
-	carrier := tracingHeadersCarrier(newHeaders)
-	if err := span.Tracer().Inject(span.Context(), opentracing.TextMap, carrier); err != nil {
-		// Something had to go seriously wrong for Inject to fail, usually a setup problem.
-		// A good Tracer implementation may also emit a metric.
-		response.log.WithFields(ErrField(err)).Error("Failed to inject tracing span.")
-	}
 	if len(newHeaders) == 0 {
 		return headers // Tracer did not add any tracing headers, so return the original map
 	}
@@ -226,7 +239,14 @@ func (c *Connection) extractInboundSpan(callReq *callReq) opentracing.Span {
 // by all tracers is used to deserialize the tracing context from the
 // application headers and start a new server-side span.
 // Once the span is started, it is wrapped in a new Context, which is returned.
+//
+// When OpenTelemetry is enabled, tracer is ignored, and the span is started using
+// the W3C span context in the headers, or the frame's tracing IDs if it's missing.
 func ExtractInboundSpan(ctx context.Context, call *InboundCall, headers map[string]string, tracer opentracing.Tracer) context.Context {
+	if call.conn != nil && call.conn.otelTracer != nil {
+		return extractInboundOTelSpanFromHeaders(ctx, call, headers)
+	}
+
 	var span = call.Response().span
 	if span != nil {
 		if headers != nil {
@@ -0,0 +1,264 @@
+// Copyright (c) 2015 Uber Technologies, Inc.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package tchannel
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/net/context"
+)
+
+// otelInstrumentationName is the instrumentation name used when creating
+// OpenTelemetry tracers and meters.
+const otelInstrumentationName = "github.com/uber/tchannel-go"
+
+// otelPropagator serializes the OpenTelemetry span context into application
+// headers using the W3C traceparent, tracestate and baggage formats.
+var otelPropagator = propagation.NewCompositeTextMapPropagator(
+	propagation.TraceContext{},
+	propagation.Baggage{},
+)
+
+// newOTelTracer returns the Tracer used for OpenTelemetry spans, or nil if
+// no TracerProvider is set, in which case OpenTracing is used.
+func newOTelTracer(provider trace.TracerProvider) trace.Tracer {
+	if provider == nil {
+		return nil
+	}
+	return provider.Tracer(otelInstrumentationName, trace.WithInstrumentationVersion(VersionInfo))
+}
+
+// otelHeadersCarrier adapts application headers to an OpenTelemetry
+// TextMapCarrier. Keys are prefixed in the same way as OpenTracing keys,
+// so they are hidden from the application. This means the W3C headers are
+// sent as "$tracing$traceparent" and "$tracing$tracestate", which are not
+// understood by other TChannel implementations.
+type otelHeadersCarrier tracingHeadersCarrier
+
+// Get implements propagation.TextMapCarrier.
+func (c otelHeadersCarrier) Get(key string) string {
+	return c[tracingKeyEncoding.mapAndCache(key)]
+}
+
+// Set implements propagation.TextMapCarrier.
+func (c otelHeadersCarrier) Set(key, val string) {
+	tracingHeadersCarrier(c).Set(key, val)
+}
+
+// Keys implements propagation.TextMapCarrier.
+func (c otelHeadersCarrier) Keys() []string {
+	var keys []string
+	tracingHeadersCarrier(c).ForeachKey(func(key, _ string) error {
+		keys = append(keys, key)
+		return nil
+	})
+	return keys
+}
+
+// spanFromOTel maps an OpenTelemetry span context onto the frame's Span.
+// The frame only has space for 64-bit IDs, so the low 64 bits of the
+// 128-bit trace ID are used. The full trace ID is propagated using the
+// W3C traceparent application header for formats that support headers.
+func spanFromOTel(sc, parent trace.SpanContext) Span {
+	traceID := sc.TraceID()
+	spanID := sc.SpanID()
+	parentID := parent.SpanID()
+
+	span := Span{
+		traceID:  binary.BigEndian.Uint64(traceID[8:]),
+		spanID:   binary.BigEndian.Uint64(spanID[:]),
+		parentID: binary.BigEndian.Uint64(parentID[:]),
+	}
+	if sc.IsSampled() {
+		span.flags = 1
+	}
+	return span
+}
+
+// otelSpanContext maps the frame's Span onto an OpenTelemetry span context.
+// It returns an invalid span context if the Span is not set.
+func (s Span) otelSpanContext() trace.SpanContext {
+	var (
+		traceID trace.TraceID
+		spanID  trace.SpanID
+		flags   trace.TraceFlags
+	)
+	binary.BigEndian.PutUint64(traceID[8:], s.traceID)
+	binary.BigEndian.PutUint64(spanID[:], s.spanID)
+	if s.flags&1 == 1 {
+		flags = trace.FlagsSampled
+	}
+	return trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: flags,
+		Remote:     true,
+	})
+}
+
+// startOutboundOTelSpan is the OpenTelemetry equivalent of startOutboundSpan.
+// The span's IDs are copied into call.callReq.Tracing.
+func (c *Connection) startOutboundOTelSpan(ctx context.Context, serviceName, methodName string, call *OutboundCall, startTime time.Time) trace.Span {
+	if isTracingDisabled(ctx) {
+		call.callReq.Tracing.initRandom()
+		return nil
+	}
+
+	parent := trace.SpanContextFromContext(ctx)
+	_, span := c.otelTracer.Start(ctx, methodName,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithTimestamp(startTime),
+		trace.WithAttributes(
+			attribute.String("rpc.system", "tchannel"),
+			attribute.String("rpc.service", serviceName),
+			attribute.String("rpc.method", methodName),
+			attribute.String("peer.service", serviceName),
+			attribute.String("as", call.callReq.Headers[ArgScheme]),
+		),
+	)
+	c.setOTelPeerHostPort(span)
+
+	if sc := span.SpanContext(); sc.IsValid() {
+		call.callReq.Tracing = spanFromOTel(sc, parent)
+	} else {
+		call.callReq.Tracing.initRandom()
+	}
+	return span
+}
+
+// otelFormatHasHeaders returns whether the arg scheme uses application headers,
+// and so can propagate the W3C span context with the full 128-bit trace ID.
+func otelFormatHasHeaders(as string) bool {
+	return as == JSON.String() || strings.HasPrefix(as, Thrift.String())
+}
+
+// extractInboundOTelSpan is the OpenTelemetry equivalent of extractInboundSpan.
+// Formats with application headers start the span in ExtractInboundSpan using
+// the W3C span context, so it returns nil for those formats. Otherwise, the
+// frame's Span is used as the parent, and the name is set once the method is read.
+func (c *Connection) extractInboundOTelSpan(callReq *callReq, startTime time.Time) trace.Span {
+	if otelFormatHasHeaders(callReq.Headers[ArgScheme]) {
+		return nil
+	}
+	ctx := trace.ContextWithRemoteSpanContext(context.Background(), callReq.Tracing.otelSpanContext())
+	return c.startInboundOTelSpan(ctx, "", callReq.Service, callReq.Headers, startTime)
+}
+
+func (c *Connection) startInboundOTelSpan(ctx context.Context, methodName, serviceName string, headers transportHeaders, startTime time.Time) trace.Span {
+	_, span := c.otelTracer.Start(ctx, methodName,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithTimestamp(startTime),
+		trace.WithAttributes(
+			attribute.String("rpc.system", "tchannel"),
+			attribute.String("rpc.service", serviceName),
+			attribute.String("peer.service", headers[CallerName]),
+			attribute.String("as", headers[ArgScheme]),
+		),
+	)
+	if methodName != "" {
+		span.SetAttributes(attribute.String("rpc.method", methodName))
+	}
+	c.setOTelPeerHostPort(span)
+	return span
+}
+
+// extractInboundOTelSpanFromHeaders is the OpenTelemetry equivalent of ExtractInboundSpan.
+// The W3C span context in the headers is preferred as it has the full 128-bit
+// trace ID, and the frame's Span is used if it's missing.
+func extractInboundOTelSpanFromHeaders(ctx context.Context, call *InboundCall, headers map[string]string) context.Context {
+	frameSC := call.tracing.otelSpanContext()
+	parentCtx := otelPropagator.Extract(ctx, otelHeadersCarrier(headers))
+	tracingHeadersCarrier(headers).RemoveTracingKeys()
+
+	parentSC := trace.SpanContextFromContext(parentCtx)
+	if !parentSC.IsValid() {
+		parentSC = frameSC
+	} else if sameLowTraceID(parentSC.TraceID(), frameSC.TraceID()) {
+		// A relay may have rewritten the frame's span ID to its own span,
+		// which is a more direct parent than the caller's span in the headers.
+		parentSC = parentSC.WithSpanID(frameSC.SpanID())
+	}
+	parentCtx = trace.ContextWithRemoteSpanContext(parentCtx, parentSC)
+
+	response := call.Response()
+	if response.otelSpan == nil {
+		response.otelSpan = call.conn.startInboundOTelSpan(parentCtx, call.MethodString(), call.serviceName, call.headers, response.calledAt)
+	}
+	return trace.ContextWithSpan(parentCtx, response.otelSpan)
+}
+
+// sameLowTraceID returns whether the trace IDs match in the low 64 bits, which
+// are the only bits propagated in the frame's tracing fields.
+func sameLowTraceID(a, b trace.TraceID) bool {
+	return bytes.Equal(a[8:], b[8:])
+}
+
+// injectOutboundOTelSpan serializes the span context into new headers.
+func injectOutboundOTelSpan(span trace.Span) map[string]string {
+	ctx := trace.ContextWithSpan(context.Background(), span)
+	newHeaders := make(map[string]string)
+	otelPropagator.Inject(ctx, otelHeadersCarrier(newHeaders))
+	return newHeaders
+}
+
+// finishOTelSpan ends the span, recording err and marking the span as failed if needed.
+func finishOTelSpan(span trace.Span, err error, failed bool, now time.Time) {
+	if err != nil {
+		span.RecordError(err, trace.WithTimestamp(now))
+	}
+	if failed {
+		msg := ""
+		if err != nil {
+			msg = err.Error()
+		}
+		span.SetStatus(codes.Error, msg)
+	}
+	span.End(trace.WithTimestamp(now))
+}
+
+func (c *Connection) setOTelPeerHostPort(span trace.Span) {
+	if !span.IsRecording() {
+		return
+	}
+	addr := c.remotePeerAddress
+	if addr.ipv4 != 0 {
+		var ip [4]byte
+		binary.BigEndian.PutUint32(ip[:], addr.ipv4)
+		span.SetAttributes(attribute.String("net.sock.peer.addr", net.IP(ip[:]).String()))
+	}
+	if addr.ipv6 != "" {
+		span.SetAttributes(attribute.String("net.sock.peer.addr", addr.ipv6))
+	}
+	if addr.hostname != "" {
+		span.SetAttributes(attribute.String("net.peer.name", addr.hostname))
+	}
+	if addr.port != 0 {
+		span.SetAttributes(attribute.Int("net.peer.port", int(addr.port)))
+	}
+}
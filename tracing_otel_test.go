@@ -0,0 +1,165 @@
+// Copyright (c) 2015 Uber Technologies, Inc.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package tchannel_test
+
+import (
+	"encoding/binary"
+	"testing"
+	"time"
+
+	. "github.com/uber/tchannel-go"
+	"github.com/uber/tchannel-go/json"
+	"github.com/uber/tchannel-go/raw"
+	"github.com/uber/tchannel-go/testutils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/net/context"
+)
+
+func otelTestOpts() (*testutils.ChannelOpts, *sdktrace.TracerProvider, *tracetest.SpanRecorder) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	opts := testutils.NewOpts().NoRelay()
+	opts.TracerProvider = provider
+	return opts, provider, recorder
+}
+
+// waitForEndedSpans waits for n spans to end after the first skip spans, since
+// spans are ended on different goroutines, possibly after the response is received.
+func waitForEndedSpans(t testing.TB, recorder *tracetest.SpanRecorder, skip, n int) []sdktrace.ReadOnlySpan {
+	require.True(t, testutils.WaitFor(time.Second, func() bool {
+		return len(recorder.Ended()) == skip+n
+	}), "Timed out waiting for spans to end")
+	return recorder.Ended()[skip:]
+}
+
+// waitForOTelSpans waits for the client and server spans after the first skip spans.
+func waitForOTelSpans(t testing.TB, recorder *tracetest.SpanRecorder, skip int) (client, server sdktrace.ReadOnlySpan) {
+	for _, s := range waitForEndedSpans(t, recorder, skip, 2) {
+		switch s.SpanKind() {
+		case trace.SpanKindClient:
+			client = s
+		case trace.SpanKindServer:
+			server = s
+		}
+	}
+	require.NotNil(t, client, "Missing client span")
+	require.NotNil(t, server, "Missing server span")
+	return client, server
+}
+
+func otelAttr(s sdktrace.ReadOnlySpan, key string) attribute.Value {
+	for _, kv := range s.Attributes() {
+		if string(kv.Key) == key {
+			return kv.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+func TestOTelTracingJSON(t *testing.T) {
+	opts, provider, recorder := otelTestOpts()
+	testutils.WithTestServer(t, opts, func(t testing.TB, ts *testutils.TestServer) {
+		var (
+			handlerSpan    trace.SpanContext
+			handlerHeaders map[string]string
+		)
+		handler := func(ctx json.Context, req map[string]string) (map[string]string, error) {
+			handlerSpan = trace.SpanContextFromContext(ctx)
+			handlerHeaders = ctx.Headers()
+			return req, nil
+		}
+		json.Register(ts.Server(), json.Handlers{"call": handler}, nil)
+
+		rootCtx, root := provider.Tracer("test").Start(context.Background(), "root")
+		defer root.End()
+
+		ctx, cancel := NewContextBuilder(time.Second).SetParentContext(rootCtx).Build()
+		defer cancel()
+
+		var res map[string]string
+		peer := ts.Server().Peers().GetOrAdd(ts.HostPort())
+		require.NoError(t, json.CallPeer(json.WithHeaders(ctx, map[string]string{"app": "header"}), peer, ts.ServiceName(), "call", map[string]string{}, &res), "Call failed")
+
+		clientSpan, serverSpan := waitForOTelSpans(t, recorder, 0)
+		traceID := root.SpanContext().TraceID()
+		assert.Equal(t, traceID, clientSpan.SpanContext().TraceID(), "Client span should be in the root trace")
+		assert.Equal(t, root.SpanContext().SpanID(), clientSpan.Parent().SpanID(), "Client span parent should be root")
+		assert.Equal(t, traceID, serverSpan.SpanContext().TraceID(), "Full trace ID should be propagated using headers")
+		assert.Equal(t, clientSpan.SpanContext().SpanID(), serverSpan.Parent().SpanID(), "Server span parent should be client span")
+		assert.Equal(t, serverSpan.SpanContext(), handlerSpan, "Handler context should have the server span")
+		assert.Equal(t, map[string]string{"app": "header"}, handlerHeaders, "Tracing headers should be hidden from the handler")
+
+		for _, s := range []sdktrace.ReadOnlySpan{clientSpan, serverSpan} {
+			assert.Equal(t, "call", s.Name(), "Unexpected span name")
+			assert.Equal(t, "tchannel", otelAttr(s, "rpc.system").AsString(), "Unexpected rpc.system")
+			assert.Equal(t, "call", otelAttr(s, "rpc.method").AsString(), "Unexpected rpc.method")
+			assert.Equal(t, "json", otelAttr(s, "as").AsString(), "Unexpected arg scheme")
+			assert.Equal(t, codes.Unset, s.Status().Code, "Unexpected status")
+		}
+	})
+}
+
+func TestOTelTracingRaw(t *testing.T) {
+	opts, provider, recorder := otelTestOpts()
+	testutils.WithTestServer(t, opts, func(t testing.TB, ts *testutils.TestServer) {
+		var handlerSpan *Span
+		testutils.RegisterFunc(ts.Server(), "fail", func(ctx context.Context, args *raw.Args) (*raw.Res, error) {
+			handlerSpan = CurrentSpan(ctx)
+			return &raw.Res{IsErr: true}, nil
+		})
+
+		rootCtx, root := provider.Tracer("test").Start(context.Background(), "root")
+		defer root.End()
+
+		ctx, cancel := NewContextBuilder(time.Second).SetParentContext(rootCtx).Build()
+		defer cancel()
+
+		_, _, resp, err := raw.Call(ctx, ts.Server(), ts.HostPort(), ts.ServiceName(), "fail", nil, nil)
+		require.NoError(t, err, "Call failed")
+		assert.True(t, resp.ApplicationError(), "Expected application error")
+
+		clientSpan, serverSpan := waitForOTelSpans(t, recorder, 0)
+
+		// Raw calls only propagate the frame's 64-bit IDs.
+		clientTraceID := clientSpan.SpanContext().TraceID()
+		serverTraceID := serverSpan.SpanContext().TraceID()
+		assert.Equal(t, clientTraceID[8:], serverTraceID[8:], "Low 64 bits of the trace ID should be propagated")
+		assert.Equal(t, clientSpan.SpanContext().SpanID(), serverSpan.Parent().SpanID(), "Server span parent should be client span")
+		assert.True(t, serverSpan.Parent().IsRemote(), "Server span parent should be remote")
+		assert.True(t, serverSpan.SpanContext().IsSampled(), "Sampled flag should be propagated")
+
+		serverSpanID := serverSpan.SpanContext().SpanID()
+		assert.Equal(t, binary.BigEndian.Uint64(serverSpanID[:]), handlerSpan.SpanID(), "CurrentSpan should use the server span")
+		assert.Equal(t, "fail", serverSpan.Name(), "Server span name should be set once the method is read")
+
+		for _, s := range []sdktrace.ReadOnlySpan{clientSpan, serverSpan} {
+			assert.Equal(t, codes.Error, s.Status().Code, "Application errors should mark the span as failed")
+			assert.Equal(t, "raw", otelAttr(s, "as").AsString(), "Unexpected arg scheme")
+		}
+	})
+}